@@ -0,0 +1,213 @@
+package zeroeventhub
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrIPNotAllowed = NewAPIError("client IP not allowed", http.StatusForbidden)
+	ErrRateLimited  = NewAPIError("rate limit exceeded", http.StatusTooManyRequests)
+)
+
+// ParseCIDRs parses IP ranges in CIDR notation, e.g. "10.0.0.0/8", for AllowIPs and ClientIPResolver. A single IP
+// is accepted as well.
+func ParseCIDRs(cidrs ...string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", cidr)
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(8*len(ip), 8*len(ip))})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIPResolver finds the IP of the client of a request. Without a Header it is the remote address of the
+// connection. Behind proxies, Header names the header they put the address of their client in, e.g.
+// X-Forwarded-For; it is only trusted when the connection comes from one of TrustedProxies, and addresses of
+// TrustedProxies in it are skipped, so that clients can't choose their IP by sending the header themselves.
+type ClientIPResolver struct {
+	Header         string
+	TrustedProxies []*net.IPNet
+}
+
+// ClientIP returns the IP of the client of request, or nil if the remote address can't be parsed.
+func (r ClientIPResolver) ClientIP(request *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || r.Header == "" || !containsIP(r.TrustedProxies, ip) {
+		return ip
+	}
+	// each proxy appends the address of its client, so the last address not of a trusted proxy is the client
+	hops := strings.Split(strings.Join(request.Header.Values(r.Header), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !containsIP(r.TrustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// AllowIPs wraps a handler, e.g. the one returned by Handler, rejecting requests from clients with an IP outside
+// allowed with 403. To allowlist several feeds differently, wrap the handler of each with its own list.
+func AllowIPs(handler http.Handler, allowed []*net.IPNet, resolver ClientIPResolver) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if ip := resolver.ClientIP(request); ip == nil || !containsIP(allowed, ip) {
+			http.Error(writer, ErrIPNotAllowed.Error(), ErrIPNotAllowed.Status())
+			return
+		}
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+// RateLimitOptions configures RateLimit.
+type RateLimitOptions struct {
+	// RequestsPerSecond is the sustained rate allowed per caller, and Burst the number of requests a caller may
+	// send at once (at least 1). RequestsPerSecond 0 (or less) disables the limit.
+	RequestsPerSecond float64
+	Burst             int
+	// Key (optional) returns the caller of a request, who gets a token bucket of their own. By default it is the
+	// principal of RequireBearer (its subject for principals with a GetSubject method, like JWT claims), else the
+	// subject of the client certificate from WithPeerIdentity, else the IP given by ClientIP.
+	Key      func(request *http.Request) string
+	ClientIP ClientIPResolver
+	// OnLimited (optional) is called with the key of every rejected request, e.g. to record a metric.
+	OnLimited func(key string)
+}
+
+// RateLimit wraps a handler, e.g. the one returned by Handler, limiting the rate of requests of each caller with
+// a token bucket. Requests over the limit are rejected with 429 and a Retry-After header. Wrap it inside
+// RequireBearer or WithPeerIdentity to limit per principal rather than per IP. To limit several feeds
+// differently, wrap the handler of each with its own options.
+func RateLimit(handler http.Handler, options RateLimitOptions) http.Handler {
+	if options.RequestsPerSecond <= 0 {
+		return handler
+	}
+	key := options.Key
+	if key == nil {
+		key = options.defaultKey
+	}
+	buckets := &rateLimiters{
+		rate:    options.RequestsPerSecond,
+		burst:   options.Burst,
+		buckets: make(map[string]*rateLimiter),
+		pruneAt: 1024,
+		now:     time.Now,
+	}
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		k := key(request)
+		if retryAfter := buckets.take(k); retryAfter > 0 {
+			if options.OnLimited != nil {
+				options.OnLimited(k)
+			}
+			writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(writer, ErrRateLimited.Error(), ErrRateLimited.Status())
+			return
+		}
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+func (o RateLimitOptions) defaultKey(request *http.Request) string {
+	if principal, ok := PrincipalFromContext(request.Context()); ok {
+		if subjecter, ok := principal.(interface{ GetSubject() (string, error) }); ok {
+			if subject, err := subjecter.GetSubject(); err == nil && subject != "" {
+				return "principal:" + subject
+			}
+		}
+		return fmt.Sprintf("principal:%v", principal)
+	}
+	if peer, ok := PeerIdentityFromContext(request.Context()); ok {
+		return "peer:" + peer.Subject
+	}
+	return "ip:" + o.ClientIP.ClientIP(request).String()
+}
+
+// rateLimiters holds a token bucket per caller for RateLimit.
+type rateLimiters struct {
+	lock    sync.Mutex
+	rate    float64
+	burst   int
+	buckets map[string]*rateLimiter
+	// pruneAt is the number of buckets at which idle buckets are deleted
+	pruneAt int
+	now     func() time.Time
+}
+
+// take takes a token from the bucket of key, returning how long to wait for one if it is empty.
+func (l *rateLimiters) take(key string) time.Duration {
+	l.lock.Lock()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		// pruning whenever the number of buckets has doubled keeps the cost per request constant
+		if len(l.buckets) >= l.pruneAt {
+			for k, b := range l.buckets {
+				if b.full() {
+					delete(l.buckets, k)
+				}
+			}
+			l.pruneAt = 2 * len(l.buckets)
+			if l.pruneAt < 1024 {
+				l.pruneAt = 1024
+			}
+		}
+		bucket = newRateLimiter(l.rate, l.burst, false)
+		bucket.now = l.now
+		l.buckets[key] = bucket
+	}
+	l.lock.Unlock()
+	return bucket.take()
+}
+
+// take takes a token if there is one, returning how long to wait for one otherwise.
+func (l *rateLimiter) take() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// full returns whether the bucket has refilled completely, i.e. it has been idle for a while.
+func (l *rateLimiter) full() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	return l.tokens >= l.burst
+}
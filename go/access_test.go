@@ -0,0 +1,168 @@
+package zeroeventhub
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	proxies, err := ParseCIDRs("10.0.0.0/8", "192.168.1.1")
+	require.NoError(t, err)
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		values     []string
+		expected   string
+	}{
+		{name: "no header configured", remoteAddr: "10.0.0.1:1234", values: []string{"1.2.3.4"}, expected: "10.0.0.1"},
+		{name: "direct client", header: "X-Forwarded-For", remoteAddr: "1.2.3.4:1234", expected: "1.2.3.4"},
+		{name: "spoofed by untrusted client", header: "X-Forwarded-For", remoteAddr: "1.2.3.4:1234", values: []string{"5.6.7.8"}, expected: "1.2.3.4"},
+		{name: "behind proxy", header: "X-Forwarded-For", remoteAddr: "10.0.0.1:1234", values: []string{"1.2.3.4"}, expected: "1.2.3.4"},
+		{name: "behind proxies", header: "X-Forwarded-For", remoteAddr: "10.0.0.1:1234", values: []string{"5.6.7.8, 1.2.3.4, 192.168.1.1"}, expected: "1.2.3.4"},
+		{name: "several header lines", header: "X-Forwarded-For", remoteAddr: "10.0.0.1:1234", values: []string{"5.6.7.8", "1.2.3.4", "10.1.1.1"}, expected: "1.2.3.4"},
+		{name: "only proxies", header: "X-Forwarded-For", remoteAddr: "10.0.0.1:1234", values: []string{"10.0.0.2"}, expected: "10.0.0.2"},
+		{name: "missing header", header: "X-Forwarded-For", remoteAddr: "10.0.0.1:1234", expected: "10.0.0.1"},
+		{name: "garbage", header: "X-Forwarded-For", remoteAddr: "10.0.0.1:1234", values: []string{"1.2.3.4, unknown, 10.0.0.2"}, expected: "10.0.0.2"},
+		{name: "real ip", header: "X-Real-IP", remoteAddr: "10.0.0.1:1234", values: []string{"1.2.3.4"}, expected: "1.2.3.4"},
+		{name: "ipv6", header: "X-Forwarded-For", remoteAddr: "10.0.0.1:1234", values: []string{"2001:db8::1"}, expected: "2001:db8::1"},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.RemoteAddr = test.remoteAddr
+		for _, value := range test.values {
+			request.Header.Add(test.header, value)
+		}
+		if test.header == "" {
+			request.Header.Add("X-Forwarded-For", test.values[0])
+		}
+		resolver := ClientIPResolver{Header: test.header, TrustedProxies: proxies}
+		require.Equal(t, test.expected, resolver.ClientIP(request).String(), test.name)
+	}
+
+	_, err = ParseCIDRs("10.0.0.0/33")
+	require.Error(t, err)
+	_, err = ParseCIDRs("localhost")
+	require.Error(t, err)
+}
+
+func TestAllowIPs(t *testing.T) {
+	allowed, err := ParseCIDRs("1.2.3.0/24", "2001:db8::/32")
+	require.NoError(t, err)
+	proxies, err := ParseCIDRs("10.0.0.1")
+	require.NoError(t, err)
+	handler := AllowIPs(Handler(nil, NewTestZeroEventHubAPI()), allowed, ClientIPResolver{Header: "X-Forwarded-For", TrustedProxies: proxies})
+
+	for remoteAddr, status := range map[string]int{
+		"1.2.3.4:1234":     http.StatusOK,
+		"[2001:db8::1]:80": http.StatusOK,
+		"1.2.4.4:1234":     http.StatusForbidden,
+		"10.0.0.1:1234":    http.StatusForbidden,
+		"garbage":          http.StatusForbidden,
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/feed/v1?n=2&cursor0=_first", nil)
+		request.RemoteAddr = remoteAddr
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		require.Equal(t, status, recorder.Code, remoteAddr)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/feed/v1?n=2&cursor0=_first", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", "1.2.3.4")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestRateLimitPerCaller(t *testing.T) {
+	var served, limited int64
+	var lock sync.Mutex
+	limitedKeys := map[string]int{}
+	handler := RateLimit(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt64(&served, 1)
+	}), RateLimitOptions{
+		// slow enough for no token to be added while the test runs
+		RequestsPerSecond: 0.01,
+		Burst:             10,
+		OnLimited: func(key string) {
+			lock.Lock()
+			defer lock.Unlock()
+			limitedKeys[key]++
+		},
+	})
+	authenticated := RequireBearer(handler, verifyTestToken)
+
+	send := func(handler http.Handler, remoteAddr, token string) {
+		request := httptest.NewRequest(http.MethodGet, "/feed/v1", nil)
+		request.RemoteAddr = remoteAddr
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code == http.StatusTooManyRequests {
+			atomic.AddInt64(&limited, 1)
+			assert.Equal(t, "100", recorder.Header().Get("Retry-After"))
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			send(handler, "1.2.3.4:1234", "")
+		}()
+		go func() {
+			defer wg.Done()
+			send(handler, "5.6.7.8:1234", "")
+		}()
+		go func() {
+			defer wg.Done()
+			// the principal has a bucket of its own, whatever its IP
+			send(authenticated, "1.2.3.4:1234", "alice")
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int64(30), served)
+	require.Equal(t, int64(120), limited)
+	require.Equal(t, map[string]int{"ip:1.2.3.4": 40, "ip:5.6.7.8": 40, "principal:alice": 40}, limitedKeys)
+
+	// RequestsPerSecond 0 disables the limit
+	unlimited := RateLimit(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}), RateLimitOptions{Burst: 1})
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		unlimited.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/feed/v1", nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+}
+
+func TestRateLimitBuckets(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiters := &rateLimiters{rate: 2, burst: 2, buckets: make(map[string]*rateLimiter), pruneAt: 2, now: func() time.Time { return now }}
+	require.Equal(t, time.Duration(0), limiters.take("a"))
+	require.Equal(t, time.Duration(0), limiters.take("a"))
+	require.Equal(t, 500*time.Millisecond, limiters.take("a"))
+	now = now.Add(250 * time.Millisecond)
+	require.Equal(t, 250*time.Millisecond, limiters.take("a"))
+	now = now.Add(250 * time.Millisecond)
+	require.Equal(t, time.Duration(0), limiters.take("a"))
+	require.Equal(t, time.Duration(0), limiters.take("b"))
+
+	// a is idle and b not, so a is pruned when c is added
+	now = now.Add(time.Second)
+	require.Equal(t, time.Duration(0), limiters.take("b"))
+	require.Equal(t, time.Duration(0), limiters.take("c"))
+	require.Len(t, limiters.buckets, 2)
+	require.Contains(t, limiters.buckets, "b")
+	require.Contains(t, limiters.buckets, "c")
+}
@@ -1,8 +1,6 @@
 package zeroeventhub
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -11,16 +9,17 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 )
 
 const (
-	// FirstCursor is a special cursor: starts at the first event.
+	// FirstCursor is a special cursor: starts at the first event, i.e. the first event is included.
 	FirstCursor = "_first"
-	// LastCursor is a special cursor: starts at the last available event.
+	// LastCursor is a special cursor: starts at the last available event, i.e. the last event is included.
 	LastCursor = "_last"
 	// DefaultPageSize is used to figure out whether the caller hasn't provided any page size hint.
 	DefaultPageSize = 0
@@ -29,11 +28,26 @@ const (
 )
 
 // Cursor is a struct encapsulating both the partition ID and the actual cursor within this partition.
+//
+// Apart from the special cursors, a cursor is opaque to the client and always means "start strictly after":
+// the publisher returns events following the event the cursor was checkpointed at, never that event itself.
 type Cursor struct {
 	PartitionID int    `json:"partition"`
 	Cursor      string `json:"cursor"`
 }
 
+// StartAfter returns a Cursor for reading a partition from the first event strictly after the given cursor.
+// This is the normal case when resuming from a checkpoint.
+func StartAfter(partitionID int, cursor string) Cursor {
+	return Cursor{PartitionID: partitionID, Cursor: cursor}
+}
+
+// IsSpecialCursor returns true if c is one of the special cursors (FirstCursor or LastCursor),
+// which the publisher has to interpret itself rather than as a position within the partition.
+func IsSpecialCursor(c string) bool {
+	return c == FirstCursor || c == LastCursor
+}
+
 // Envelope contains event headers (standard string map) and the event data (any JSON-serializable struct)
 type Envelope struct {
 	PartitionID int               `json:"partition"`
@@ -66,6 +80,10 @@ type EventFetcher interface {
 }
 
 // API is a generic-based interface that has to be implemented on a server side.
+// When served by Handler, FetchEvents may pass all headers of an event to the receiver: only the requested
+// ones (see FilterHeaders) are sent to the client. An error from FetchEvents implementing StatusError, like an
+// APIError for a malformed cursor, is responded to with its status and message if nothing has been written yet;
+// other errors give 500.
 type API interface {
 	// GetName should return the name of the API (used in logging).
 	GetName() string
@@ -76,35 +94,40 @@ type API interface {
 }
 
 // NDJSONEventSerializer implements EventReceiver by emitting Newline-Delimited-JSON to a writer.
+// Every line is encoded into a reused buffer and written with a single Write call; the output is the same as
+// that of json.Encoder. It is not safe for concurrent use.
 type NDJSONEventSerializer struct {
-	encoder *json.Encoder
-	writer  io.Writer
+	writer io.Writer
+	buffer *lineBuffer
 }
 
 func NewNDJSONEventSerializer(writer io.Writer) *NDJSONEventSerializer {
 	return &NDJSONEventSerializer{
-		encoder: json.NewEncoder(writer),
-		writer:  writer,
+		writer: writer,
+		buffer: &lineBuffer{},
 	}
 }
 
-func (s NDJSONEventSerializer) writeNdJsonLine(item interface{}) error {
-	return s.encoder.Encode(item)
-}
-
 func (s NDJSONEventSerializer) Checkpoint(partitionID int, cursor string) error {
-	return s.writeNdJsonLine(Cursor{
-		PartitionID: partitionID,
-		Cursor:      cursor,
-	})
+	_, err := s.writer.Write(s.buffer.checkpoint(partitionID, cursor))
+	return err
 }
 
 func (s NDJSONEventSerializer) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
-	return s.writeNdJsonLine(Envelope{
-		PartitionID: partitionID,
-		Headers:     headers,
-		Data:        data,
-	})
+	line, err := s.buffer.event(partitionID, headers, data)
+	if err != nil {
+		return err
+	}
+	_, err = s.writer.Write(line)
+	return err
+}
+
+// SetEscapeHTML controls whether <, > and & are escaped in the output, as by json.Encoder.SetEscapeHTML; they are
+// by default. Without escaping, compact data round-trips byte for byte, except for unescaped U+2028 and U+2029,
+// which json.Encoder always escapes. Header keys are always written in sorted order, so serializing the same
+// envelope twice gives the same line either way.
+func (s *NDJSONEventSerializer) SetEscapeHTML(on bool) {
+	s.buffer.rawHTML = !on
 }
 
 var _ EventReceiver = &NDJSONEventSerializer{}
@@ -115,6 +138,23 @@ var _ EventReceiver = &NDJSONEventSerializer{}
 type EventPageRaw struct {
 	Events  []Envelope
 	Cursors map[int]string
+
+	// ReuseBuffers makes the page copy the data of the events into a buffer of its own, which is reused after
+	// Reset, to save allocations when fetching page after page into the same EventPageRaw. The Data of the
+	// events is then only valid until Reset; copy it to keep it longer. It also makes the page safe to use with
+	// Client.WithReusedEventData.
+	ReuseBuffers bool
+	buffer       []byte
+}
+
+// Reset empties the page for fetching the next page into it, keeping the capacity of Events and, with
+// ReuseBuffers, of the data buffer. The cursors are kept, so they can be passed on to the next fetch.
+func (page *EventPageRaw) Reset() {
+	for i := range page.Events {
+		page.Events[i] = Envelope{}
+	}
+	page.Events = page.Events[:0]
+	page.buffer = page.buffer[:0]
 }
 
 func (page *EventPageRaw) Checkpoint(partitionID int, cursor string) error {
@@ -126,6 +166,12 @@ func (page *EventPageRaw) Checkpoint(partitionID int, cursor string) error {
 }
 
 func (page *EventPageRaw) Event(partitionID int, h map[string]string, d json.RawMessage) error {
+	if page.ReuseBuffers && d != nil {
+		// the earlier events keep referring to the old array if the buffer grows
+		start := len(page.buffer)
+		page.buffer = append(page.buffer, d...)
+		d = page.buffer[start:len(page.buffer):len(page.buffer)]
+	}
 	page.Events = append(page.Events, Envelope{
 		PartitionID: partitionID,
 		Headers:     h,
@@ -160,33 +206,119 @@ func (page *EventPageSingleType[T]) Event(partitionID int, h map[string]string,
 	return nil
 }
 
+// DrainReceiver implements EventReceiver by discarding all events and only recording the latest cursor
+// of each partition. Combined with LastCursor it can be used to fast-forward a consumer to the head of
+// the feed without processing events.
+type DrainReceiver struct {
+	Cursors map[int]string
+}
+
+func (d *DrainReceiver) Checkpoint(partitionID int, cursor string) error {
+	if d.Cursors == nil {
+		d.Cursors = make(map[int]string)
+	}
+	d.Cursors[partitionID] = cursor
+	return nil
+}
+
+func (d *DrainReceiver) Event(int, map[string]string, json.RawMessage) error {
+	return nil
+}
+
+// LastCursor returns the latest cursor received for the partition, or an empty string if there was none.
+func (d *DrainReceiver) LastCursor(partitionID int) string {
+	return d.Cursors[partitionID]
+}
+
+var _ EventReceiver = &DrainReceiver{}
+
+// ContentType is the media type of the responses of Handler.
+const ContentType = "application/x-ndjson"
+
+// setNDJSONHeaders sets the headers of a successful response of Handler. nosniff keeps browsers and proxies from
+// second-guessing the content type from the first events.
+func setNDJSONHeaders(header http.Header) {
+	header.Set("Content-Type", ContentType)
+	header.Set("X-Content-Type-Options", "nosniff")
+}
+
+// WithResponseHeaders wraps a handler, e.g. the one returned by Handler, setting the headers on every response
+// before the handler writes anything, e.g. security headers like X-Content-Type-Options.
+func WithResponseHeaders(handler http.Handler, headers map[string]string) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		for key, value := range headers {
+			writer.Header().Set(key, value)
+		}
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+// HandlerOptions configures HandlerWithOptions.
+type HandlerOptions struct {
+	// RequestLogLevel is the level successful requests are logged at; LevelDebug by default.
+	RequestLogLevel LogLevel
+	// RequestLogSampling logs only 1 in RequestLogSampling successful requests; 0 or 1 logs all of them.
+	// Failed requests are always logged.
+	RequestLogSampling int
+	// MaxPageBytes limits the size of pages with a PagedSerializer; 0 means no limit.
+	MaxPageBytes int
+	// AuditSink (optional) gets a record of every request for events.
+	AuditSink AuditSink
+	// MaxResponseBytes is a hard limit on the size of a response body (before compression), e.g. the limit of an API
+	// gateway in front of the handler; 0 means no limit. A page that would exceed it ends at the last checkpoint
+	// that fits, dropping the events after it, so the events between two checkpoints are buffered. If not even the
+	// first event fits, the response is 413 with ErrPageTooLarge.
+	MaxResponseBytes int
+	// DisableHTMLEscaping leaves <, > and & in pages unescaped; see NDJSONEventSerializer.SetEscapeHTML.
+	DisableHTMLEscaping bool
+	// Gzip compresses pages with gzip for clients accepting it, as the Go http.Client does by default.
+	Gzip bool
+	// MinCompressBytes is the size a page must exceed to be compressed when Gzip is set; smaller pages are sent as
+	// they are, since compressing them costs more CPU than it saves bandwidth. The first MinCompressBytes of every
+	// page are buffered to decide. 0 compresses all pages.
+	MinCompressBytes int
+	// MetadataHeaders sends TimestampHeader and KeyHeader of events to every client, as if requested. They are
+	// also passed on to API.FetchEvents with the requested headers.
+	MetadataHeaders bool
+}
+
 // Handler wraps API in a http.Handler.
-func Handler(logger logrus.FieldLogger, api API) http.Handler {
-	if logger == nil {
-		logger = logrus.StandardLogger()
+// The context passed to API.FetchEvents is derived from the request context, and cancelled when the client
+// disconnects; expensive publishers should watch it to stop producing a page nobody will read. Writing to a client
+// that went away can fail before that is noticed; the receiver then returns ErrClientDisconnected and cancels the
+// context.
+func Handler(logger Logger, api API) http.Handler {
+	return HandlerWithOptions(logger, api, HandlerOptions{})
+}
+
+// HandlerWithOptions is Handler with control over the logging and auditing of requests and the size of pages. A
+// consumer polling an idle feed makes a request every poll interval, so logging all of them at Info is usually too
+// much.
+func HandlerWithOptions(logger Logger, api API, opts HandlerOptions) http.Handler {
+	logger = orNop(logger)
+	var requestCount uint64
+	sampled := func() bool {
+		if opts.RequestLogSampling <= 1 {
+			return true
+		}
+		return (atomic.AddUint64(&requestCount, 1)-1)%uint64(opts.RequestLogSampling) == 0
 	}
 	router := mux.NewRouter()
 	router.Methods(http.MethodGet).
 		Path("/feed/v1").
 		HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			audit := newAccessAudit(opts.AuditSink, api, writer, request)
+			defer audit.emit(opts.AuditSink)
+			writer = audit.writer(writer)
 			query := request.URL.Query()
-			if !query.Has("n") {
-				http.Error(writer, ErrHandshakePartitionCountMissing.Error(), ErrHandshakePartitionCountMissing.Status())
-				return
-			}
-			if n, err := strconv.Atoi(query.Get("n")); err != nil {
-				http.Error(writer, err.Error(), http.StatusBadRequest)
+			if err := checkPartitionCount(api, query); err != nil {
+				http.Error(writer, err.Error(), err.Status())
 				return
-			} else {
-				if n != api.GetPartitionCount() {
-					http.Error(writer, ErrHandshakePartitionCountMismatch.Error(), ErrHandshakePartitionCountMismatch.Status())
-					return
-				}
 			}
 			var pageSizeHint int
 			if query.Has("pagesizehint") {
-				if x, err := strconv.Atoi(query.Get("pagesizehint")); err != nil {
-					http.Error(writer, err.Error(), http.StatusBadRequest)
+				if x, err := strconv.Atoi(query.Get("pagesizehint")); err != nil || x < 0 {
+					http.Error(writer, ErrIllegalPageSizeHint.Error(), ErrIllegalPageSizeHint.Status())
 					return
 				} else {
 					pageSizeHint = x
@@ -196,37 +328,159 @@ func Handler(logger logrus.FieldLogger, api API) http.Handler {
 			if query.Has("headers") {
 				headers = strings.Split(strings.TrimSuffix(query.Get("headers"), ","), ",")
 			}
+			if opts.MetadataHeaders {
+				headers = withMetadataHeaders(headers)
+			}
 			cursors, err := parseCursors(api.GetPartitionCount(), query)
 			if err != nil {
 				http.Error(writer, err.Error(), http.StatusBadRequest)
 				return
 			}
-			fields := logger.
-				WithField("event", api.GetName()).
-				WithField("PartitionCount", api.GetPartitionCount()).
-				WithField("Cursors", cursors).
-				WithField("PageSizeHint", pageSizeHint).
-				WithField("Headers", headers)
-			fields.Info()
-			serializer := NewNDJSONEventSerializer(writer)
-			err = api.FetchEvents(request.Context(), cursors, pageSizeHint, serializer, headers...)
+			audit.cursors(cursors)
+			direction, err := parseDirection(query.Get("direction"))
+			if err != nil {
+				http.Error(writer, ErrIllegalDirection.Error(), ErrIllegalDirection.Status())
+				return
+			}
+			inclusive, err := parseInclusive(query.Get(InclusiveParam))
 			if err != nil {
+				http.Error(writer, ErrIllegalInclusive.Error(), ErrIllegalInclusive.Status())
+				return
+			}
+			caughtUp, err := parseCaughtUp(query.Get(CaughtUpParam))
+			if err != nil {
+				http.Error(writer, ErrIllegalCaughtUp.Error(), ErrIllegalCaughtUp.Status())
+				return
+			}
+			// publishers may return more headers than requested; only the requested ones are written
+			buffer := lineBuffers.Get().(*lineBuffer)
+			defer buffer.release()
+			buffer.rawHTML = opts.DisableHTMLEscaping
+			buffer.caughtUpLines = caughtUp
+			ctx, cancel := context.WithCancel(WithDirection(contextWithRequest(request.Context(), request), direction))
+			ctx = WithInclusiveCursors(ctx, inclusive)
+			defer cancel()
+			// the page is written through pageWriter, which cancels ctx if the client goes away
+			pageWriter := &disconnectWriter{ResponseWriter: writer, cancel: cancel}
+			var out io.Writer = pageWriter
+			var compressor *gzipWriter
+			if opts.Gzip {
+				writer.Header().Add("Vary", "Accept-Encoding")
+				if acceptsGzip(request) {
+					compressor = newGzipWriter(pageWriter, writer.Header(), opts.MinCompressBytes)
+					defer compressor.release()
+					out = compressor
+				}
+			}
+			var capped *cappedReceiver
+			if opts.MaxResponseBytes > 0 {
+				capped, out = newCappedReceiver(out, opts.MaxResponseBytes)
+			}
+			var receiver EventReceiver = &NDJSONEventSerializer{writer: out, buffer: buffer}
+			if opts.MaxPageBytes > 0 {
+				receiver = newPagedSerializer(out, opts.MaxPageBytes, buffer)
+			}
+			if capped != nil {
+				capped.receiver = receiver
+				receiver = capped
+			}
+			serializer := pageWriter.receiver(HeaderFilter{Receiver: audit.receiver(receiver, capped), Requested: headers})
+			setNDJSONHeaders(writer.Header())
+			setPollAfterHeader(ctx, writer, api, cursors)
+			err = api.FetchEvents(ctx, cursors, pageSizeHint, serializer, headers...)
+			if pageWriter.disconnected || request.Context().Err() != nil {
+				// nobody is left to respond to
+				audit.fail(err)
+				logger.WithField("event", api.GetName()+".client_disconnected").WithError(err).Debug()
+				return
+			}
+			if err != nil && !errors.Is(err, ErrPageFull) {
+				audit.fail(err)
 				logger.WithField("event", api.GetName()+".fetch_events_error").WithError(err).Info()
-				http.Error(writer, "Internal server error", http.StatusInternalServerError)
+				// a StatusError, e.g. for a malformed cursor, is passed on to the client
+				message, status := "Internal server error", http.StatusInternalServerError
+				var statusErr StatusError
+				if errors.As(err, &statusErr) {
+					message, status = statusErr.Error(), statusErr.Status()
+				}
+				if compressor != nil && compressor.started() {
+					// the status and Content-Encoding are sent; the message ends the gzip stream instead, failing the
+					// client as it would an uncompressed page
+					_, _ = io.WriteString(compressor, message+"\n")
+					_ = compressor.close()
+				} else {
+					http.Error(writer, message, status)
+				}
 				return
 			}
+			if capped != nil {
+				if err := capped.flush(); err != nil {
+					audit.fail(err)
+					logger.WithField("event", api.GetName()+".client_disconnected").WithError(err).Debug()
+					return
+				}
+			}
+			if compressor != nil {
+				if err := compressor.close(); err != nil {
+					audit.fail(err)
+					logger.WithField("event", api.GetName()+".client_disconnected").WithError(err).Debug()
+					return
+				}
+			}
+			// the fields are only built for entries that are logged
+			if logEnabled(logger, opts.RequestLogLevel) && sampled() {
+				fields := logger.
+					WithField("event", api.GetName()).
+					WithField("PartitionCount", api.GetPartitionCount()).
+					WithField("Cursors", cursors).
+					WithField("PageSizeHint", pageSizeHint).
+					WithField("Headers", headers).
+					WithField("Direction", direction)
+				if peer, ok := PeerIdentityFromContext(ctx); ok {
+					fields = fields.WithField("Peer", peer.Subject)
+				}
+				logAt(fields, opts.RequestLogLevel)
+			}
 		})
+	router.Methods(http.MethodGet).
+		Path("/feed/v1/tail").
+		HandlerFunc(tailCursorHandler(logger, api))
+	router.Methods(http.MethodGet).
+		Path("/feed/v1/seek").
+		HandlerFunc(seekHandler(logger, api))
+	router.Methods(http.MethodGet).
+		Path("/feed/v1/event").
+		HandlerFunc(eventByIDHandler(logger, api))
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		router.ServeHTTP(writer, request)
 	})
 }
 
+// checkPartitionCount checks the handshake: the partition count n the client expects must match the API.
+func checkPartitionCount(api API, query url.Values) StatusError {
+	if !query.Has("n") {
+		return ErrHandshakePartitionCountMissing
+	}
+	n, err := strconv.Atoi(query.Get("n"))
+	if err != nil {
+		return NewAPIError(err.Error(), http.StatusBadRequest)
+	}
+	if n != api.GetPartitionCount() {
+		return ErrHandshakePartitionCountMismatch
+	}
+	return nil
+}
+
 func parseCursors(partitionCount int, query url.Values) (cursors []Cursor, err error) {
 	for i := 0; i < partitionCount; i++ {
 		partition := fmt.Sprintf("cursor%d", i)
 		if !query.Has(partition) {
 			continue
 		}
+		// publishers disagree on what an empty cursor means, so it is refused rather than passed on
+		if query.Get(partition) == "" {
+			return nil, ErrEmptyCursor
+		}
 		cursors = append(cursors, Cursor{
 			PartitionID: i,
 			Cursor:      query.Get(partition),
@@ -242,9 +496,31 @@ func parseCursors(partitionCount int, query url.Values) (cursors []Cursor, err e
 type Client struct {
 	httpClient       *http.Client
 	requestProcessor func(r *http.Request) error
-	logger           logrus.FieldLogger
+	logger           Logger
 	url              string
 	partitionCount   int
+	requestTimeout   time.Duration
+	endpoints        *endpoints
+	hedgeDelay       time.Duration
+	onHedge          func()
+	onConn           func(reused bool)
+	hmacSigner       *hmacSigner
+	resume           resumeSettings
+	paramNames       map[string]string
+	rateLimiter      *rateLimiter
+	circuitBreaker   *circuitBreaker
+	maxResponseBytes int64
+	reuseEventData   bool
+	queryParams      []queryParam
+	redirects        redirectPolicy
+	emptyCursorFirst bool
+	idleReadTimeout  time.Duration
+	onPage           func(PageSummary)
+	inclusive        bool
+	direction        Direction
+	onTrace          func(FetchTrace)
+	caughtUp         bool
+	strictDecoding   bool
 }
 
 var _ EventFetcher = &Client{}
@@ -256,12 +532,42 @@ func NewClient(url string, partitionCount int) Client {
 		requestProcessor: func(r *http.Request) error {
 			return nil
 		},
-		logger:         logrus.StandardLogger(),
+		logger:         NopLogger(),
 		url:            url,
 		partitionCount: partitionCount,
 	}
 }
 
+// WithEmptyCursorAsFirst is a Client method for consumers whose cursor storage returns "" for partitions it has no
+// cursor for: FetchEvents then starts those partitions at FirstCursor, instead of returning ErrEmptyCursor.
+func (c Client) WithEmptyCursorAsFirst() (r Client) {
+	r = c
+	r.emptyCursorFirst = true
+	return
+}
+
+// checkEmptyCursors returns ErrEmptyCursor if a cursor is empty, or, with WithEmptyCursorAsFirst, a copy of cursors
+// with empty cursors replaced by FirstCursor.
+func (c Client) checkEmptyCursors(cursors []Cursor) ([]Cursor, error) {
+	for i, cursor := range cursors {
+		if cursor.Cursor != "" {
+			continue
+		}
+		if !c.emptyCursorFirst {
+			return nil, ErrEmptyCursor
+		}
+		replaced := make([]Cursor, len(cursors))
+		copy(replaced, cursors)
+		for j := i; j < len(replaced); j++ {
+			if replaced[j].Cursor == "" {
+				replaced[j].Cursor = FirstCursor
+			}
+		}
+		return replaced, nil
+	}
+	return cursors, nil
+}
+
 // WithHttpClient is a Client method for providing custom HTTP client.
 func (c Client) WithHttpClient(httpClient *http.Client) (r Client) {
 	r = c
@@ -275,32 +581,178 @@ func (c Client) WithRequestProcessor(requestProcessor func(r *http.Request) erro
 	return
 }
 
-// WithLogger is a Client method for providing custom logger.
-func (c Client) WithLogger(logger logrus.FieldLogger) (r Client) {
+// WithRequestTimeout is a Client method for providing a hard per-request timeout. Each FetchEvents call derives
+// a context with this timeout from the caller's context, so whichever deadline is shorter wins.
+// Unlike http.Client.Timeout it is applied per call and can be disabled again by passing 0.
+func (c Client) WithRequestTimeout(d time.Duration) (r Client) {
 	r = c
-	r.logger = logger
+	r.requestTimeout = d
 	return
 }
 
-type checkpointOrEvent struct {
-	PartitionId int `json:"partition"`
-	// either this is set:
-	Cursor string `json:"cursor"`
-	// OR, these are set:
-	Headers map[string]string `json:"headers"`
-	Data    json.RawMessage   `json:"data"`
+// WithMaxResponseBytes is a Client method for limiting the size of a response body, as a safety limit against
+// misbehaving servers streaming unbounded data. FetchEvents returns ErrResponseTooLarge once the limit is exceeded;
+// the events and checkpoints before that point have already been passed to the receiver. The other requests of the
+// Client, like TailCursor, are limited as well. Pass 0 to disable the limit.
+func (c Client) WithMaxResponseBytes(n int64) (r Client) {
+	r = c
+	r.maxResponseBytes = n
+	return
+}
+
+// WithReusedEventData is a Client method for saving an allocation per event when reading large pages. If set, the
+// data passed to EventReceiver.Event is only valid until Event returns, as the next event is read into the same
+// buffer; receivers retaining the data must copy it. Don't use it with EventPageRaw, which retains the data,
+// unless its ReuseBuffers is set.
+// Receivers decoding the data right away, like EventPageSingleType, are fine.
+func (c Client) WithReusedEventData(reuse bool) (r Client) {
+	r = c
+	r.reuseEventData = reuse
+	return
+}
+
+// maxBytesReader is like io.LimitReader, but fails with ErrResponseTooLarge instead of returning EOF at the limit.
+type maxBytesReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.remaining <= 0 {
+		// only an error if there actually is more data
+		var b [1]byte
+		if n, err := m.reader.Read(b[:]); n > 0 {
+			return 0, ErrResponseTooLarge
+		} else {
+			return 0, err
+		}
+	}
+	if int64(len(p)) > m.remaining {
+		p = p[:m.remaining]
+	}
+	n, err := m.reader.Read(p)
+	m.remaining -= int64(n)
+	return n, err
+}
+
+// WithLogger is a Client method for providing custom logger; by default nothing is logged.
+func (c Client) WithLogger(logger Logger) (r Client) {
+	r = c
+	r.logger = orNop(logger)
+	return
 }
 
 // FetchEvents is a client-side implementation that queries the server and properly deserializes received data.
-func (c Client) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+func (c Client) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) (err error) {
 	if len(cursors) == 0 {
 		return ErrCursorsMissing
 	}
+	if pageSizeHint < 0 {
+		return ErrIllegalPageSizeHint
+	}
+	if cursors, err = c.checkEmptyCursors(cursors); err != nil {
+		return err
+	}
+	if err := c.checkQueryParams(); err != nil {
+		return err
+	}
 
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/feed/v1", c.url), nil)
-	if err != nil {
+	if c.rateLimiter != nil && c.rateLimiter.perEvent {
+		counter := &eventCounter{receiver: r}
+		r = counter
+		defer func() {
+			c.rateLimiter.consume(counter.events)
+		}()
+	}
+	return c.guard(ctx, func(ctx context.Context) error {
+		return c.fetchResuming(ctx, cursors, pageSizeHint, r, headers...)
+	})
+}
+
+// guard does a request with send, subject to the circuit breaker, request timeout and rate limit of the Client.
+func (c Client) guard(ctx context.Context, send func(ctx context.Context) error) (err error) {
+	if c.circuitBreaker != nil {
+		generation, allowErr := c.circuitBreaker.allow()
+		if allowErr != nil {
+			return allowErr
+		}
+		// classified against the caller's context: hitting the request timeout counts as a failure
+		defer func(ctx context.Context) {
+			c.circuitBreaker.record(generation, classifyOutcome(ctx, err))
+		}(ctx)
+	}
+
+	if c.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.requestTimeout)
+		defer cancel()
+	}
+
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.wait(ctx); err != nil {
+			return err
+		}
+	}
+	return send(ctx)
+}
+
+// fetchFromEndpoints does a FetchEvents request, failing over to the next endpoint if enabled.
+func (c Client) fetchFromEndpoints(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	return c.withEndpoints(ctx, func(url string) (bool, error) {
+		return c.fetchEventsFrom(ctx, url, cursors, pageSizeHint, r, headers...)
+	})
+}
+
+// withEndpoints does a request with send against the base URL, failing over to the next endpoint if enabled while
+// send returns retry.
+func (c Client) withEndpoints(ctx context.Context, send func(url string) (retry bool, err error)) (err error) {
+	if c.endpoints == nil {
+		_, err = send(c.url)
 		return err
 	}
+	for _, i := range c.endpoints.candidates() {
+		var retry bool
+		url := c.endpoints.urls[i]
+		if retry, err = send(url); !retry || ctx.Err() != nil {
+			if err == nil {
+				c.endpoints.succeeded(i)
+			}
+			return err
+		}
+		if logEnabled(c.logger, LevelWarn) {
+			c.logger.WithField("event", "zeroeventhub.failover").WithField("requestUrl", url).WithError(err).Warn()
+		}
+	}
+	return err
+}
+
+// responseBody returns the body of res, limited by the idle read timeout and maximum response size of the Client.
+// stop must be called when done reading.
+func (c Client) responseBody(res *http.Response) (body io.Reader, stop func()) {
+	body, stop = res.Body, func() {}
+	if c.idleReadTimeout > 0 {
+		idle := newIdleReader(res.Body, c.idleReadTimeout)
+		body, stop = idle, idle.stop
+	}
+	if c.maxResponseBytes > 0 {
+		body = &maxBytesReader{reader: body, remaining: c.maxResponseBytes}
+	}
+	return body, stop
+}
+
+// retryStatus tells whether a response with the status is retried against the next endpoint: server errors are,
+// except 501 Not Implemented, which the other endpoints of the same feed respond with as well.
+func retryStatus(status int) bool {
+	return status/100 == 5 && status != http.StatusNotImplemented
+}
+
+// fetchEventsFrom does a single FetchEvents request against the given base URL. retry is true if the request failed
+// before anything was passed to the receiver, due to a connection error or a server error.
+func (c Client) fetchEventsFrom(ctx context.Context, url string, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/feed/v1", url), nil)
+	if err != nil {
+		return false, err
+	}
 
 	req = req.WithContext(ctx)
 
@@ -315,64 +767,73 @@ func (c Client) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint
 	if len(headers) != 0 {
 		q.Add("headers", strings.Join(headers, ","))
 	}
+	if c.direction != Forward {
+		q.Add("direction", c.direction.String())
+	}
+	if c.inclusive {
+		q.Add(InclusiveParam, "1")
+	}
+	if c.caughtUp {
+		q.Add(CaughtUpParam, "1")
+	}
+	c.renameParams(q)
+	c.addQueryParams(q)
 	req.URL.RawQuery = q.Encode()
 
 	if err := c.requestProcessor(req); err != nil {
-		return err
+		return false, err
 	}
 
-	res, err := c.httpClient.Do(req)
+	start := time.Now()
+	var res *http.Response
+	var summary *pageSummarizer
+	tracer := c.newFetchTracer()
+	defer func() {
+		c.finishFetchTrace(tracer, url, res, summary, err)
+	}()
+	res, err = c.do(tracer.trace(req))
 	if err != nil {
-		return err
+		// a redirect is a matter of configuration, which the other endpoints likely share
+		return !errors.Is(err, ErrRedirected), err
 	}
-	defer func(body io.ReadCloser) {
-		_ = body.Close()
-	}(res.Body)
+	defer closeBody(res.Body)
+	body, stop := c.responseBody(res)
+	defer stop()
 
 	if res.StatusCode/100 != 2 {
-		log := c.logger.WithFields(logrus.Fields{
-			"responseCode": strconv.Itoa(res.StatusCode),
-			"requestUrl":   req.URL.String(),
-		}).WithContext(ctx)
-		if all, err := io.ReadAll(res.Body); err != nil {
-			log.WithField("event", "zeroeventhub.res_body_read_error").WithError(err).Error()
-			return err
+		retry = retryStatus(res.StatusCode)
+		event := "zeroeventhub.unexpected_response_body"
+		all, err := io.ReadAll(body)
+		if err != nil {
+			event = "zeroeventhub.res_body_read_error"
+		} else if res.StatusCode == http.StatusRequestEntityTooLarge {
+			err = ErrPageTooLarge
 		} else {
-			if string(all) == "\n" || string(all) == "" {
-				err = errors.Errorf("empty response body")
-			} else {
-				err = errors.Errorf("unexpected response body: %s", string(all))
-			}
-			log.WithField("event", "zeroeventhub.unexpected_response_body").WithError(err).Error()
-			return err
-		}
-	}
-
-	scanner := bufio.NewScanner(res.Body)
-	for scanner.Scan() {
-		line := bytes.TrimSpace(scanner.Bytes())
-		if len(line) == 0 {
-			continue
+			err = &ResponseError{StatusCode: res.StatusCode, Body: string(all)}
 		}
-
-		// we only partially parse at this point, as "data" is json.RawMessage
-		var parsedLine checkpointOrEvent
-		if err := json.Unmarshal(line, &parsedLine); err != nil {
-			return err
-		}
-		if parsedLine.Cursor != "" {
-			// checkpoint
-			if err := r.Checkpoint(parsedLine.PartitionId, parsedLine.Cursor); err != nil {
-				return err
-			}
-
-		} else {
-			// event
-			if err := r.Event(parsedLine.PartitionId, parsedLine.Headers, parsedLine.Data); err != nil {
-				return err
-			}
+		if logEnabled(c.logger, LevelError) {
+			c.logger.
+				WithField("responseCode", strconv.Itoa(res.StatusCode)).
+				WithField("requestUrl", req.URL.String()).
+				WithContext(ctx).
+				WithField("event", event).
+				WithError(err).
+				Error()
 		}
+		return retry, err
 	}
 
-	return nil
+	passPollAfter(res, r)
+	summary = c.newPageSummarizer(cursors, start)
+	err = decodeRawLines(summary.reader(body), c.reuseEventData, DecodeOptions{Strict: c.strictDecoding}, func(line *rawLine) error {
+		switch line.kind() {
+		case LineCheckpoint:
+			summary.checkpoint(line.PartitionID, string(line.Cursor))
+		case LineEvent:
+			summary.event()
+		}
+		return receiveLine(line, r)
+	})
+	c.finishPageSummary(ctx, summary, pageSizeHint, err)
+	return false, err
 }
@@ -1,6 +1,7 @@
 package zeroeventhub
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -11,10 +12,9 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/sirupsen/logrus"
-	hookstest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
 )
 
@@ -65,6 +65,17 @@ func (t TestZeroEventHubAPI) GetPartitionCount() int {
 	return 2
 }
 
+func (t TestZeroEventHubAPI) TailCursor(ctx context.Context, partitionID int) (string, error) {
+	partition, ok := t.partitions[partitionID]
+	if !ok {
+		return "", ErrPartitionDoesntExist
+	}
+	if len(partition) == 0 {
+		return FirstCursor, nil
+	}
+	return strconv.Itoa(partition[len(partition)-1].Cursor), nil
+}
+
 func (t TestZeroEventHubAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
 	if pageSizeHint == DefaultPageSize {
 		pageSizeHint = 100
@@ -86,24 +97,43 @@ func (t TestZeroEventHubAPI) FetchEvents(ctx context.Context, cursors []Cursor,
 			return err500
 		case cursorReturn504:
 			return err504
+		case cursorReturn400:
+			return err400
 		default:
 			lastProcessedCursor, err = strconv.Atoi(cursor.Cursor)
 			if err != nil {
 				return err
 			}
+			if InclusiveCursorsFromContext(ctx) {
+				// the event of the cursor is included in either direction
+				if DirectionFromContext(ctx) == Backward {
+					lastProcessedCursor++
+				} else {
+					lastProcessedCursor--
+				}
+			}
 		}
 		eventsProcessed := 0
-		h := make(map[string]string, 1)
-		for _, header := range headers {
-			if header == "content-type" {
-				h["content-type"] = "application/json"
-				break
+		// all headers are returned, the requested ones are selected by FilterHeaders
+		h := map[string]string{
+			"content-type": "application/json",
+			"foo":          "bar",
+		}
+		if DirectionFromContext(ctx) == Backward {
+			// the cursor is the first event not to return
+			if cursor.Cursor == LastCursor {
+				lastProcessedCursor = len(partition)
 			}
-			if header == All {
-				h["content-type"] = "application/json"
-				h["foo"] = "bar"
-				break
+			for i := lastProcessedCursor - 1; i >= 0 && eventsProcessed < pageSizeHint; i-- {
+				if err := r.Event(cursor.PartitionID, h, mustMarshalJson(partition[i])); err != nil {
+					return err
+				}
+				if err := r.Checkpoint(cursor.PartitionID, fmt.Sprintf("%d", i)); err != nil {
+					return err
+				}
+				eventsProcessed++
 			}
+			continue
 		}
 		for _, event := range partition {
 			if event.Cursor > lastProcessedCursor {
@@ -154,6 +184,15 @@ func TestAPI(t *testing.T) {
 			}},
 			expectedErrorString: "unexpected response body: Internal server error\n",
 		},
+		{
+			name:           "status error",
+			partitionCount: 2,
+			cursors: []Cursor{{
+				PartitionID: 0,
+				Cursor:      cursorReturn400,
+			}},
+			expectedErrorString: "unexpected response body: malformed cursor\n",
+		},
 		{
 			name:           "out of range cursor",
 			partitionCount: 2,
@@ -247,10 +286,8 @@ func TestAPI(t *testing.T) {
 }
 
 func BenchmarkFeed(b *testing.B) {
-	logger := logrus.New()
-	logger.SetOutput(io.Discard)
-	server := httptest.NewServer(Handler(logger, NewTestZeroEventHubAPI()))
-	client := NewClient(server.URL, 2).WithLogger(logger)
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	client := NewClient(server.URL, 2)
 	var page EventPageSingleType[TestEvent]
 	err := client.FetchEvents(context.Background(), []Cursor{
 		{
@@ -265,6 +302,87 @@ func BenchmarkFeed(b *testing.B) {
 	require.NoError(b, err)
 }
 
+// cannedTransport answers every request with the same response body.
+type cannedTransport struct {
+	body []byte
+}
+
+func (c cannedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(c.body)),
+		Request:    req,
+	}, nil
+}
+
+// dataRecorder records the data of the events both as copied when received and as retained.
+type dataRecorder struct {
+	copied   []string
+	retained []json.RawMessage
+}
+
+func (r *dataRecorder) Event(_ int, _ map[string]string, data json.RawMessage) error {
+	r.copied = append(r.copied, string(data))
+	r.retained = append(r.retained, data)
+	return nil
+}
+
+func (r *dataRecorder) Checkpoint(int, string) error {
+	return nil
+}
+
+func TestClientReusedEventData(t *testing.T) {
+	body := `{"partition":0,"headers":{"type":"long"},"data":{"name":"a longer event"}}
+{"partition":0,"cursor":"0"}
+{"partition":0,"data":"short"}
+{"partition":0}
+{"partition":0,"cursor":"2"}
+`
+	client := NewClient("http://example.com", 1).WithHttpClient(&http.Client{Transport: cannedTransport{body: []byte(body)}})
+	cursors := []Cursor{{PartitionID: 0, Cursor: FirstCursor}}
+
+	var receiver dataRecorder
+	require.NoError(t, client.WithReusedEventData(true).FetchEvents(context.Background(), cursors, DefaultPageSize, &receiver))
+	require.Equal(t, []string{`{"name":"a longer event"}`, `"short"`, ``}, receiver.copied)
+	require.Nil(t, receiver.retained[2])
+	// the second event was read into the buffer of the first
+	require.Equal(t, `"short"`, string(receiver.retained[0][:len(`"short"`)]))
+
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), cursors, DefaultPageSize, &page))
+	require.Equal(t, json.RawMessage(`{"name":"a longer event"}`), page.Events[0].Data)
+	require.Equal(t, map[string]string{"type": "long"}, page.Events[0].Headers)
+	require.Nil(t, page.Events[1].Headers)
+	require.Equal(t, "2", page.Cursors[0])
+}
+
+func TestEventPageRawReuseBuffers(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	client := NewClient(server.URL, 2).WithReusedEventData(true)
+
+	page := EventPageRaw{ReuseBuffers: true}
+	cursor := "9000"
+	for fetch := 0; fetch < 3; fetch++ {
+		page.Reset()
+		require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: 1, Cursor: cursor}}, 300, &page))
+		require.Len(t, page.Events, 300)
+		// every event keeps its own data, although the client reads them all into the same buffer
+		for i, event := range page.Events {
+			var data TestEvent
+			require.NoError(t, json.Unmarshal(event.Data, &data))
+			require.Equal(t, 9001+fetch*300+i, data.Cursor)
+		}
+		cursor = page.Cursors[1]
+	}
+	require.Equal(t, "9900", cursor)
+	capacity := cap(page.Events)
+	page.Reset()
+	require.Empty(t, page.Events)
+	require.Equal(t, capacity, cap(page.Events))
+	require.Equal(t, "9900", page.Cursors[1])
+}
+
 type loggingRoundTripper struct {
 	actualRoundTripper http.RoundTripper
 	requestHeaders     http.Header
@@ -424,16 +542,15 @@ func TestEnvelopeHeaders(t *testing.T) {
 // Variables for mocking responses
 var err500 = errors.New("error when fetching events")
 var err504 = errors.New("") // The response body is supposed to be blank in this case.
+var err400 = NewAPIError("malformed cursor", http.StatusBadRequest)
 
 const (
 	cursorReturn500 = "returnHttp500"
 	cursorReturn504 = "returnHttp504"
+	cursorReturn400 = "returnHttp400"
 )
 
-func MockHandler(logger logrus.FieldLogger, api API) http.Handler {
-	if logger == nil {
-		logger = logrus.StandardLogger()
-	}
+func MockHandler(logger Logger, api API) http.Handler {
 	router := mux.NewRouter()
 	router.Methods(http.MethodGet).
 		Path("/feed/v1").
@@ -464,12 +581,10 @@ func MockHandler(logger logrus.FieldLogger, api API) http.Handler {
 }
 
 func TestMockResponses(t *testing.T) {
-	log := logrus.New()
-	h := hookstest.NewLocal(log)
-	logrus.AddHook(h)
+	log := &recordingLogger{}
 
 	server := httptest.NewServer(MockHandler(nil, NewTestZeroEventHubAPI()))
-	client := NewClient(server.URL, 2)
+	client := NewClient(server.URL, 2).WithLogger(log)
 	var page EventPageSingleType[TestEvent]
 
 	err := client.FetchEvents(context.Background(), []Cursor{{Cursor: cursorReturn500}}, DefaultPageSize, &page, All)
@@ -480,11 +595,11 @@ func TestMockResponses(t *testing.T) {
 	// Checking logged entries
 	http500logged := false
 	http504logged := false
-	for _, e := range h.AllEntries() {
-		if e.Data["responseCode"] == "500" {
+	for _, e := range log.Entries() {
+		if e.Fields["responseCode"] == "500" {
 			http500logged = true
 		}
-		if e.Data["responseCode"] == "504" {
+		if e.Fields["responseCode"] == "504" {
 			http504logged = true
 		}
 	}
@@ -492,3 +607,228 @@ func TestMockResponses(t *testing.T) {
 	assert.True(t, http500logged)
 	assert.True(t, http504logged)
 }
+
+func TestPageSizeHintValidation(t *testing.T) {
+	var requests int
+	handler := Handler(nil, NewTestZeroEventHubAPI())
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		handler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	var page EventPageRaw
+	err := NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, -1, &page)
+	require.Equal(t, ErrIllegalPageSizeHint, err)
+	require.Equal(t, 0, requests)
+
+	for _, hint := range []string{"-1", "x", ""} {
+		res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=10&pagesizehint=" + hint)
+		require.NoError(t, err)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, http.StatusBadRequest, res.StatusCode, hint)
+		require.Equal(t, ErrIllegalPageSizeHint.Error()+"\n", string(body))
+	}
+}
+
+func TestEmptyCursor(t *testing.T) {
+	var requests int
+	handler := Handler(nil, NewTestZeroEventHubAPI())
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		handler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	var page EventPageRaw
+	err := client.FetchEvents(context.Background(), []Cursor{{PartitionID: 0, Cursor: "10"}, {PartitionID: 1, Cursor: ""}}, 5, &page)
+	require.Equal(t, ErrEmptyCursor, err)
+	require.Equal(t, 0, requests)
+
+	cursors := []Cursor{{PartitionID: 0, Cursor: "10"}, {PartitionID: 1, Cursor: ""}}
+	require.NoError(t, client.WithEmptyCursorAsFirst().FetchEvents(context.Background(), cursors, 5, &page))
+	require.Equal(t, "", cursors[1].Cursor)
+	require.Equal(t, map[int]string{0: "15", 1: "4"}, page.Cursors)
+
+	// the empty value used to be passed on to the API as a cursor
+	res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=10&cursor1=")
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	require.Equal(t, ErrEmptyCursor.Error()+"\n", string(body))
+}
+
+func TestRequestTimeout(t *testing.T) {
+	slowServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-request.Context().Done():
+			return
+		}
+		_, _ = writer.Write([]byte(`{"partition":0,"cursor":"1"}` + "\n"))
+	}))
+	defer slowServer.Close()
+	cursors := []Cursor{{Cursor: FirstCursor}}
+
+	var page EventPageRaw
+	client := NewClient(slowServer.URL, 1).WithRequestTimeout(50 * time.Millisecond)
+	err := client.FetchEvents(context.Background(), cursors, DefaultPageSize, &page)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// the caller's context wins when its deadline is shorter
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client = NewClient(slowServer.URL, 1).WithRequestTimeout(time.Minute)
+	err = client.FetchEvents(ctx, cursors, DefaultPageSize, &page)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	page = EventPageRaw{}
+	client = NewClient(slowServer.URL, 1).WithRequestTimeout(5 * time.Second)
+	err = client.FetchEvents(context.Background(), cursors, DefaultPageSize, &page)
+	require.NoError(t, err)
+	require.Equal(t, map[int]string{0: "1"}, page.Cursors)
+}
+
+func TestCursorHelpers(t *testing.T) {
+	require.True(t, IsSpecialCursor(FirstCursor))
+	require.True(t, IsSpecialCursor(LastCursor))
+	require.False(t, IsSpecialCursor("123"))
+	require.False(t, IsSpecialCursor(""))
+	require.Equal(t, Cursor{PartitionID: 1, Cursor: "123"}, StartAfter(1, "123"))
+
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	client := NewClient(server.URL, 2)
+	var page EventPageSingleType[TestEvent]
+	err := client.FetchEvents(context.Background(), []Cursor{StartAfter(1, "123")}, 1, &page)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	require.Equal(t, 124, page.Events[0].Data.Cursor)
+	require.Equal(t, map[int]string{1: "124"}, page.Cursors)
+}
+
+// blockingZeroEventHubAPI emits a single event and then blocks until the request context is cancelled.
+type blockingZeroEventHubAPI struct {
+	TestZeroEventHubAPI
+	started   chan struct{}
+	cancelled chan error
+}
+
+func (t blockingZeroEventHubAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	if err := r.Event(0, nil, mustMarshalJson(TestEvent{})); err != nil {
+		return err
+	}
+	close(t.started)
+	select {
+	case <-ctx.Done():
+		t.cancelled <- ctx.Err()
+	case <-time.After(10 * time.Second):
+		t.cancelled <- nil
+	}
+	return ctx.Err()
+}
+
+func TestClientDisconnectCancelsFetchEvents(t *testing.T) {
+	api := blockingZeroEventHubAPI{
+		TestZeroEventHubAPI: *NewTestZeroEventHubAPI(),
+		started:             make(chan struct{}),
+		cancelled:           make(chan error, 1),
+	}
+	server := httptest.NewServer(Handler(nil, api))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-api.started
+		cancel()
+	}()
+	var page EventPageRaw
+	err := NewClient(server.URL, 2).FetchEvents(ctx, []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	select {
+	case err := <-api.cancelled:
+		require.Equal(t, context.Canceled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("FetchEvents was not cancelled after the client disconnected")
+	}
+}
+
+func TestDrainReceiver(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	client := NewClient(server.URL, 2)
+	var drain DrainReceiver
+	require.Equal(t, "", drain.LastCursor(0))
+	err := client.FetchEvents(context.Background(), []Cursor{
+		{PartitionID: 0, Cursor: LastCursor},
+		{PartitionID: 1, Cursor: "4999"},
+	}, 10, &drain)
+	require.NoError(t, err)
+	require.Equal(t, "9999", drain.LastCursor(0))
+	require.Equal(t, "5009", drain.LastCursor(1))
+	require.Equal(t, map[int]string{0: "9999", 1: "5009"}, drain.Cursors)
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// streams checkpoints until the client goes away
+		for i := 0; request.Context().Err() == nil; i++ {
+			if _, err := fmt.Fprintf(writer, `{"partition":0,"cursor":"%d"}`+"\n", i); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var page EventPageRaw
+	err := NewClient(server.URL, 1).WithMaxResponseBytes(1000).FetchEvents(ctx, []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page)
+	require.Equal(t, ErrResponseTooLarge, err)
+	// every line before the limit was received
+	require.Equal(t, map[int]string{0: "32"}, page.Cursors)
+
+	// a response of exactly the limit is fine
+	exactServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"partition":0,"cursor":"1"}` + "\n"))
+	}))
+	defer exactServer.Close()
+	page = EventPageRaw{}
+	err = NewClient(exactServer.URL, 1).WithMaxResponseBytes(29).FetchEvents(ctx, []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page)
+	require.NoError(t, err)
+	require.Equal(t, map[int]string{0: "1"}, page.Cursors)
+}
+
+func TestResponseHeaders(t *testing.T) {
+	handler := WithResponseHeaders(Handler(nil, timestampedAPI{NewTestZeroEventHubAPI()}), map[string]string{
+		"Cache-Control": "no-store",
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+	}{
+		{path: "/feed/v1?n=2&cursor0=_first", status: http.StatusOK, contentType: ContentType},
+		{path: "/feed/v1/tail?n=2&partition=0", status: http.StatusOK, contentType: ContentType},
+		{path: "/feed/v1/seek?n=2&partition=0&from=2024-03-01T00:00:00Z", status: http.StatusOK, contentType: ContentType},
+		// errors are plain text
+		{path: "/feed/v1?n=3&cursor0=_first", status: http.StatusBadRequest, contentType: "text/plain; charset=utf-8"},
+	}
+	for _, test := range tests {
+		res, err := http.Get(server.URL + test.path)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		require.Equal(t, test.status, res.StatusCode, test.path)
+		require.Equal(t, test.contentType, res.Header.Get("Content-Type"), test.path)
+		require.Equal(t, "nosniff", res.Header.Get("X-Content-Type-Options"), test.path)
+		require.Equal(t, "no-store", res.Header.Get("Cache-Control"), test.path)
+	}
+}
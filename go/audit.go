@@ -0,0 +1,352 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AuditSink records the requests for events served by Handler, set in HandlerOptions.AuditSink, e.g. for a
+// compliance record of who read what. RecordAccess is called once per request, after the response is complete,
+// whether it succeeded or not, and before the handler returns; slow sinks should be wrapped in an AsyncAuditSink.
+// ctx is the context of the request, which may be done.
+type AuditSink interface {
+	RecordAccess(ctx context.Context, rec AccessRecord)
+}
+
+// AccessRecord describes a request for events, for AuditSink.
+type AccessRecord struct {
+	// Principal is the principal of RequireBearer, or else the subject of the client certificate from
+	// WithPeerIdentity; nil for anonymous requests.
+	Principal  Principal
+	RemoteAddr string
+	Feed       string
+	// Partitions has an entry per cursor requested; it is empty for requests rejected before the cursors were
+	// parsed.
+	Partitions []PartitionAccess
+	// Events and Bytes are the number of events and bytes written in the response.
+	Events   int
+	Bytes    int64
+	Duration time.Duration
+	// Status is the HTTP status of the response. A response failing after the first event has been written has
+	// status 200 and Err set.
+	Status int
+	// Aborted is true if the client went away before the response was complete.
+	Aborted bool
+	Err     error
+}
+
+// PartitionAccess is the part of an AccessRecord for one partition.
+type PartitionAccess struct {
+	PartitionID int
+	StartCursor string
+	// EndCursor is the last checkpoint written, or StartCursor if there was none.
+	EndCursor string
+	Events    int
+}
+
+// accessAudit is a http.ResponseWriter building the AccessRecord of a request served by Handler. Its methods do
+// nothing on a nil accessAudit, which is used when there is no AuditSink.
+type accessAudit struct {
+	http.ResponseWriter
+	request *http.Request
+	start   time.Time
+	record  AccessRecord
+	// partitions maps partition IDs to their index in record.Partitions
+	partitions map[int]int
+}
+
+func newAccessAudit(sink AuditSink, api API, writer http.ResponseWriter, request *http.Request) *accessAudit {
+	if sink == nil {
+		return nil
+	}
+	return &accessAudit{
+		ResponseWriter: writer,
+		request:        request,
+		start:          time.Now(),
+		record:         AccessRecord{RemoteAddr: request.RemoteAddr, Feed: api.GetName()},
+	}
+}
+
+func (a *accessAudit) WriteHeader(status int) {
+	if a.record.Status == 0 {
+		a.record.Status = status
+	}
+	a.ResponseWriter.WriteHeader(status)
+}
+
+func (a *accessAudit) Write(p []byte) (int, error) {
+	if a.record.Status == 0 {
+		a.record.Status = http.StatusOK
+	}
+	n, err := a.ResponseWriter.Write(p)
+	a.record.Bytes += int64(n)
+	if err != nil {
+		// writes fail once the connection is closed, possibly before the request context is cancelled
+		a.record.Aborted = true
+	}
+	return n, err
+}
+
+func (a *accessAudit) Flush() {
+	if flusher, ok := a.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (a *accessAudit) Unwrap() http.ResponseWriter {
+	return a.ResponseWriter
+}
+
+// writer returns the writer to respond with: a itself, or writer if a is nil.
+func (a *accessAudit) writer(writer http.ResponseWriter) http.ResponseWriter {
+	if a == nil {
+		return writer
+	}
+	return a
+}
+
+// cursors records the cursors requested.
+func (a *accessAudit) cursors(cursors []Cursor) {
+	if a == nil {
+		return
+	}
+	a.partitions = make(map[int]int, len(cursors))
+	for _, cursor := range cursors {
+		a.partitions[cursor.PartitionID] = len(a.record.Partitions)
+		a.record.Partitions = append(a.record.Partitions, PartitionAccess{
+			PartitionID: cursor.PartitionID,
+			StartCursor: cursor.Cursor,
+			EndCursor:   cursor.Cursor,
+		})
+	}
+}
+
+// receiver returns receiver wrapped to count the events and record the checkpoints written. With a capped receiver
+// below it, the lines are only recorded once capped has flushed them, as it may drop them.
+func (a *accessAudit) receiver(receiver EventReceiver, capped *cappedReceiver) EventReceiver {
+	if a == nil {
+		return receiver
+	}
+	r := &auditReceiver{receiver: receiver, audit: a}
+	if capped != nil {
+		r.deferred = true
+		capped.flushed = r.commit
+	}
+	return r
+}
+
+// fail records the error FetchEvents returned.
+func (a *accessAudit) fail(err error) {
+	if a != nil {
+		a.record.Err = err
+	}
+}
+
+// emit passes the record to sink, once the response is complete.
+func (a *accessAudit) emit(sink AuditSink) {
+	if a == nil {
+		return
+	}
+	ctx := a.request.Context()
+	a.record.Duration = time.Since(a.start)
+	if a.record.Status == 0 {
+		a.record.Status = http.StatusOK
+	}
+	a.record.Aborted = a.record.Aborted || ctx.Err() != nil
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		a.record.Principal = principal
+	} else if peer, ok := PeerIdentityFromContext(ctx); ok {
+		a.record.Principal = peer.Subject
+	}
+	sink.RecordAccess(ctx, a.record)
+}
+
+// auditReceiver is an EventReceiver counting the events and recording the checkpoints written to receiver. If
+// deferred is set, the lines are kept in pending until commit is called.
+type auditReceiver struct {
+	receiver EventReceiver
+	audit    *accessAudit
+	deferred bool
+	pending  []auditedLine
+}
+
+// auditedLine is an event, or a checkpoint if checkpoint is set.
+type auditedLine struct {
+	partitionID int
+	checkpoint  bool
+	cursor      string
+}
+
+func (r *auditReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	return r.pass(auditedLine{partitionID: partitionID}, func() error {
+		return r.receiver.Event(partitionID, headers, data)
+	})
+}
+
+func (r *auditReceiver) Checkpoint(partitionID int, cursor string) error {
+	return r.pass(auditedLine{partitionID: partitionID, checkpoint: true, cursor: cursor}, func() error {
+		return r.receiver.Checkpoint(partitionID, cursor)
+	})
+}
+
+// pass records line once write has passed it to the receiver, or, if deferred, once it is committed.
+func (r *auditReceiver) pass(line auditedLine, write func() error) error {
+	if !r.deferred {
+		if err := write(); err != nil {
+			return err
+		}
+		r.record(line)
+		return nil
+	}
+	// write may flush, committing the line
+	r.pending = append(r.pending, line)
+	if err := write(); err != nil {
+		// the lines not flushed were dropped
+		r.pending = r.pending[:0]
+		return err
+	}
+	return nil
+}
+
+// commit records the pending lines, once they are written.
+func (r *auditReceiver) commit() {
+	for _, line := range r.pending {
+		r.record(line)
+	}
+	r.pending = r.pending[:0]
+}
+
+func (r *auditReceiver) record(line auditedLine) {
+	i, ok := r.audit.partitions[line.partitionID]
+	switch {
+	case line.checkpoint:
+		if ok {
+			r.audit.record.Partitions[i].EndCursor = line.cursor
+		}
+	default:
+		r.audit.record.Events++
+		if ok {
+			r.audit.record.Partitions[i].Events++
+		}
+	}
+}
+
+// NewLoggerAuditSink returns an AuditSink logging every record at Info with the event "<feed>.access", e.g. to a
+// logrus logger with logrusadapter or to an slog.Logger with NewSlogLogger.
+func NewLoggerAuditSink(logger Logger) AuditSink {
+	return loggerAuditSink{logger: orNop(logger)}
+}
+
+type loggerAuditSink struct {
+	logger Logger
+}
+
+func (s loggerAuditSink) RecordAccess(ctx context.Context, rec AccessRecord) {
+	entry := s.logger.
+		WithContext(ctx).
+		WithField("event", rec.Feed+".access").
+		WithField("Principal", rec.Principal).
+		WithField("RemoteAddr", rec.RemoteAddr).
+		WithField("Partitions", rec.Partitions).
+		WithField("Events", rec.Events).
+		WithField("Bytes", rec.Bytes).
+		WithField("Duration", rec.Duration).
+		WithField("Status", rec.Status).
+		WithField("Aborted", rec.Aborted)
+	if rec.Err != nil {
+		entry = entry.WithError(rec.Err)
+	}
+	entry.Info()
+}
+
+// AsyncAuditSink is an AuditSink passing the records on to another AuditSink from a goroutine of its own, so that a
+// slow sink, e.g. a database, never holds up the responses. The records are buffered; when the buffer is full they
+// are dropped rather than blocking, and counted by Dropped. Close stops it after passing on the buffered records.
+type AsyncAuditSink struct {
+	sink    AuditSink
+	records chan asyncAccessRecord
+	done    chan struct{}
+	dropped uint64
+	closing sync.Once
+	lock    sync.RWMutex
+	closed  bool
+}
+
+type asyncAccessRecord struct {
+	ctx context.Context
+	rec AccessRecord
+}
+
+// NewAsyncAuditSink returns an AsyncAuditSink buffering up to bufferSize records for sink.
+func NewAsyncAuditSink(sink AuditSink, bufferSize int) *AsyncAuditSink {
+	s := &AsyncAuditSink{
+		sink:    sink,
+		records: make(chan asyncAccessRecord, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		for record := range s.records {
+			s.sink.RecordAccess(record.ctx, record.rec)
+		}
+	}()
+	return s
+}
+
+func (s *AsyncAuditSink) RecordAccess(ctx context.Context, rec AccessRecord) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		atomic.AddUint64(&s.dropped, 1)
+		return
+	}
+	select {
+	// the request is over by the time the record is passed on, so only the values of its context are kept
+	case s.records <- asyncAccessRecord{ctx: valuesContext{ctx}, rec: rec}:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+}
+
+// Dropped returns the number of records dropped because the buffer was full, or the sink closed.
+func (s *AsyncAuditSink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close passes on the buffered records and stops the AsyncAuditSink, returning when done or when ctx is done.
+// Records after Close are dropped.
+func (s *AsyncAuditSink) Close(ctx context.Context) error {
+	s.closing.Do(func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.closed = true
+		close(s.records)
+	})
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// valuesContext is a context with the values of another context, but never done.
+type valuesContext struct {
+	context.Context
+}
+
+func (valuesContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (valuesContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (valuesContext) Err() error {
+	return nil
+}
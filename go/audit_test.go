@@ -0,0 +1,183 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// channelAuditSink is an AuditSink sending the records to a channel.
+type channelAuditSink chan AccessRecord
+
+func (s channelAuditSink) RecordAccess(ctx context.Context, rec AccessRecord) {
+	s <- rec
+}
+
+// endlessAPI is an API writing events to partition 0 until the client goes away.
+type endlessAPI struct {
+	*TestZeroEventHubAPI
+}
+
+func (endlessAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	for ctx.Err() == nil {
+		if err := r.Event(0, nil, json.RawMessage(`{"padding":"`+strings.Repeat("x", 1000)+`"}`)); err != nil {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return ctx.Err()
+}
+
+func TestAuditSink(t *testing.T) {
+	sink := make(channelAuditSink, 1)
+	handler := HandlerWithOptions(nil, NewTestZeroEventHubAPI(), HandlerOptions{AuditSink: sink})
+	server := httptest.NewServer(RequireBearer(handler, verifyTestToken))
+	defer server.Close()
+	client := NewClient(server.URL, 2).WithRequestProcessor(func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer alice")
+		return nil
+	})
+
+	// success
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: 0, Cursor: "10"}, {PartitionID: 1, Cursor: "20"}}, 5, &page))
+	rec := <-sink
+	require.Equal(t, Principal("alice"), rec.Principal)
+	require.Equal(t, "TestZeroEventHubAPI", rec.Feed)
+	require.NotEmpty(t, rec.RemoteAddr)
+	require.Equal(t, []PartitionAccess{
+		{PartitionID: 0, StartCursor: "10", EndCursor: "15", Events: 5},
+		{PartitionID: 1, StartCursor: "20", EndCursor: "25", Events: 5},
+	}, rec.Partitions)
+	require.Equal(t, 10, rec.Events)
+	require.True(t, rec.Bytes > 500, rec.Bytes)
+	require.True(t, rec.Duration > 0)
+	require.Equal(t, http.StatusOK, rec.Status)
+	require.False(t, rec.Aborted)
+	require.NoError(t, rec.Err)
+
+	// publisher error
+	require.Error(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: cursorReturn500}}, 5, &page))
+	rec = <-sink
+	require.Equal(t, http.StatusInternalServerError, rec.Status)
+	require.Equal(t, err500, rec.Err)
+	require.Equal(t, []PartitionAccess{{PartitionID: 0, StartCursor: cursorReturn500, EndCursor: cursorReturn500}}, rec.Partitions)
+	require.Equal(t, 0, rec.Events)
+
+	// rejected request
+	require.Error(t, NewClient(server.URL, 3).WithRequestProcessor(func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer alice")
+		return nil
+	}).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	rec = <-sink
+	require.Equal(t, http.StatusBadRequest, rec.Status)
+	require.Empty(t, rec.Partitions)
+}
+
+// cancellingReceiver is an EventReceiver cancelling the fetch after cancelAfter events.
+type cancellingReceiver struct {
+	EventPageRaw
+	cancelAfter int
+	cancel      context.CancelFunc
+}
+
+func (r *cancellingReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if len(r.Events) == r.cancelAfter-1 {
+		r.cancel()
+	}
+	return r.EventPageRaw.Event(partitionID, headers, data)
+}
+
+func TestAuditSinkClientAbort(t *testing.T) {
+	sink := make(channelAuditSink, 1)
+	server := httptest.NewServer(HandlerWithOptions(nil, endlessAPI{NewTestZeroEventHubAPI()}, HandlerOptions{AuditSink: sink}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := NewClient(server.URL, 2).FetchEvents(ctx, []Cursor{{Cursor: "10"}}, 5, &cancellingReceiver{cancelAfter: 10, cancel: cancel})
+	require.Error(t, err)
+
+	select {
+	case rec := <-sink:
+		require.True(t, rec.Aborted)
+		require.Error(t, rec.Err)
+		require.Equal(t, http.StatusOK, rec.Status)
+		require.True(t, rec.Events >= 10, rec.Events)
+		require.Equal(t, rec.Events, rec.Partitions[0].Events)
+		require.Nil(t, rec.Principal)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no record for aborted request")
+	}
+}
+
+// blockingAuditSink is an AuditSink blocking until release is closed.
+type blockingAuditSink struct {
+	release  chan struct{}
+	recorded chan asyncAccessRecord
+}
+
+func (s blockingAuditSink) RecordAccess(ctx context.Context, rec AccessRecord) {
+	<-s.release
+	s.recorded <- asyncAccessRecord{ctx: ctx, rec: rec}
+}
+
+func TestAsyncAuditSink(t *testing.T) {
+	blocking := blockingAuditSink{release: make(chan struct{}), recorded: make(chan asyncAccessRecord, 10)}
+	sink := NewAsyncAuditSink(blocking, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// one record is being passed on, two are buffered and two are dropped
+		for i := 0; i < 5; i++ {
+			sink.RecordAccess(ctx, AccessRecord{Events: i})
+			if i == 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RecordAccess blocked")
+	}
+	require.Equal(t, uint64(2), sink.Dropped())
+
+	close(blocking.release)
+	require.NoError(t, sink.Close(context.Background()))
+	close(blocking.recorded)
+	var events []int
+	for record := range blocking.recorded {
+		// the records outlive the cancelled request contexts
+		require.NoError(t, record.ctx.Err())
+		events = append(events, record.rec.Events)
+	}
+	require.Equal(t, []int{0, 1, 2}, events)
+
+	sink.RecordAccess(context.Background(), AccessRecord{})
+	require.Equal(t, uint64(3), sink.Dropped())
+	require.NoError(t, sink.Close(context.Background()))
+}
+
+func TestLoggerAuditSink(t *testing.T) {
+	log := &recordingLogger{}
+	server := httptest.NewServer(HandlerWithOptions(nil, NewTestZeroEventHubAPI(), HandlerOptions{AuditSink: NewLoggerAuditSink(log)}))
+	defer server.Close()
+
+	var page EventPageRaw
+	require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{PartitionID: 1, Cursor: "10"}}, 5, &page))
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "info", entries[0].Level)
+	require.Equal(t, "TestZeroEventHubAPI.access", entries[0].Fields["event"])
+	require.Equal(t, 5, entries[0].Fields["Events"])
+	require.Equal(t, []PartitionAccess{{PartitionID: 1, StartCursor: "10", EndCursor: "15", Events: 5}}, entries[0].Fields["Partitions"])
+}
@@ -0,0 +1,48 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Principal is the caller of a request authenticated by RequireBearer, as returned by its verifier, e.g. the
+// claims of a JWT. Publishers can get it with PrincipalFromContext, e.g. to filter the events a caller may read.
+type Principal interface{}
+
+var (
+	ErrBearerTokenMissing = NewAPIError("missing bearer token", http.StatusUnauthorized)
+	ErrBearerTokenInvalid = NewAPIError("invalid bearer token", http.StatusUnauthorized)
+)
+
+type principalContextKey struct{}
+
+// PrincipalFromContext returns the principal authenticated by RequireBearer for the request being served; ok is
+// false for requests not served through RequireBearer.
+func PrincipalFromContext(ctx context.Context) (principal Principal, ok bool) {
+	principal = ctx.Value(principalContextKey{})
+	return principal, principal != nil
+}
+
+// RequireBearer wraps a handler, e.g. the one returned by Handler, requiring a bearer token in the Authorization
+// header that verify accepts. Requests without a token, or with one verify returns an error for, are rejected with
+// 401 and a WWW-Authenticate header. The principal returned by verify, which must not be nil, is put in the
+// request context, where it is available to API.FetchEvents with PrincipalFromContext. The jwtauth module has a
+// verifier for JWTs.
+func RequireBearer(handler http.Handler, verify func(ctx context.Context, token string) (Principal, error)) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		scheme, token, _ := strings.Cut(request.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			writer.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(writer, ErrBearerTokenMissing.Error(), ErrBearerTokenMissing.Status())
+			return
+		}
+		principal, err := verify(request.Context(), token)
+		if err != nil || principal == nil {
+			writer.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(writer, ErrBearerTokenInvalid.Error(), ErrBearerTokenInvalid.Status())
+			return
+		}
+		handler.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), principalContextKey{}, principal)))
+	})
+}
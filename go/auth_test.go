@@ -0,0 +1,77 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// principalAPI is a TestZeroEventHubAPI recording the principal of the last request.
+type principalAPI struct {
+	*TestZeroEventHubAPI
+	principal *Principal
+}
+
+func (p principalAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	*p.principal, _ = PrincipalFromContext(ctx)
+	return p.TestZeroEventHubAPI.FetchEvents(ctx, cursors, pageSizeHint, r, headers...)
+}
+
+func verifyTestToken(ctx context.Context, token string) (Principal, error) {
+	switch token {
+	case "alice":
+		return "alice", nil
+	case "expired":
+		return nil, errors.New("token is expired")
+	}
+	return nil, errors.New("unknown token")
+}
+
+func TestRequireBearer(t *testing.T) {
+	api := principalAPI{TestZeroEventHubAPI: NewTestZeroEventHubAPI(), principal: new(Principal)}
+	server := httptest.NewServer(RequireBearer(Handler(nil, api), verifyTestToken))
+	defer server.Close()
+	withToken := func(authorization string) Client {
+		return NewClient(server.URL, 2).WithRequestProcessor(func(r *http.Request) error {
+			r.Header.Set("Authorization", authorization)
+			return nil
+		})
+	}
+
+	var page EventPageRaw
+	require.NoError(t, withToken("Bearer alice").FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	require.Len(t, page.Events, 5)
+	require.Equal(t, "alice", *api.principal)
+
+	tests := []struct {
+		authorization   string
+		err             StatusError
+		wwwAuthenticate string
+	}{
+		{authorization: "", err: ErrBearerTokenMissing, wwwAuthenticate: "Bearer"},
+		{authorization: "Basic YWxpY2U6", err: ErrBearerTokenMissing, wwwAuthenticate: "Bearer"},
+		{authorization: "Bearer ", err: ErrBearerTokenMissing, wwwAuthenticate: "Bearer"},
+		{authorization: "Bearer expired", err: ErrBearerTokenInvalid, wwwAuthenticate: `Bearer error="invalid_token"`},
+		{authorization: "bearer bob", err: ErrBearerTokenInvalid, wwwAuthenticate: `Bearer error="invalid_token"`},
+	}
+	for _, test := range tests {
+		err := withToken(test.authorization).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page)
+		require.Equal(t, &ResponseError{StatusCode: http.StatusUnauthorized, Body: test.err.Error() + "\n"}, err, test.authorization)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/feed/v1/tail?n=2&partition=0", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", test.authorization)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode, test.authorization)
+		require.Equal(t, test.wwwAuthenticate, res.Header.Get("WWW-Authenticate"), test.authorization)
+	}
+
+	_, ok := PrincipalFromContext(context.Background())
+	require.False(t, ok)
+}
@@ -0,0 +1,269 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// The benchmarks of encoding and decoding, meant to be quick enough to run in CI with -benchtime=1x. Build with
+// -tags profbench to also write CPU and heap profiles of every benchmark (see profile).
+
+const (
+	// benchmarkEventCount is the number of events served and serialized by the benchmarks.
+	benchmarkEventCount = 10000
+	// benchmarkResponseBytes is the approximate size of the canned response parsed by BenchmarkFetchEventsParse.
+	benchmarkResponseBytes = 10 << 20
+)
+
+// benchmarkFixtures are generated once, outside of the timed part of the benchmarks.
+var benchmarkFixtures struct {
+	once sync.Once
+	// events are the data of benchmarkEventCount events
+	events []json.RawMessage
+	// response is a response of about benchmarkResponseBytes with responseEvents events, every other one with
+	// headers and each followed by a checkpoint
+	response       []byte
+	responseEvents int
+}
+
+func loadBenchmarkFixtures() {
+	benchmarkFixtures.once.Do(func() {
+		for i := 0; i < benchmarkEventCount; i++ {
+			benchmarkFixtures.events = append(benchmarkFixtures.events, json.RawMessage(`{"id":`+strconv.Itoa(i)+`,"name":"generated event","tags":["a","b"]}`))
+		}
+		var body bytes.Buffer
+		for i := 0; body.Len() < benchmarkResponseBytes; i++ {
+			if i%2 == 0 {
+				fmt.Fprintf(&body, `{"partition":0,"headers":{"type":"test"},"data":{"id":%d,"name":"event %d","tags":["a","b"]}}`+"\n", i, i)
+			} else {
+				fmt.Fprintf(&body, `{"partition":0,"data":{"id":%d,"name":"event %d","tags":["a","b"]}}`+"\n", i, i)
+			}
+			fmt.Fprintf(&body, `{"partition":0,"cursor":"%d"}`+"\n", i)
+			benchmarkFixtures.responseEvents++
+		}
+		benchmarkFixtures.response = body.Bytes()
+	})
+}
+
+// generatingAPI serves the events of the fixtures in a single partition.
+type generatingAPI struct{}
+
+func (generatingAPI) GetName() string {
+	return "generatingAPI"
+}
+
+func (generatingAPI) GetPartitionCount() int {
+	return 1
+}
+
+func (generatingAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	headerValues := map[string]string{"type": "test"}
+	for i := 0; i < pageSizeHint && i < len(benchmarkFixtures.events); i++ {
+		if err := r.Event(0, headerValues, benchmarkFixtures.events[i]); err != nil {
+			return err
+		}
+		if err := r.Checkpoint(0, strconv.Itoa(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countingReceiver counts the events and the bytes of their data, without retaining anything.
+type countingReceiver struct {
+	events, bytes int
+}
+
+func (r *countingReceiver) Event(_ int, _ map[string]string, data json.RawMessage) error {
+	r.events++
+	r.bytes += len(data)
+	return nil
+}
+
+func (r *countingReceiver) Checkpoint(int, string) error {
+	return nil
+}
+
+// BenchmarkNDJSONEventSerializer measures serializing a page of benchmarkEventCount events.
+func BenchmarkNDJSONEventSerializer(b *testing.B) {
+	loadBenchmarkFixtures()
+	serializer := HeaderFilter{Receiver: NewNDJSONEventSerializer(io.Discard), Requested: []string{All}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	defer profile(b)()
+	for i := 0; i < b.N; i++ {
+		if err := (generatingAPI{}).FetchEvents(context.Background(), nil, benchmarkEventCount, serializer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFetchEventsParse measures parsing a response of about benchmarkResponseBytes in the client, without
+// the network.
+func BenchmarkFetchEventsParse(b *testing.B) {
+	loadBenchmarkFixtures()
+	client := NewClient("http://example.com", 1).WithHttpClient(&http.Client{Transport: cannedTransport{body: benchmarkFixtures.response}})
+	cursors := []Cursor{{PartitionID: 0, Cursor: FirstCursor}}
+
+	for _, reuse := range []bool{false, true} {
+		b.Run(fmt.Sprintf("reuse=%v", reuse), func(b *testing.B) {
+			client := client.WithReusedEventData(reuse)
+			b.ReportAllocs()
+			b.SetBytes(int64(len(benchmarkFixtures.response)))
+			defer profile(b)()
+			for i := 0; i < b.N; i++ {
+				var receiver countingReceiver
+				if err := client.FetchEvents(context.Background(), cursors, DefaultPageSize, &receiver); err != nil {
+					b.Fatal(err)
+				}
+				if receiver.events != benchmarkFixtures.responseEvents {
+					b.Fatalf("got %d events", receiver.events)
+				}
+			}
+		})
+	}
+
+	// fetching page after page into an EventPageRaw
+	for _, reuse := range []bool{false, true} {
+		b.Run(fmt.Sprintf("page/reuse=%v", reuse), func(b *testing.B) {
+			client := client.WithReusedEventData(reuse)
+			page := EventPageRaw{ReuseBuffers: reuse}
+			b.ReportAllocs()
+			b.SetBytes(int64(len(benchmarkFixtures.response)))
+			defer profile(b)()
+			for i := 0; i < b.N; i++ {
+				if reuse {
+					page.Reset()
+				} else {
+					page = EventPageRaw{}
+				}
+				if err := client.FetchEvents(context.Background(), cursors, DefaultPageSize, &page); err != nil {
+					b.Fatal(err)
+				}
+				if len(page.Events) != benchmarkFixtures.responseEvents {
+					b.Fatalf("got %d events", len(page.Events))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDecodeCheckpoints measures decoding the checkpoint lines of the response of BenchmarkFetchEventsParse,
+// which take the fast path of parseCheckpointLine.
+func BenchmarkDecodeCheckpoints(b *testing.B) {
+	loadBenchmarkFixtures()
+	var checkpoints []byte
+	for _, line := range bytes.SplitAfter(benchmarkFixtures.response, []byte("\n")) {
+		if bytes.Contains(line, []byte(`"cursor"`)) {
+			checkpoints = append(checkpoints, line...)
+		}
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(checkpoints)))
+	b.ResetTimer()
+	defer profile(b)()
+	for i := 0; i < b.N; i++ {
+		count := 0
+		err := decodeRawLines(bytes.NewReader(checkpoints), false, DecodeOptions{}, func(line *rawLine) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if count != benchmarkFixtures.responseEvents {
+			b.Fatalf("got %d checkpoints", count)
+		}
+	}
+}
+
+// BenchmarkHandlerServe measures serving and reading a page of benchmarkEventCount events through httptest.
+func BenchmarkHandlerServe(b *testing.B) {
+	loadBenchmarkFixtures()
+	server := httptest.NewServer(Handler(nil, generatingAPI{}))
+	defer server.Close()
+	client := NewClient(server.URL, 1).WithReusedEventData(true)
+	b.ReportAllocs()
+	b.ResetTimer()
+	defer profile(b)()
+	for i := 0; i < b.N; i++ {
+		var receiver countingReceiver
+		if err := client.FetchEvents(context.Background(), []Cursor{{PartitionID: 0, Cursor: FirstCursor}}, benchmarkEventCount, &receiver, All); err != nil {
+			b.Fatal(err)
+		}
+		if receiver.events != benchmarkEventCount {
+			b.Fatalf("got %d events", receiver.events)
+		}
+	}
+}
+
+// discardingLogger builds its fields like logrus does, copying them into a new map for every field, and
+// discards the entries logged from its minimum level.
+type discardingLogger struct {
+	fields map[string]interface{}
+	min    LogLevel
+}
+
+func (l discardingLogger) WithField(key string, value interface{}) Logger {
+	fields := make(map[string]interface{}, len(l.fields)+1)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fields[key] = value
+	return discardingLogger{fields: fields, min: l.min}
+}
+
+func (l discardingLogger) WithError(err error) Logger {
+	return l.WithField("error", err)
+}
+
+func (l discardingLogger) WithContext(context.Context) Logger {
+	return l
+}
+
+func (l discardingLogger) Enabled(level LogLevel) bool {
+	return level >= l.min
+}
+
+func (discardingLogger) Debug() {}
+func (discardingLogger) Info()  {}
+func (discardingLogger) Warn()  {}
+func (discardingLogger) Error() {}
+
+// BenchmarkHandlerRequestLogging measures the overhead of the request log on the poll of a single event, calling
+// the handler directly: "info" logs every request, "disabled" logs them at debug with a logger at info.
+func BenchmarkHandlerRequestLogging(b *testing.B) {
+	loadBenchmarkFixtures()
+	for _, bench := range []struct {
+		name   string
+		logger Logger
+		opts   HandlerOptions
+	}{
+		{name: "info", logger: discardingLogger{min: LevelInfo}, opts: HandlerOptions{RequestLogLevel: LevelInfo}},
+		{name: "disabled", logger: discardingLogger{min: LevelInfo}},
+		{name: "nil", logger: nil},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			handler := HandlerWithOptions(bench.logger, generatingAPI{}, bench.opts)
+			request := httptest.NewRequest(http.MethodGet, "/feed/v1?n=1&cursor0=_first&pagesizehint=1", nil)
+			b.ReportAllocs()
+			b.ResetTimer()
+			defer profile(b)()
+			for i := 0; i < b.N; i++ {
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, request)
+				if recorder.Code != http.StatusOK {
+					b.Fatalf("got status %d", recorder.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package zeroeventhub
+
+import (
+	"net/http"
+)
+
+// CaughtUpParam is the query parameter by which a client asks for caught-up checkpoints: with caughtup=1, the
+// server may mark the last checkpoint of a partition as being at the tail of the feed, i.e. there were no more
+// events when the page was written, as {"partition":0,"cursor":"...","caughtUp":true}. Without it, the server
+// writes a plain checkpoint, so the wire format is unchanged for clients not asking. A page without the mark may
+// still be at the tail, e.g. if the publisher doesn't support it.
+const CaughtUpParam = "caughtup"
+
+// ErrIllegalCaughtUp is returned by Handler for a caughtup parameter other than 0 or 1.
+var ErrIllegalCaughtUp = NewAPIError("illegal caughtup; expected 0 or 1", http.StatusBadRequest)
+
+// CaughtUpReceiver is an EventReceiver told when a checkpoint is at the tail of the feed. On the server side,
+// publishers call SendCaughtUp on the receiver passed to FetchEvents. On the client side, a Client asking for
+// caught-up checkpoints with WithCaughtUp calls CaughtUp instead of Checkpoint for them if its receiver implements
+// CaughtUpReceiver, e.g. for a tail follower to poll less often.
+type CaughtUpReceiver interface {
+	EventReceiver
+	// CaughtUp is Checkpoint for a checkpoint at the tail of the partition.
+	CaughtUp(partitionID int, cursor string) error
+}
+
+// SendCaughtUp passes a checkpoint at the tail of the partition to r: to CaughtUp if r implements CaughtUpReceiver,
+// and to Checkpoint otherwise. Publishers call it instead of Checkpoint for the last checkpoint of a partition when
+// they know there are no more events.
+func SendCaughtUp(r EventReceiver, partitionID int, cursor string) error {
+	if receiver, ok := r.(CaughtUpReceiver); ok {
+		return receiver.CaughtUp(partitionID, cursor)
+	}
+	return r.Checkpoint(partitionID, cursor)
+}
+
+func parseCaughtUp(s string) (bool, error) {
+	switch s {
+	case "", "0":
+		return false, nil
+	case "1":
+		return true, nil
+	default:
+		return false, ErrIllegalCaughtUp
+	}
+}
+
+// WithCaughtUp is a Client method for asking for caught-up checkpoints; see CaughtUpParam. Receivers not
+// implementing CaughtUpReceiver get them as plain checkpoints.
+func (c Client) WithCaughtUp(on bool) (r Client) {
+	r = c
+	r.caughtUp = on
+	return
+}
+
+// SetCaughtUpLines controls whether CaughtUp writes caught-up checkpoints; by default it writes plain checkpoints.
+// Handler turns it on for clients passing CaughtUpParam.
+func (s *NDJSONEventSerializer) SetCaughtUpLines(on bool) {
+	s.buffer.caughtUpLines = on
+}
+
+// CaughtUp writes a checkpoint, marked as caught up if enabled with SetCaughtUpLines.
+func (s NDJSONEventSerializer) CaughtUp(partitionID int, cursor string) error {
+	line := s.buffer.checkpoint(partitionID, cursor)
+	if s.buffer.caughtUpLines {
+		line = append(line[:len(line)-2], `,"caughtUp":true}`+"\n"...)
+		s.buffer.line = line
+	}
+	_, err := s.writer.Write(line)
+	return err
+}
+
+func (s *PagedSerializer) CaughtUp(partitionID int, cursor string) error {
+	s.checkpointed = true
+	return s.serializer.CaughtUp(partitionID, cursor)
+}
+
+func (f HeaderFilter) CaughtUp(partitionID int, cursor string) error {
+	return SendCaughtUp(f.Receiver, partitionID, cursor)
+}
+
+func (c *cappedReceiver) CaughtUp(partitionID int, cursor string) error {
+	if err := SendCaughtUp(c.receiver, partitionID, cursor); err != nil {
+		return err
+	}
+	if err := c.check(); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+func (r *auditReceiver) CaughtUp(partitionID int, cursor string) error {
+	return r.pass(auditedLine{partitionID: partitionID, checkpoint: true, cursor: cursor}, func() error {
+		return SendCaughtUp(r.receiver, partitionID, cursor)
+	})
+}
+
+func (c *eventCounter) CaughtUp(partitionID int, cursor string) error {
+	return SendCaughtUp(c.receiver, partitionID, cursor)
+}
+
+func (t *checkpointTracker) CaughtUp(partitionID int, cursor string) error {
+	t.calls++
+	if t.receiverErr = SendCaughtUp(t.receiver, partitionID, cursor); t.receiverErr != nil {
+		return t.receiverErr
+	}
+	t.checkpointed(partitionID, cursor)
+	return nil
+}
+
+func (l *lockedReceiver) CaughtUp(partitionID int, cursor string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return SendCaughtUp(l.receiver, partitionID, cursor)
+}
+
+func (p *progressReceiver) CaughtUp(partitionID int, cursor string) error {
+	p.cursor = cursor
+	p.caughtUp = true
+	return SendCaughtUp(p.receiver, partitionID, cursor)
+}
+
+func (p *bufferedPage) CaughtUp(partitionID int, cursor string) error {
+	p.cursor = cursor
+	p.caughtUp = true
+	p.calls = append(p.calls, bufferedCall{checkpoint: true, caughtUp: true, partitionID: partitionID, cursor: cursor})
+	return nil
+}
+
+func (m *orderedMerger) CaughtUp(partitionID int, cursor string) error {
+	m.streams[partitionID] = append(m.streams[partitionID], Line{
+		Kind:       LineCheckpoint,
+		Checkpoint: &Cursor{PartitionID: partitionID, Cursor: cursor},
+		CaughtUp:   true,
+	})
+	return nil
+}
+
+// CaughtUp always forwards the checkpoint, as it is the last one for now.
+func (b *CheckpointBatcher) CaughtUp(partitionID int, cursor string) error {
+	b.counts[partitionID] = 0
+	delete(b.pending, partitionID)
+	return SendCaughtUp(b.receiver, partitionID, cursor)
+}
+
+// CaughtUp records the cursor once receiver has accepted it.
+func (t *CursorTracker) CaughtUp(partitionID int, cursor string) error {
+	if err := SendCaughtUp(t.receiver, partitionID, cursor); err != nil {
+		return err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.cursors[partitionID] = cursor
+	return nil
+}
+
+func (e *EncryptingReceiver) CaughtUp(partitionID int, cursor string) error {
+	return SendCaughtUp(e.receiver, partitionID, cursor)
+}
+
+func (d *DecryptingReceiver) CaughtUp(partitionID int, cursor string) error {
+	return SendCaughtUp(d.receiver, partitionID, cursor)
+}
+
+func (t TombstoneRouter) CaughtUp(partitionID int, cursor string) error {
+	return SendCaughtUp(t.Receiver, partitionID, cursor)
+}
+
+var (
+	_ CaughtUpReceiver = &NDJSONEventSerializer{}
+	_ CaughtUpReceiver = &PagedSerializer{}
+	_ CaughtUpReceiver = HeaderFilter{}
+	_ CaughtUpReceiver = &lockedReceiver{}
+	_ CaughtUpReceiver = &progressReceiver{}
+	_ CaughtUpReceiver = &bufferedPage{}
+	_ CaughtUpReceiver = &orderedMerger{}
+	_ CaughtUpReceiver = &CheckpointBatcher{}
+	_ CaughtUpReceiver = &CursorTracker{}
+	_ CaughtUpReceiver = &EncryptingReceiver{}
+	_ CaughtUpReceiver = &DecryptingReceiver{}
+	_ CaughtUpReceiver = TombstoneRouter{}
+)
@@ -0,0 +1,202 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// caughtUpAPI serves 10 events in each partition, marking the checkpoint of the last one as caught up.
+type caughtUpAPI struct {
+	*TestZeroEventHubAPI
+}
+
+func (caughtUpAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	for _, cursor := range cursors {
+		start := 0
+		if cursor.Cursor != FirstCursor {
+			after, err := strconv.Atoi(cursor.Cursor)
+			if err != nil {
+				return err
+			}
+			start = after + 1
+		}
+		for i := start; i < 10 && i < start+pageSizeHint; i++ {
+			if err := r.Event(cursor.PartitionID, nil, json.RawMessage(strconv.Itoa(i))); err != nil {
+				return err
+			}
+			var err error
+			if i == 9 {
+				err = SendCaughtUp(r, cursor.PartitionID, strconv.Itoa(i))
+			} else {
+				err = r.Checkpoint(cursor.PartitionID, strconv.Itoa(i))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// caughtUpPage is an EventPageRaw recording the caught-up checkpoints.
+type caughtUpPage struct {
+	EventPageRaw
+	caughtUp map[int]string
+}
+
+func (p *caughtUpPage) CaughtUp(partitionID int, cursor string) error {
+	if p.caughtUp == nil {
+		p.caughtUp = map[int]string{}
+	}
+	p.caughtUp[partitionID] = cursor
+	return p.Checkpoint(partitionID, cursor)
+}
+
+func TestCaughtUp(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, caughtUpAPI{NewTestZeroEventHubAPI()}))
+	defer server.Close()
+	client := NewClient(server.URL, 2).WithCaughtUp(true)
+
+	// in the middle of the stream
+	var page caughtUpPage
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, 5, &page))
+	require.Len(t, page.Events, 5)
+	require.Equal(t, "4", page.Cursors[0])
+	require.Nil(t, page.caughtUp)
+
+	// at the tail, also through the receivers wrapping it
+	page = caughtUpPage{}
+	require.NoError(t, client.WithResumeFromCheckpoint(1, nil).WithRateLimit(1000, 100).
+		FetchEvents(context.Background(), []Cursor{{Cursor: "4"}}, 100, &page))
+	require.Len(t, page.Events, 5)
+	require.Equal(t, map[int]string{0: "9"}, page.caughtUp)
+	require.Equal(t, "9", page.Cursors[0])
+
+	// other receivers get a plain checkpoint
+	var raw EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "4"}}, 100, &raw))
+	require.Equal(t, "9", raw.Cursors[0])
+
+	// and so do clients not asking for them
+	page = caughtUpPage{}
+	require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "4"}}, 100, &page))
+	require.Equal(t, "9", page.Cursors[0])
+	require.Nil(t, page.caughtUp)
+
+	// the wire format only changes for clients asking for it
+	for query, last := range map[string]string{
+		"":            `{"partition":0,"cursor":"9"}`,
+		"&caughtup=0": `{"partition":0,"cursor":"9"}`,
+		"&caughtup=1": `{"partition":0,"cursor":"9","caughtUp":true}`,
+	} {
+		res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=8&pagesizehint=10" + query)
+		require.NoError(t, err)
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		require.Equal(t, `{"partition":0,"data":9}`+"\n"+last+"\n", string(body), query)
+	}
+	res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=8&caughtup=x")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, ErrIllegalCaughtUp.Status(), res.StatusCode)
+}
+
+func TestParseCaughtUpLine(t *testing.T) {
+	line, err := ParseLine([]byte(`{"partition":1,"cursor":"9","caughtUp":true}`))
+	require.NoError(t, err)
+	require.Equal(t, Line{Kind: LineCheckpoint, Checkpoint: &Cursor{PartitionID: 1, Cursor: "9"}, CaughtUp: true}, line)
+	encoded, err := EncodeLine(line)
+	require.NoError(t, err)
+	require.Equal(t, `{"partition":1,"cursor":"9","caughtUp":true}`+"\n", string(encoded))
+}
+
+func TestCaughtUpThroughFetchHelpers(t *testing.T) {
+	var requests int32
+	api := HandlerWithOptions(nil, caughtUpAPI{NewTestZeroEventHubAPI()}, HandlerOptions{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		api.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, 2).WithCaughtUp(true)
+	ctx := context.Background()
+	both := []Cursor{{PartitionID: 0, Cursor: "4"}, {PartitionID: 1, Cursor: "4"}}
+
+	t.Run("FetchEventsParallel", func(t *testing.T) {
+		var page caughtUpPage
+		require.NoError(t, FetchEventsParallel(ctx, client, both, 100, &page))
+		require.Equal(t, map[int]string{0: "9", 1: "9"}, page.caughtUp)
+	})
+
+	t.Run("FetchEventsAllPartitions", func(t *testing.T) {
+		var page caughtUpPage
+		require.NoError(t, client.FetchEventsAllPartitions(ctx, map[int]string{0: "4"}, 100, &page, ParallelOptions{}))
+		require.Equal(t, map[int]string{0: "9", 1: "9"}, page.caughtUp)
+	})
+
+	t.Run("FetchEventsOrdered", func(t *testing.T) {
+		var page caughtUpPage
+		less := func(a, b Envelope) bool { return string(a.Data) < string(b.Data) }
+		require.NoError(t, FetchEventsOrdered(ctx, client, both, 100, less, &page))
+		require.Equal(t, map[int]string{0: "9", 1: "9"}, page.caughtUp)
+	})
+
+	t.Run("CursorTracker", func(t *testing.T) {
+		var page caughtUpPage
+		tracker := NewCursorTracker(&page, nil)
+		require.NoError(t, client.FetchEvents(ctx, both, 100, tracker))
+		require.Equal(t, map[int]string{0: "9", 1: "9"}, page.caughtUp)
+		require.Equal(t, Cursors{0: "9", 1: "9"}, tracker.Cursors())
+	})
+
+	// FetchAllEvents stops at the caught-up checkpoint, without fetching an empty page
+	for _, prefetch := range []bool{false, true} {
+		t.Run("FetchAllEvents prefetch="+strconv.FormatBool(prefetch), func(t *testing.T) {
+			var page caughtUpPage
+			atomic.StoreInt32(&requests, 0)
+			require.NoError(t, FetchAllEvents(ctx, client, Cursor{Cursor: FirstCursor}, 3, &page, FetchAllOptions{Prefetch: prefetch}))
+			require.Len(t, page.Events, 10)
+			require.Equal(t, map[int]string{0: "9"}, page.caughtUp)
+			require.Equal(t, int32(4), atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestCaughtUpThroughReceivers(t *testing.T) {
+	for name, wrap := range map[string]func(r EventReceiver) EventReceiver{
+		"CheckpointBatcher": func(r EventReceiver) EventReceiver { return CheckpointEvery(3, r) },
+		"EncryptingReceiver": func(r EventReceiver) EventReceiver {
+			encrypting, err := NewEncryptingReceiver(r, "k1", encryptionKeys["k1"])
+			require.NoError(t, err)
+			return encrypting
+		},
+		"DecryptingReceiver": func(r EventReceiver) EventReceiver { return NewDecryptingReceiver(r, encryptionKeys, nil) },
+		"TombstoneRouter":    func(r EventReceiver) EventReceiver { return TombstoneRouter{Receiver: r} },
+	} {
+		var page caughtUpPage
+		receiver := wrap(&page)
+		require.NoError(t, receiver.Checkpoint(0, "1"), name)
+		require.NoError(t, SendCaughtUp(receiver, 0, "2"), name)
+		require.Equal(t, map[int]string{0: "2"}, page.caughtUp, name)
+	}
+
+	// a publisher batching checkpoints still marks the last one
+	var buf bytes.Buffer
+	serializer := NewNDJSONEventSerializer(&buf)
+	serializer.SetCaughtUpLines(true)
+	batcher := CheckpointEvery(3, serializer)
+	require.NoError(t, batcher.Checkpoint(0, "1"))
+	require.NoError(t, SendCaughtUp(batcher, 0, "2"))
+	require.NoError(t, batcher.Flush())
+	require.Equal(t, `{"partition":0,"cursor":"2","caughtUp":true}`+"\n", buf.String())
+}
@@ -0,0 +1,65 @@
+package zeroeventhub
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// CheckpointBatcher implements EventReceiver by forwarding all events, but only every Nth checkpoint
+// of each partition, to another EventReceiver. Publishers emitting a checkpoint after every event can use it
+// to cut the wire volume roughly in half. The tradeoff is coarser resumption granularity: a consumer
+// that stops in the middle of a page resumes from the last forwarded checkpoint and may see up to N-1
+// events again.
+//
+// Flush has to be called at the end of the page so that the final checkpoint is always forwarded.
+type CheckpointBatcher struct {
+	receiver EventReceiver
+	n        int
+	counts   map[int]int
+	pending  map[int]string
+}
+
+var _ EventReceiver = &CheckpointBatcher{}
+
+// CheckpointEvery is a constructor for the CheckpointBatcher.
+func CheckpointEvery(n int, receiver EventReceiver) *CheckpointBatcher {
+	if n < 1 {
+		n = 1
+	}
+	return &CheckpointBatcher{
+		receiver: receiver,
+		n:        n,
+		counts:   make(map[int]int),
+		pending:  make(map[int]string),
+	}
+}
+
+func (b *CheckpointBatcher) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	return b.receiver.Event(partitionID, headers, data)
+}
+
+func (b *CheckpointBatcher) Checkpoint(partitionID int, cursor string) error {
+	b.counts[partitionID]++
+	if b.counts[partitionID]%b.n != 0 {
+		b.pending[partitionID] = cursor
+		return nil
+	}
+	delete(b.pending, partitionID)
+	return b.receiver.Checkpoint(partitionID, cursor)
+}
+
+// Flush forwards the last checkpoint of each partition that hasn't been forwarded yet.
+func (b *CheckpointBatcher) Flush() error {
+	partitionIDs := make([]int, 0, len(b.pending))
+	for partitionID := range b.pending {
+		partitionIDs = append(partitionIDs, partitionID)
+	}
+	sort.Ints(partitionIDs)
+	for _, partitionID := range partitionIDs {
+		if err := b.receiver.Checkpoint(partitionID, b.pending[partitionID]); err != nil {
+			return err
+		}
+		delete(b.pending, partitionID)
+	}
+	return nil
+}
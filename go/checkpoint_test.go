@@ -0,0 +1,65 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// batchingZeroEventHubAPI only forwards every 10th checkpoint of TestZeroEventHubAPI.
+type batchingZeroEventHubAPI struct {
+	TestZeroEventHubAPI
+}
+
+func (t batchingZeroEventHubAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	batcher := CheckpointEvery(10, r)
+	if err := t.TestZeroEventHubAPI.FetchEvents(ctx, cursors, pageSizeHint, batcher, headers...); err != nil {
+		return err
+	}
+	return batcher.Flush()
+}
+
+func TestCheckpointEvery(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, batchingZeroEventHubAPI{*NewTestZeroEventHubAPI()}))
+	loggingClient := server.Client()
+	loggingRoundTripper := loggingRoundTripper{actualRoundTripper: server.Client().Transport}
+	loggingClient.Transport = &loggingRoundTripper
+	cursors := []Cursor{
+		{PartitionID: 0, Cursor: FirstCursor},
+		{PartitionID: 1, Cursor: "4999"},
+	}
+
+	// the logging round tripper consumes the body, so the page is inspected through a regular client
+	err := NewClient(server.URL, 2).WithHttpClient(loggingClient).FetchEvents(context.Background(), cursors, 25, &EventPageRaw{})
+	require.NoError(t, err)
+	var checkpoints []string
+	for _, line := range strings.Split(strings.TrimSpace(loggingRoundTripper.response), "\n") {
+		if strings.Contains(line, `"cursor":"`) {
+			checkpoints = append(checkpoints, line)
+		}
+	}
+	require.Equal(t, []string{
+		`{"partition":0,"cursor":"9"}`,
+		`{"partition":0,"cursor":"19"}`,
+		`{"partition":1,"cursor":"5009"}`,
+		`{"partition":1,"cursor":"5019"}`,
+		`{"partition":0,"cursor":"24"}`,
+		`{"partition":1,"cursor":"5024"}`,
+	}, checkpoints)
+
+	client := NewClient(server.URL, 2)
+	var page EventPageSingleType[TestEvent]
+	err = client.FetchEvents(context.Background(), cursors, 25, &page)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 50)
+	require.Equal(t, map[int]string{0: "24", 1: "5024"}, page.Cursors)
+
+	// resuming off the less frequent checkpoints continues where the page ended
+	page = EventPageSingleType[TestEvent]{}
+	err = client.FetchEvents(context.Background(), []Cursor{{PartitionID: 0, Cursor: "24"}}, 1, &page)
+	require.NoError(t, err)
+	require.Equal(t, 25, page.Events[0].Data.Cursor)
+}
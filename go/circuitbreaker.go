@@ -0,0 +1,212 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitOpen is returned by Client.FetchEvents without sending a request while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets all requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails all requests with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through to find out whether the server has recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerSettings configures the circuit breaker of a Client. Zero values are replaced by defaults.
+type CircuitBreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures tripping the breaker (default 5).
+	FailureThreshold int
+	// OpenDuration is how long the breaker stays open before probing (default 30s).
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of successful probes needed to close the breaker again (default 1).
+	HalfOpenProbes int
+	// OnStateChange is called on every state transition, e.g. for alerting. It must not call back into the Client.
+	OnStateChange func(from, to CircuitState)
+}
+
+type circuitBreaker struct {
+	settings CircuitBreakerSettings
+	now      func() time.Time
+
+	mu    sync.Mutex
+	state CircuitState
+	// generation is incremented on every transition, so that the outcomes of requests allowed in an earlier state
+	// are ignored
+	generation     uint64
+	failures       int
+	openedAt       time.Time
+	probesInFlight int
+	probeSuccesses int
+}
+
+type requestOutcome int
+
+const (
+	outcomeSuccess requestOutcome = iota
+	outcomeFailure
+	outcomeIgnored
+)
+
+func newCircuitBreaker(settings CircuitBreakerSettings) *circuitBreaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 5
+	}
+	if settings.OpenDuration <= 0 {
+		settings.OpenDuration = 30 * time.Second
+	}
+	if settings.HalfOpenProbes <= 0 {
+		settings.HalfOpenProbes = 1
+	}
+	return &circuitBreaker{
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+// setState transitions to a new state; b.mu must be held.
+func (b *circuitBreaker) setState(state CircuitState) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.probesInFlight = 0
+	b.probeSuccesses = 0
+	if state == CircuitOpen {
+		b.openedAt = b.now()
+	}
+	if from != state && b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(from, state)
+	}
+}
+
+// allow returns ErrCircuitOpen if a request may not be sent; otherwise the outcome of the request must be recorded
+// with the returned generation.
+func (b *circuitBreaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.settings.OpenDuration {
+		b.setState(CircuitHalfOpen)
+	}
+	switch b.state {
+	case CircuitOpen:
+		return 0, ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probesInFlight+b.probeSuccesses >= b.settings.HalfOpenProbes {
+			return 0, ErrCircuitOpen
+		}
+		b.probesInFlight++
+	}
+	return b.generation, nil
+}
+
+// currentState returns the state the next request would see, without transitioning.
+func (b *circuitBreaker) currentState() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.settings.OpenDuration {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// record records the outcome of a request allowed in the given generation. Outcomes of requests allowed before the
+// last transition are dropped: e.g. a request sent while closed that completes while half-open is not a probe.
+func (b *circuitBreaker) record(generation uint64, outcome requestOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+	switch b.state {
+	case CircuitClosed:
+		switch outcome {
+		case outcomeSuccess:
+			b.failures = 0
+		case outcomeFailure:
+			b.failures++
+			if b.failures >= b.settings.FailureThreshold {
+				b.setState(CircuitOpen)
+			}
+		}
+	case CircuitHalfOpen:
+		b.probesInFlight--
+		switch outcome {
+		case outcomeSuccess:
+			b.probeSuccesses++
+			if b.probeSuccesses >= b.settings.HalfOpenProbes {
+				b.setState(CircuitClosed)
+			}
+		case outcomeFailure:
+			b.setState(CircuitOpen)
+		}
+	}
+}
+
+// classifyOutcome tells whether a request outcome counts as a failure of the server. Errors caused by the caller,
+// i.e. a done context, a failing receiver or a 4xx response (except 429 Too Many Requests), don't count. Neither
+// does 501 Not Implemented, e.g. for Client.TailCursor against a server without support for it.
+func classifyOutcome(ctx context.Context, err error) requestOutcome {
+	if err == nil {
+		return outcomeSuccess
+	}
+	if ctx.Err() != nil {
+		return outcomeIgnored
+	}
+	var responseErr *ResponseError
+	if errors.As(err, &responseErr) {
+		if retryStatus(responseErr.StatusCode) || responseErr.StatusCode == http.StatusTooManyRequests {
+			return outcomeFailure
+		}
+		return outcomeIgnored
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return outcomeFailure
+	}
+	return outcomeIgnored
+}
+
+// WithCircuitBreaker is a Client method for enabling a circuit breaker: after a number of consecutive failures
+// (connection errors, 5xx or 429 responses) FetchEvents fails fast with ErrCircuitOpen for a while, then lets
+// probe requests through to find out whether the server recovered. The returned Client and copies made from it
+// share the breaker, so it is safe to use from several partition workers.
+func (c Client) WithCircuitBreaker(settings CircuitBreakerSettings) (r Client) {
+	r = c
+	r.circuitBreaker = newCircuitBreaker(settings)
+	return
+}
+
+// CircuitState returns the current state of the circuit breaker, e.g. for reporting in health checks.
+// It is always CircuitClosed if no circuit breaker was configured.
+func (c Client) CircuitState() CircuitState {
+	if c.circuitBreaker == nil {
+		return CircuitClosed
+	}
+	return c.circuitBreaker.currentState()
+}
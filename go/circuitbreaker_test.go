@@ -0,0 +1,184 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	handler := Handler(nil, NewTestZeroEventHubAPI())
+	// status is the status code the server responds with; 200 serves the feed
+	var status, requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if code := int(atomic.LoadInt32(&status)); code != http.StatusOK {
+			http.Error(writer, http.StatusText(code), code)
+			return
+		}
+		handler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	var transitions []string
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := NewClient(server.URL, 2).WithCircuitBreaker(CircuitBreakerSettings{
+		FailureThreshold: 3,
+		OpenDuration:     10 * time.Second,
+		HalfOpenProbes:   2,
+		OnStateChange: func(from, to CircuitState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	client.circuitBreaker.now = clock.Now
+
+	fetch := func(code int) error {
+		atomic.StoreInt32(&status, int32(code))
+		var page EventPageRaw
+		return client.FetchEvents(context.Background(), []Cursor{{Cursor: LastCursor}}, DefaultPageSize, &page)
+	}
+
+	// a success resets the consecutive failure count, 4xx other than 429 isn't a failure
+	require.Error(t, fetch(http.StatusInternalServerError))
+	require.Error(t, fetch(http.StatusBadGateway))
+	require.NoError(t, fetch(http.StatusOK))
+	require.Error(t, fetch(http.StatusServiceUnavailable))
+	require.Error(t, fetch(http.StatusTooManyRequests))
+	require.Error(t, fetch(http.StatusBadRequest))
+	require.Error(t, fetch(http.StatusNotFound))
+	require.Empty(t, transitions)
+
+	// tripping
+	require.Error(t, fetch(http.StatusInternalServerError))
+	require.Equal(t, []string{"closed->open"}, transitions)
+	require.Equal(t, int32(8), atomic.LoadInt32(&requests))
+	require.Equal(t, ErrCircuitOpen, fetch(http.StatusOK))
+	require.Equal(t, int32(8), atomic.LoadInt32(&requests))
+
+	// a failing probe opens the breaker again
+	clock.now = clock.now.Add(10 * time.Second)
+	require.Error(t, fetch(http.StatusInternalServerError))
+	require.Equal(t, []string{"closed->open", "open->half-open", "half-open->open"}, transitions)
+	require.Equal(t, ErrCircuitOpen, fetch(http.StatusOK))
+
+	// recovery after enough successful probes
+	clock.now = clock.now.Add(10 * time.Second)
+	require.NoError(t, fetch(http.StatusOK))
+	require.NoError(t, fetch(http.StatusOK))
+	require.Equal(t, []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}, transitions)
+	require.NoError(t, fetch(http.StatusOK))
+	require.Equal(t, int32(12), atomic.LoadInt32(&requests))
+}
+
+func TestCircuitBreakerConcurrentUse(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(writer, "Internal server error", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, 2).WithCircuitBreaker(CircuitBreakerSettings{
+		FailureThreshold: 5,
+		OpenDuration:     time.Hour,
+	})
+
+	var wg sync.WaitGroup
+	var circuitOpen int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				var page EventPageRaw
+				err := client.FetchEvents(context.Background(), []Cursor{{Cursor: LastCursor}}, DefaultPageSize, &page)
+				if err == ErrCircuitOpen {
+					atomic.AddInt32(&circuitOpen, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// requests that were already allowed when the breaker tripped may still complete
+	require.True(t, atomic.LoadInt32(&requests) < 100)
+	require.Equal(t, int32(500)-atomic.LoadInt32(&requests), atomic.LoadInt32(&circuitOpen))
+}
+
+func TestCircuitState(t *testing.T) {
+	var failing int32 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			http.Error(writer, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		_, _ = writer.Write([]byte(`{"partition":0,"cursor":"1"}` + "\n"))
+	}))
+	defer server.Close()
+	require.Equal(t, CircuitClosed, NewClient(server.URL, 1).CircuitState())
+
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := NewClient(server.URL, 1).WithCircuitBreaker(CircuitBreakerSettings{
+		FailureThreshold: 2,
+		OpenDuration:     time.Minute,
+	})
+	client.circuitBreaker.now = clock.Now
+	fetch := func() error {
+		var page EventPageRaw
+		return client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page)
+	}
+
+	// trip
+	require.Error(t, fetch())
+	require.Equal(t, CircuitClosed, client.CircuitState())
+	require.Error(t, fetch())
+	require.Equal(t, CircuitOpen, client.CircuitState())
+
+	// cooldown
+	clock.now = clock.now.Add(59 * time.Second)
+	require.Equal(t, ErrCircuitOpen, fetch())
+	require.Equal(t, CircuitOpen, client.CircuitState())
+	clock.now = clock.now.Add(time.Second)
+	require.Equal(t, CircuitHalfOpen, client.CircuitState())
+
+	// recovery
+	atomic.StoreInt32(&failing, 0)
+	require.NoError(t, fetch())
+	require.Equal(t, CircuitClosed, client.CircuitState())
+}
+
+func TestCircuitBreakerStaleOutcome(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	breaker := newCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1, OpenDuration: time.Minute})
+	breaker.now = clock.Now
+
+	// two requests are sent while closed, and the second trips the breaker
+	slow, err := breaker.allow()
+	require.NoError(t, err)
+	failing, err := breaker.allow()
+	require.NoError(t, err)
+	breaker.record(failing, outcomeFailure)
+	require.Equal(t, CircuitOpen, breaker.currentState())
+
+	// the first completes while a probe is in flight; it is no probe, so it neither closes the breaker nor frees
+	// the probe slot
+	clock.now = clock.now.Add(time.Minute)
+	probe, err := breaker.allow()
+	require.NoError(t, err)
+	breaker.record(slow, outcomeSuccess)
+	require.Equal(t, CircuitHalfOpen, breaker.currentState())
+	_, err = breaker.allow()
+	require.Equal(t, ErrCircuitOpen, err)
+	require.Equal(t, 1, breaker.probesInFlight)
+
+	// nor does a stale failure reopen it
+	breaker.record(failing, outcomeFailure)
+	require.Equal(t, CircuitHalfOpen, breaker.currentState())
+
+	breaker.record(probe, outcomeSuccess)
+	require.Equal(t, CircuitClosed, breaker.currentState())
+}
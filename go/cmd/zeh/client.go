@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// requestHeaders is a repeatable flag of HTTP request headers, e.g. -H 'Authorization: Bearer ...'.
+type requestHeaders []string
+
+func (h *requestHeaders) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *requestHeaders) Set(value string) error {
+	if _, _, err := parseHeader(value); err != nil {
+		return err
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+// parseHeader parses a header given as "Key: Value".
+func parseHeader(header string) (key, value string, err error) {
+	i := strings.Index(header, ":")
+	if i < 0 {
+		return "", "", errors.Errorf("header %q isn't of the form 'Key: Value'", header)
+	}
+	key = strings.TrimSpace(header[:i])
+	if key == "" {
+		return "", "", errors.Errorf("header %q has no key", header)
+	}
+	return key, strings.TrimSpace(header[i+1:]), nil
+}
+
+// clientFlags are the flags for connecting to a feed, shared by all commands.
+type clientFlags struct {
+	partitions   int
+	headers      requestHeaders
+	eventHeaders string
+	timeout      time.Duration
+}
+
+// register registers the flags; eventHeaders is the default of -headers.
+func (f *clientFlags) register(flags *flag.FlagSet, eventHeaders string) {
+	flags.IntVar(&f.partitions, "partitions", 1, "partition count of the feed")
+	flags.Var(&f.headers, "H", "HTTP request header 'Key: Value', repeatable")
+	flags.StringVar(&f.eventHeaders, "headers", eventHeaders, "comma-separated event headers to return, or _all")
+	flags.DurationVar(&f.timeout, "timeout", time.Minute, "timeout of each request")
+}
+
+func (f *clientFlags) client(url string) zeroeventhub.Client {
+	headers := f.headers
+	return zeroeventhub.NewClient(strings.TrimSuffix(url, "/"), f.partitions).
+		WithRequestTimeout(f.timeout).
+		WithRequestProcessor(func(r *http.Request) error {
+			for _, header := range headers {
+				key, value, err := parseHeader(header)
+				if err != nil {
+					return err
+				}
+				r.Header.Add(key, value)
+			}
+			return nil
+		})
+}
+
+func (f *clientFlags) requestedEventHeaders() []string {
+	if f.eventHeaders == "" {
+		return nil
+	}
+	return strings.Split(f.eventHeaders, ",")
+}
+
+// parseArgs parses the flags, which may be given before or after the URL, and returns the URL.
+func parseArgs(flags *flag.FlagSet, args []string) (string, error) {
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return "", errors.New("missing feed URL")
+	}
+	url := flags.Arg(0)
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return "", err
+	}
+	if flags.NArg() != 0 {
+		return "", errors.Errorf("unexpected arguments %q", flags.Args())
+	}
+	return url, nil
+}
+
+// lineWriter implements EventReceiver by writing NDJSON lines in the wire format; checkpoints are skipped if
+// eventsOnly is set. HTML characters are not escaped, so the data of events is written as it was received.
+type lineWriter struct {
+	writer     io.Writer
+	eventsOnly bool
+	// cursor is the latest checkpoint, and events the number of events written
+	cursor string
+	events int
+	// pollAfter is the poll interval hinted by the server, if any
+	pollAfter  time.Duration
+	serializer *zeroeventhub.NDJSONEventSerializer
+}
+
+func (w *lineWriter) PollAfter(d time.Duration) {
+	w.pollAfter = d
+}
+
+func (w *lineWriter) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	w.events++
+	return w.lines().Event(partitionID, headers, data)
+}
+
+func (w *lineWriter) Checkpoint(partitionID int, cursor string) error {
+	w.cursor = cursor
+	if w.eventsOnly {
+		return nil
+	}
+	return w.lines().Checkpoint(partitionID, cursor)
+}
+
+func (w *lineWriter) lines() *zeroeventhub.NDJSONEventSerializer {
+	if w.serializer == nil {
+		w.serializer = zeroeventhub.NewNDJSONEventSerializer(w.writer)
+		w.serializer.SetEscapeHTML(false)
+	}
+	return w.serializer
+}
+
+// tableWriter implements EventReceiver by writing a human-readable table, with a row for each event and checkpoint.
+type tableWriter struct {
+	writer *tabwriter.Writer
+}
+
+func newTableWriter(writer io.Writer) *tableWriter {
+	w := &tableWriter{writer: tabwriter.NewWriter(writer, 0, 8, 2, ' ', 0)}
+	fmt.Fprintln(w.writer, "PARTITION\tCURSOR\tHEADERS\tDATA")
+	return w
+}
+
+func (w *tableWriter) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	var formatted []string
+	for _, key := range sortedKeys(headers) {
+		formatted = append(formatted, key+"="+headers[key])
+	}
+	if len(formatted) == 0 {
+		formatted = []string{"-"}
+	}
+	_, err := fmt.Fprintf(w.writer, "%d\t-\t%s\t%s\n", partitionID, strings.Join(formatted, ","), data)
+	return err
+}
+
+func (w *tableWriter) Checkpoint(partitionID int, cursor string) error {
+	_, err := fmt.Fprintf(w.writer, "%d\t%s\t-\t-\n", partitionID, cursor)
+	return err
+}
+
+func (w *tableWriter) Flush() error {
+	return w.writer.Flush()
+}
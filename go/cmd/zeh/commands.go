@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// errNoProgress is returned when a page has events but no checkpoint after them, so fetching again would return
+// the same events forever.
+var errNoProgress = zeroeventhub.ErrNoProgress
+
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		_, _ = io.WriteString(stderr, "usage: zeh "+name+" [flags] "+args+"\n\nflags:\n")
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// fetchCommand fetches a single page of a partition, printing it as NDJSON (the wire format) or a table.
+func fetchCommand(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("fetch", "<url>", stderr)
+	var client clientFlags
+	client.register(flags, "")
+	partitionID := flags.Int("p", 0, "partition ID")
+	cursor := flags.String("c", zeroeventhub.FirstCursor, "cursor to fetch from; _first, _last or a checkpoint")
+	pageSize := flags.Int("n", 100, "page size hint")
+	format := flags.String("format", "ndjson", "output format: ndjson or table")
+	url, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	cursors := []zeroeventhub.Cursor{{PartitionID: *partitionID, Cursor: *cursor}}
+	switch *format {
+	case "ndjson":
+		return client.client(url).FetchEvents(ctx, cursors, *pageSize, &lineWriter{writer: stdout}, client.requestedEventHeaders()...)
+	case "table":
+		table := newTableWriter(stdout)
+		if err := client.client(url).FetchEvents(ctx, cursors, *pageSize, table, client.requestedEventHeaders()...); err != nil {
+			return err
+		}
+		return table.Flush()
+	default:
+		return errors.Errorf("unknown format %q", *format)
+	}
+}
+
+// tailCommand follows a partition, printing the events as NDJSON. With -state, the cursor is saved to a file
+// after every page, and read from it on start, so that tailing resumes where it stopped. When caught up, it
+// waits the poll interval hinted by the server, or -interval if there is none.
+func tailCommand(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("tail", "<url>", stderr)
+	var client clientFlags
+	client.register(flags, "")
+	partitionID := flags.Int("p", 0, "partition ID")
+	cursor := flags.String("c", zeroeventhub.LastCursor, "cursor to start from when there is no saved state")
+	state := flags.String("state", "", "file to save the cursor to and resume from")
+	pageSize := flags.Int("n", 100, "page size hint")
+	interval := flags.Duration("interval", time.Second, "time to wait before polling again when caught up, unless the server hints otherwise")
+	exit := flags.Bool("exit", false, "exit when caught up instead of polling for new events")
+	url, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *state != "" {
+		saved, err := readCursor(*state)
+		switch {
+		case err == nil:
+			*cursor = saved
+		case !os.IsNotExist(err):
+			return err
+		}
+	}
+	c := client.client(url)
+	for {
+		writer := lineWriter{writer: stdout, eventsOnly: true, cursor: *cursor}
+		err := c.FetchEvents(ctx, []zeroeventhub.Cursor{{PartitionID: *partitionID, Cursor: *cursor}}, *pageSize, &writer, client.requestedEventHeaders()...)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if writer.cursor != *cursor && *state != "" {
+			if err := saveCursor(*state, writer.cursor); err != nil {
+				return err
+			}
+		}
+		if writer.events > 0 && writer.cursor == *cursor {
+			return errNoProgress
+		}
+		caughtUp := writer.events == 0
+		*cursor = writer.cursor
+		if !caughtUp {
+			continue
+		}
+		if *exit {
+			return nil
+		}
+		wait := *interval
+		if writer.pollAfter > 0 {
+			wait = writer.pollAfter
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// saveCursor writes the cursor to a file, replacing it atomically.
+func saveCursor(path, cursor string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(cursor + "\n"); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// dumpCommand drains partitions to an NDJSON file in the wire format, events followed by their checkpoints,
+// so the dump keeps the original cursors.
+func dumpCommand(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("dump", "<url>", stderr)
+	var client clientFlags
+	client.register(flags, zeroeventhub.All)
+	partitionID := flags.Int("p", -1, "partition ID; all partitions if not given")
+	cursor := flags.String("c", zeroeventhub.FirstCursor, "cursor to start from")
+	output := flags.String("o", "", "file to write to; standard output if not given")
+	pageSize := flags.Int("n", 1000, "page size hint")
+	url, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	var partitionIDs []int
+	if *partitionID >= 0 {
+		partitionIDs = []int{*partitionID}
+	} else {
+		for i := 0; i < client.partitions; i++ {
+			partitionIDs = append(partitionIDs, i)
+		}
+	}
+	c := client.client(url)
+	dump := func(writer io.Writer) error {
+		for _, partitionID := range partitionIDs {
+			// writing to a file is quick, but a slow pipe shouldn't idle the network
+			err := zeroeventhub.FetchAllEvents(ctx, c, zeroeventhub.Cursor{PartitionID: partitionID, Cursor: *cursor}, *pageSize,
+				&lineWriter{writer: writer}, zeroeventhub.FetchAllOptions{Prefetch: true}, client.requestedEventHeaders()...)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if *output == "" {
+		return dump(stdout)
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := dump(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
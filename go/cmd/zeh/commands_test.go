@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// memoryAPI serves partitions of events with the index of an event as its cursor.
+type memoryAPI struct {
+	partitions [][]string
+	// pollAfter is the poll interval hinted to clients, and fetches counts the calls to FetchEvents
+	pollAfter time.Duration
+	fetches   int32
+}
+
+func (m *memoryAPI) PollAfter(context.Context, []zeroeventhub.Cursor) time.Duration {
+	return m.pollAfter
+}
+
+func (m *memoryAPI) GetName() string {
+	return "memoryAPI"
+}
+
+func (m *memoryAPI) GetPartitionCount() int {
+	return len(m.partitions)
+}
+
+func (m *memoryAPI) FetchEvents(ctx context.Context, cursors []zeroeventhub.Cursor, pageSizeHint int, r zeroeventhub.EventReceiver, headers ...string) error {
+	atomic.AddInt32(&m.fetches, 1)
+	if pageSizeHint == zeroeventhub.DefaultPageSize {
+		pageSizeHint = 3
+	}
+	for _, cursor := range cursors {
+		events := m.partitions[cursor.PartitionID]
+		start := 0
+		switch cursor.Cursor {
+		case zeroeventhub.FirstCursor:
+		case zeroeventhub.LastCursor:
+			start = len(events) - 1
+		default:
+			after, err := strconv.Atoi(cursor.Cursor)
+			if err != nil {
+				return err
+			}
+			start = after + 1
+		}
+		for i := start; i < len(events) && i < start+pageSizeHint; i++ {
+			if err := r.Event(cursor.PartitionID, map[string]string{"index": strconv.Itoa(i), "type": "test"}, []byte(events[i])); err != nil {
+				return err
+			}
+			if err := r.Checkpoint(cursor.PartitionID, strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newTestServer(t *testing.T) (*memoryAPI, string) {
+	api := &memoryAPI{partitions: [][]string{{`{"n":0}`, `{"n":1}`, `{"n":2}`, `{"n":3}`, `{"n":4}`}, {`"a"`, `"b"`}}}
+	server := httptest.NewServer(zeroeventhub.Handler(nil, api))
+	t.Cleanup(server.Close)
+	return api, server.URL
+}
+
+func runCommand(t *testing.T, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	err := run(context.Background(), args, &stdout, io.Discard)
+	return stdout.String(), err
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		key    string
+		value  string
+		err    string
+	}{
+		{header: "Authorization: Bearer abc", key: "Authorization", value: "Bearer abc"},
+		{header: "X-Empty:", key: "X-Empty", value: ""},
+		{header: "X-Url: http://example.com:8080", key: "X-Url", value: "http://example.com:8080"},
+		{header: "no colon", err: `header "no colon" isn't of the form 'Key: Value'`},
+		{header: " : value", err: `header " : value" has no key`},
+	}
+	for _, test := range tests {
+		key, value, err := parseHeader(test.header)
+		if test.err != "" {
+			require.EqualError(t, err, test.err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.key, key)
+		require.Equal(t, test.value, value)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	_, url := newTestServer(t)
+
+	output, err := runCommand(t, "fetch", "-partitions", "2", "-c", "1", "-n", "2", url, "-headers", "index")
+	require.NoError(t, err)
+	require.Equal(t, `{"partition":0,"headers":{"index":"2"},"data":{"n":2}}
+{"partition":0,"cursor":"2"}
+{"partition":0,"headers":{"index":"3"},"data":{"n":3}}
+{"partition":0,"cursor":"3"}
+`, output)
+
+	output, err = runCommand(t, "fetch", "-partitions", "2", "-p", "1", "-format", "table", "-headers", "_all", url)
+	require.NoError(t, err)
+	require.Equal(t, `PARTITION  CURSOR  HEADERS            DATA
+1          -       index=0,type=test  "a"
+1          0       -                  -
+1          -       index=1,type=test  "b"
+1          1       -                  -
+`, output)
+
+	_, err = runCommand(t, "fetch", url)
+	require.EqualError(t, err, "unexpected response body: handshake error: partition count mismatch\n")
+	_, err = runCommand(t, "fetch", "-partitions", "2", "-format", "xml", url)
+	require.EqualError(t, err, `unknown format "xml"`)
+}
+
+func TestTail(t *testing.T) {
+	api, url := newTestServer(t)
+	state := filepath.Join(t.TempDir(), "cursor")
+
+	output, err := runCommand(t, "tail", "-partitions", "2", "-c", zeroeventhub.FirstCursor, "-n", "2", "-state", state, "-exit", url)
+	require.NoError(t, err)
+	require.Equal(t, 5, strings.Count(output, "\n"))
+	saved, err := os.ReadFile(state)
+	require.NoError(t, err)
+	require.Equal(t, "4\n", string(saved))
+
+	// resumes from the saved cursor, not -c
+	api.partitions[0] = append(api.partitions[0], `{"n":5}`)
+	output, err = runCommand(t, "tail", "-partitions", "2", "-c", zeroeventhub.FirstCursor, "-state", state, "-exit", url)
+	require.NoError(t, err)
+	require.Equal(t, `{"partition":0,"data":{"n":5}}`+"\n", output)
+	saved, err = os.ReadFile(state)
+	require.NoError(t, err)
+	require.Equal(t, "5\n", string(saved))
+
+	// stops when cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, run(ctx, []string{"tail", "-partitions", "2", url}, io.Discard, io.Discard))
+
+	// polls after the interval hinted by the server rather than -interval
+	api.pollAfter = 10 * time.Millisecond
+	api.fetches = 0
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	require.NoError(t, run(ctx, []string{"tail", "-partitions", "2", "-interval", "1h", url}, io.Discard, io.Discard))
+	require.True(t, atomic.LoadInt32(&api.fetches) >= 3, "%d fetches", api.fetches)
+}
+
+func TestDump(t *testing.T) {
+	_, url := newTestServer(t)
+	file := filepath.Join(t.TempDir(), "dump.ndjson")
+
+	_, err := runCommand(t, "dump", "-partitions", "2", "-o", file, url)
+	require.NoError(t, err)
+	dump, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	var expected strings.Builder
+	for partitionID, events := range [][]string{{`{"n":0}`, `{"n":1}`, `{"n":2}`, `{"n":3}`, `{"n":4}`}, {`"a"`, `"b"`}} {
+		for i, event := range events {
+			fmt.Fprintf(&expected, `{"partition":%d,"headers":{"index":"%d","type":"test"},"data":%s}`+"\n", partitionID, i, event)
+			fmt.Fprintf(&expected, `{"partition":%d,"cursor":"%d"}`+"\n", partitionID, i)
+		}
+	}
+	require.Equal(t, expected.String(), string(dump))
+
+	output, err := runCommand(t, "dump", "-partitions", "2", "-p", "1", "-c", "0", "-headers", "", url)
+	require.NoError(t, err)
+	require.Equal(t, `{"partition":1,"data":"b"}`+"\n"+`{"partition":1,"cursor":"1"}`+"\n", output)
+}
+
+func TestUsage(t *testing.T) {
+	_, err := runCommand(t)
+	require.Equal(t, errUsage, err)
+	_, err = runCommand(t, "frobnicate")
+	require.Equal(t, errUsage, err)
+	_, err = runCommand(t, "fetch")
+	require.EqualError(t, err, "missing feed URL")
+	_, err = runCommand(t, "fetch", "-H", "nonsense", "http://localhost")
+	require.Error(t, err)
+}
+
+func TestCursor(t *testing.T) {
+	api, url := newTestServer(t)
+	state := filepath.Join(t.TempDir(), "cursor")
+
+	_, err := runCommand(t, "cursor", "get", state)
+	require.True(t, os.IsNotExist(err))
+	output, err := runCommand(t, "cursor", "set", "-dry-run", state, "2")
+	require.NoError(t, err)
+	require.Equal(t, state+`: "" -> "2"`+"\n", output)
+	_, err = os.Stat(state)
+	require.True(t, os.IsNotExist(err))
+
+	_, err = runCommand(t, "cursor", "set", state, "2")
+	require.NoError(t, err)
+	output, err = runCommand(t, "cursor", "get", state)
+	require.NoError(t, err)
+	require.Equal(t, "2\n", output)
+
+	// tail resumes from the cursor that was set
+	output, err = runCommand(t, "tail", "-partitions", "2", "-state", state, "-exit", url)
+	require.NoError(t, err)
+	require.Equal(t, `{"partition":0,"data":{"n":3}}`+"\n"+`{"partition":0,"data":{"n":4}}`+"\n", output)
+	output, err = runCommand(t, "cursor", "get", state)
+	require.NoError(t, err)
+	require.Equal(t, "4\n", output)
+
+	// rewinding to the start replays everything
+	_, err = runCommand(t, "cursor", "set", state, zeroeventhub.FirstCursor)
+	require.NoError(t, err)
+	output, err = runCommand(t, "tail", "-partitions", "2", "-state", state, "-exit", url)
+	require.NoError(t, err)
+	require.Equal(t, len(api.partitions[0]), strings.Count(output, "\n"))
+
+	_, err = runCommand(t, "cursor", "set", state)
+	require.EqualError(t, err, "missing state file or cursor")
+	_, err = runCommand(t, "cursor", "list")
+	require.EqualError(t, err, `unknown cursor command "list"`)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// cursorCommand reads or changes the cursor saved by tail -state, to rewind or fast-forward a tail.
+func cursorCommand(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		_, _ = io.WriteString(stderr, "usage: zeh cursor get|set [flags] <state file> [cursor]\n")
+		return errors.New("missing cursor command")
+	}
+	switch args[0] {
+	case "get":
+		flags := newFlagSet("cursor get", "<state file>", stderr)
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			flags.Usage()
+			return errors.New("missing state file")
+		}
+		cursor, err := readCursor(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, cursor)
+		return err
+	case "set":
+		flags := newFlagSet("cursor set", "<state file> <cursor>", stderr)
+		dryRun := flags.Bool("dry-run", false, "print the change instead of saving it")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 2 {
+			flags.Usage()
+			return errors.New("missing state file or cursor")
+		}
+		path, cursor := flags.Arg(0), flags.Arg(1)
+		if cursor == "" {
+			return errors.New("empty cursor")
+		}
+		previous, err := readCursor(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if *dryRun {
+			_, err := fmt.Fprintf(stdout, "%s: %q -> %q\n", path, previous, cursor)
+			return err
+		}
+		return saveCursor(path, cursor)
+	default:
+		return errors.Errorf("unknown cursor command %q", args[0])
+	}
+}
+
+// readCursor reads a cursor saved by saveCursor.
+func readCursor(path string) (string, error) {
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(saved)), nil
+}
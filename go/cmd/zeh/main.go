@@ -0,0 +1,72 @@
+// Command zeh is a command-line consumer for ZeroEventHub feeds, for poking at feeds during development and
+// operations.
+//
+// Usage:
+//
+//	zeh fetch [flags] <url>   fetch a page of a partition and print it
+//	zeh tail [flags] <url>    follow a partition, printing new events
+//	zeh dump [flags] <url>    drain partitions to an NDJSON file
+//	zeh serve [flags]         serve a dump over the protocol
+//	zeh cursor get|set ...    read or change the cursor saved by tail -state
+//
+// Run a subcommand with -h for its flags.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+const usage = `usage: zeh <command> [flags] [url]
+
+commands:
+  fetch   fetch a page of a partition and print it
+  tail    follow a partition, printing new events
+  dump    drain partitions to an NDJSON file
+  serve   serve a dump over the protocol
+  cursor  read or change the cursor saved by tail -state
+
+Run "zeh <command> -h" for the flags of a command.
+`
+
+// errUsage is returned for a missing or unknown command; the usage has been printed.
+var errUsage = errors.New("invalid command")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if err != errUsage {
+			fmt.Fprintln(os.Stderr, "zeh:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// commands are the subcommands; each parses its own flags from args and writes its output to stdout.
+var commands = map[string]func(ctx context.Context, args []string, stdout, stderr io.Writer) error{
+	"fetch":  fetchCommand,
+	"tail":   tailCommand,
+	"dump":   dumpCommand,
+	"serve":  serveCommand,
+	"cursor": cursorCommand,
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return errUsage
+	}
+	return command(ctx, args[1:], stdout, stderr)
+}
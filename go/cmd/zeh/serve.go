@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// dumpedEvent is an event of a dump together with the checkpoint following it.
+type dumpedEvent struct {
+	envelope zeroeventhub.Envelope
+	cursor   string
+}
+
+// dumpAPI serves the events of a dump written by the dump command, with their original cursors.
+type dumpAPI struct {
+	partitions [][]dumpedEvent
+	// positions maps the cursor of each event to its index, per partition
+	positions []map[string]int
+	latency   time.Duration
+}
+
+// dumpLoader is the receiver loadDump consumes a dump with.
+type dumpLoader struct {
+	partitions [][]dumpedEvent
+	pending    [][]zeroeventhub.Envelope
+}
+
+func (l *dumpLoader) partition(partitionID int) error {
+	if partitionID < 0 {
+		return zeroeventhub.ErrPartitionDoesntExist
+	}
+	for len(l.partitions) <= partitionID {
+		l.partitions = append(l.partitions, nil)
+		l.pending = append(l.pending, nil)
+	}
+	return nil
+}
+
+func (l *dumpLoader) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if err := l.partition(partitionID); err != nil {
+		return err
+	}
+	l.pending[partitionID] = append(l.pending[partitionID], zeroeventhub.Envelope{PartitionID: partitionID, Headers: headers, Data: data})
+	return nil
+}
+
+func (l *dumpLoader) Checkpoint(partitionID int, cursor string) error {
+	if err := l.partition(partitionID); err != nil {
+		return err
+	}
+	for _, envelope := range l.pending[partitionID] {
+		l.partitions[partitionID] = append(l.partitions[partitionID], dumpedEvent{envelope: envelope, cursor: cursor})
+	}
+	l.pending[partitionID] = nil
+	return nil
+}
+
+// loadDump reads a dump. An event's cursor is the first checkpoint of its partition after it; events after the
+// last checkpoint of a partition can't be resumed from, so they are left out. partitionCount 0 means one more
+// than the highest partition ID in the dump.
+func loadDump(ctx context.Context, r io.Reader, partitionCount int) (*dumpAPI, error) {
+	var loader dumpLoader
+	_, err := zeroeventhub.ConsumeNDJSON(ctx, r, &loader)
+	var streamErr *zeroeventhub.StreamError
+	if errors.As(err, &streamErr) {
+		return nil, errors.New("unexpected error line in dump")
+	}
+	if err != nil {
+		return nil, err
+	}
+	partitions := loader.partitions
+	if partitionCount == 0 {
+		partitionCount = len(partitions)
+	}
+	if partitionCount < len(partitions) {
+		return nil, errors.Errorf("dump has %d partitions, more than %d", len(partitions), partitionCount)
+	}
+	for len(partitions) < partitionCount {
+		partitions = append(partitions, nil)
+	}
+	api := &dumpAPI{partitions: partitions, positions: make([]map[string]int, partitionCount)}
+	for partitionID, events := range partitions {
+		api.positions[partitionID] = make(map[string]int, len(events))
+		for i, event := range events {
+			api.positions[partitionID][event.cursor] = i
+		}
+	}
+	return api, nil
+}
+
+func (d *dumpAPI) GetName() string {
+	return "zeh.dump"
+}
+
+func (d *dumpAPI) GetPartitionCount() int {
+	return len(d.partitions)
+}
+
+func (d *dumpAPI) FetchEvents(ctx context.Context, cursors []zeroeventhub.Cursor, pageSizeHint int, r zeroeventhub.EventReceiver, headers ...string) error {
+	if d.latency > 0 {
+		select {
+		case <-time.After(d.latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if pageSizeHint == zeroeventhub.DefaultPageSize {
+		pageSizeHint = 100
+	}
+	for _, cursor := range cursors {
+		events := d.partitions[cursor.PartitionID]
+		start := 0
+		switch cursor.Cursor {
+		case zeroeventhub.FirstCursor:
+		case zeroeventhub.LastCursor:
+			if len(events) > 0 {
+				start = len(events) - 1
+			}
+		default:
+			i, ok := d.positions[cursor.PartitionID][cursor.Cursor]
+			if !ok {
+				return errors.Errorf("cursor %q not in the dump of partition %d", cursor.Cursor, cursor.PartitionID)
+			}
+			start = i + 1
+		}
+		for i := start; i < len(events) && i < start+pageSizeHint; i++ {
+			if err := r.Event(cursor.PartitionID, events[i].envelope.Headers, events[i].envelope.Data); err != nil {
+				return err
+			}
+			if err := r.Checkpoint(cursor.PartitionID, events[i].cursor); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// serveCommand serves a dump over the protocol until interrupted, to reproduce issues locally.
+func serveCommand(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := newFlagSet("serve", "", stderr)
+	file := flags.String("f", "", "dump file to serve")
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	partitions := flags.Int("partitions", 0, "partition count; by default the partitions in the dump")
+	latency := flags.Duration("latency", 0, "artificial latency of every page")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return errors.New("missing dump file (-f)")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	api, err := loadDump(ctx, f, *partitions)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	api.latency = *latency
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: zeroeventhub.Handler(nil, api)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(stdout, "serving %d partitions on http://%s\n", api.GetPartitionCount(), listener.Addr())
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
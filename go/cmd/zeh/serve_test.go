@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDumpAndServe(t *testing.T) {
+	_, url := newTestServer(t)
+	dir := t.TempDir()
+	original := filepath.Join(dir, "original.ndjson")
+	_, err := runCommand(t, "dump", "-partitions", "2", "-o", original, url)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var stdout syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, []string{"serve", "-f", original, "-addr", "127.0.0.1:0", "-latency", "1ms"}, &stdout, &stdout)
+	}()
+	var servedURL string
+	for i := 0; i < 100 && servedURL == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if output := stdout.String(); strings.HasPrefix(output, "serving 2 partitions on ") {
+			servedURL = strings.TrimSpace(strings.TrimPrefix(output, "serving 2 partitions on "))
+		}
+	}
+	require.NotEmpty(t, servedURL, stdout.String())
+
+	// paging through the served dump with another page size gives the same dump
+	redumped := filepath.Join(dir, "redumped.ndjson")
+	_, err = runCommand(t, "dump", "-partitions", "2", "-n", "2", "-o", redumped, servedURL)
+	require.NoError(t, err)
+	expected, err := os.ReadFile(original)
+	require.NoError(t, err)
+	actual, err := os.ReadFile(redumped)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+
+	output, err := runCommand(t, "fetch", "-partitions", "2", "-p", "1", "-c", zeroeventhub.LastCursor, servedURL)
+	require.NoError(t, err)
+	require.Equal(t, `{"partition":1,"data":"b"}`+"\n"+`{"partition":1,"cursor":"1"}`+"\n", output)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestLoadDump(t *testing.T) {
+	dump := `{"partition":1,"data":1}
+{"partition":1,"data":2}
+{"partition":1,"cursor":"b"}
+{"partition":1,"cursor":"c"}
+{"partition":1,"data":3}
+`
+	api, err := loadDump(context.Background(), strings.NewReader(dump), 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, api.GetPartitionCount())
+	require.Empty(t, api.partitions[0])
+
+	// events share the checkpoint following them; the event without a checkpoint is left out
+	server := httptest.NewServer(zeroeventhub.Handler(nil, api))
+	defer server.Close()
+	var page zeroeventhub.EventPageRaw
+	require.NoError(t, zeroeventhub.NewClient(server.URL, 3).FetchEvents(context.Background(), []zeroeventhub.Cursor{{PartitionID: 1, Cursor: zeroeventhub.FirstCursor}}, 10, &page))
+	require.Len(t, page.Events, 2)
+	require.Equal(t, "b", page.Cursors[1])
+
+	_, err = loadDump(context.Background(), strings.NewReader(dump), 1)
+	require.EqualError(t, err, "dump has 2 partitions, more than 1")
+	_, err = loadDump(context.Background(), strings.NewReader(`{"partition":0,"error":"failed"}`), 0)
+	require.EqualError(t, err, "unexpected error line in dump")
+}
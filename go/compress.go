@@ -0,0 +1,97 @@
+package zeroeventhub
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// gzipWriters are reused by Handler between requests.
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+// acceptsGzip returns whether the request accepts a gzip response, i.e. its Accept-Encoding lists gzip with a
+// non-zero quality.
+func acceptsGzip(request *http.Request) bool {
+	for _, header := range request.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(header, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			if strings.TrimSpace(name) != "gzip" {
+				continue
+			}
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
+
+// gzipWriter buffers the first threshold bytes of a response and only compresses it with gzip if it grows beyond
+// them, as compressing a small page costs more CPU than it saves bytes, and may even make it larger. close must be
+// called at the end of a successful response to write a page below the threshold, and end the gzip stream.
+type gzipWriter struct {
+	writer    io.Writer
+	header    http.Header
+	threshold int
+	buffer    []byte
+	gz        *gzip.Writer
+}
+
+func newGzipWriter(writer io.Writer, header http.Header, threshold int) *gzipWriter {
+	return &gzipWriter{writer: writer, header: header, threshold: threshold}
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	if len(w.buffer)+len(p) <= w.threshold {
+		w.buffer = append(w.buffer, p...)
+		return len(p), nil
+	}
+	w.header.Set("Content-Encoding", "gzip")
+	w.header.Del("Content-Length")
+	w.gz = gzipWriters.Get().(*gzip.Writer)
+	w.gz.Reset(w.writer)
+	if _, err := w.gz.Write(w.buffer); err != nil {
+		return 0, err
+	}
+	w.buffer = nil
+	return w.gz.Write(p)
+}
+
+// started returns whether anything has been written to the response.
+func (w *gzipWriter) started() bool {
+	return w.gz != nil
+}
+
+// close writes the buffered page, if it stayed below the threshold, or ends the gzip stream.
+func (w *gzipWriter) close() error {
+	if w.gz == nil {
+		if len(w.buffer) == 0 {
+			return nil
+		}
+		_, err := w.writer.Write(w.buffer)
+		w.buffer = nil
+		return err
+	}
+	err := w.gz.Close()
+	w.release()
+	return err
+}
+
+// release returns the gzip.Writer to the pool without ending the stream, for responses that failed; it is a no-op
+// after close, and for a nil gzipWriter.
+func (w *gzipWriter) release() {
+	if w == nil || w.gz == nil {
+		return
+	}
+	w.gz.Reset(io.Discard)
+	gzipWriters.Put(w.gz)
+	w.gz = nil
+}
@@ -0,0 +1,131 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipThreshold(t *testing.T) {
+	api := NewTestZeroEventHubAPI()
+	// a page of one event is about 60 bytes, and of 100 events several kilobytes
+	server := httptest.NewServer(HandlerWithOptions(nil, api, HandlerOptions{Gzip: true, MinCompressBytes: 1000}))
+	defer server.Close()
+	plain := httptest.NewServer(Handler(nil, api))
+	defer plain.Close()
+
+	fetch := func(url, pageSizeHint string, acceptGzip bool) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, url+"/feed/v1?n=2&cursor0=10&pagesizehint="+pageSizeHint, nil)
+		require.NoError(t, err)
+		if acceptGzip {
+			// set explicitly, so that the transport leaves the response alone
+			req.Header.Set("Accept-Encoding", "gzip")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+	_, small := fetch(plain.URL, "1", false)
+	_, large := fetch(plain.URL, "100", false)
+
+	t.Run("below threshold", func(t *testing.T) {
+		resp, body := fetch(server.URL, "1", true)
+		require.Empty(t, resp.Header.Get("Content-Encoding"))
+		require.Equal(t, "Accept-Encoding", resp.Header.Get("Vary"))
+		require.Equal(t, small, body)
+	})
+
+	t.Run("above threshold", func(t *testing.T) {
+		resp, body := fetch(server.URL, "100", true)
+		require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+		require.True(t, len(body) < len(large))
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, large, decompressed)
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		resp, body := fetch(server.URL, "100", false)
+		require.Empty(t, resp.Header.Get("Content-Encoding"))
+		require.Equal(t, large, body)
+	})
+
+	t.Run("client", func(t *testing.T) {
+		var page EventPageRaw
+		require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 100, &page))
+		require.Len(t, page.Events, 100)
+		require.Equal(t, "110", page.Cursors[0])
+	})
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"":                    false,
+		"gzip":                true,
+		"deflate, gzip;q=0.5": true,
+		"br, gzip; q=0":       false,
+		"identity":            false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/feed/v1", nil)
+		req.Header.Set("Accept-Encoding", accept)
+		require.Equal(t, expected, acceptsGzip(req), accept)
+	}
+}
+
+// failingAfterAPI is a TestZeroEventHubAPI failing after writing its page.
+type failingAfterAPI struct {
+	*TestZeroEventHubAPI
+}
+
+func (a failingAfterAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	if err := a.TestZeroEventHubAPI.FetchEvents(ctx, cursors, pageSizeHint, r, headers...); err != nil {
+		return err
+	}
+	return errors.New("failed after the page")
+}
+
+func TestGzipFailure(t *testing.T) {
+	server := httptest.NewServer(HandlerWithOptions(nil, failingAfterAPI{NewTestZeroEventHubAPI()}, HandlerOptions{Gzip: true, MinCompressBytes: 1000}))
+	defer server.Close()
+	fetch := func(pageSizeHint string) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/feed/v1?n=2&cursor0=10&pagesizehint="+pageSizeHint, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	// the failure of a compressed page is in the gzip stream, which is complete
+	resp, body := fetch("100")
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.True(t, bytes.HasSuffix(decompressed, []byte(`{"partition":0,"cursor":"110"}`+"\nInternal server error\n")))
+	var page EventPageRaw
+	require.Error(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 100, &page))
+
+	// a page below the threshold is dropped for the error response
+	resp, body = fetch("1")
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("Content-Encoding"))
+	require.Equal(t, "Internal server error\n", string(body))
+}
@@ -0,0 +1,70 @@
+package zeroeventhub
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// maxDrainBytes is how much of an unread response body closeBody reads before closing it. The connection of a
+// response body closed before EOF can't be reused; reading a short rest is cheaper than a new connection, while
+// the rest of a large page is better abandoned.
+const maxDrainBytes = 64 << 10
+
+// closeBody drains up to maxDrainBytes of a response body and closes it, so that its connection can be reused.
+func closeBody(body io.ReadCloser) {
+	_, _ = io.CopyN(io.Discard, body, maxDrainBytes)
+	_ = body.Close()
+}
+
+// WithTransportDefaults is a Client method for replacing the transport of its HTTP client with one tuned for
+// polling a few servers frequently: more idle connections are kept per host than the 2 of http.DefaultTransport,
+// and for longer, so that consumers polling several partitions in parallel don't churn through connections.
+// The timeout and other settings of the HTTP client are kept, as are those of its transport if it is an
+// *http.Transport.
+func (c Client) WithTransportDefaults() (r Client) {
+	return c.withTransport(func(transport *http.Transport) {
+		transport.MaxIdleConns = 100
+		transport.MaxIdleConnsPerHost = 32
+		transport.IdleConnTimeout = 5 * time.Minute
+	})
+}
+
+// withTransport returns a client with a copy of the HTTP client and its transport (or http.DefaultTransport, if it
+// isn't an *http.Transport), changed by configure.
+func (c Client) withTransport(configure func(transport *http.Transport)) (r Client) {
+	r = c
+	base, ok := c.httpClient.Transport.(*http.Transport)
+	if !ok {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+	configure(transport)
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	r.httpClient = &httpClient
+	return
+}
+
+// WithConnObserver is a Client method for observing whether the requests of the client reuse connections, e.g. to
+// count reused connections in a metric. observe is called with the connection of every request, including hedged
+// ones; reused is false for new connections. Pass nil to stop observing.
+func (c Client) WithConnObserver(observe func(reused bool)) (r Client) {
+	r = c
+	r.onConn = observe
+	return
+}
+
+// traceConn adds the connection observer, if any, to the request.
+func (c Client) traceConn(req *http.Request) *http.Request {
+	if c.onConn == nil {
+		return req
+	}
+	onConn := c.onConn
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			onConn(info.Reused)
+		},
+	}))
+}
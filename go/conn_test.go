@@ -0,0 +1,74 @@
+package zeroeventhub
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientReusesConnections(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+
+	var reused []bool
+	client := NewClient(server.URL, 2).WithTransportDefaults().WithConnObserver(func(r bool) {
+		reused = append(reused, r)
+	})
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, 10, &page))
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 10, &page))
+	_, err := client.TailCursor(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, []bool{false, true, true}, reused)
+}
+
+// countingBody is a response body of the given size, counting the bytes read from it.
+type countingBody struct {
+	size, read int
+	closed     bool
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	if b.read == b.size {
+		return 0, io.EOF
+	}
+	n := len(p)
+	if n > b.size-b.read {
+		n = b.size - b.read
+	}
+	b.read += n
+	return n, nil
+}
+
+func (b *countingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestCloseBody(t *testing.T) {
+	// the rest of a short body is read, to let the connection be reused
+	body := &countingBody{size: 1000}
+	closeBody(body)
+	require.Equal(t, 1000, body.read)
+	require.True(t, body.closed)
+
+	// but not of a large one
+	body = &countingBody{size: 10 * maxDrainBytes}
+	closeBody(body)
+	require.Equal(t, maxDrainBytes, body.read)
+	require.True(t, body.closed)
+}
+
+func TestWithTransportDefaults(t *testing.T) {
+	client := NewClient("http://localhost", 1).WithHttpClient(&http.Client{Timeout: time.Second}).WithTransportDefaults()
+	require.Equal(t, time.Second, client.httpClient.Timeout)
+	transport := client.httpClient.Transport.(*http.Transport)
+	require.Equal(t, 32, transport.MaxIdleConnsPerHost)
+	// the default transport is left alone
+	require.Equal(t, 0, http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost)
+}
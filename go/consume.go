@@ -0,0 +1,44 @@
+package zeroeventhub
+
+import (
+	"context"
+	"io"
+)
+
+// ConsumeNDJSON passes the events and checkpoints of an NDJSON stream in the format of the feed, e.g. a dump in blob
+// storage, to receiver, as Client.FetchEvents does with a response, so that the same receivers can process it. It
+// returns the last cursor of every partition with a checkpoint, also on failure, to resume from. Lines are decoded
+// as by DecodeStream, with the same MaxLineBytes limit as the client; blank lines are skipped, and an error line
+// fails with a *StreamError. ctx is checked between lines.
+func ConsumeNDJSON(ctx context.Context, r io.Reader, receiver EventReceiver) (Cursors, error) {
+	cursors := Cursors{}
+	err := decodeRawLines(r, false, DecodeOptions{}, func(line *rawLine) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := receiveLine(line, receiver); err != nil {
+			return err
+		}
+		if line.kind() == LineCheckpoint {
+			cursors[line.PartitionID] = string(line.Cursor)
+		}
+		return nil
+	})
+	return cursors, err
+}
+
+// receiveLine passes a decoded line to r: an event, a checkpoint or a caught-up checkpoint. An error line is
+// returned as a *StreamError.
+func receiveLine(line *rawLine, r EventReceiver) error {
+	switch line.kind() {
+	case LineCheckpoint:
+		if line.CaughtUp {
+			return SendCaughtUp(r, line.PartitionID, string(line.Cursor))
+		}
+		return r.Checkpoint(line.PartitionID, string(line.Cursor))
+	case LineError:
+		return &StreamError{PartitionID: line.PartitionID, Message: line.Error}
+	default:
+		return r.Event(line.PartitionID, line.Headers, line.Data)
+	}
+}
@@ -0,0 +1,57 @@
+package zeroeventhub
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumeNDJSONGoldenFiles(t *testing.T) {
+	for _, c := range readGoldenCases(t) {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			var recorder goldenRecorder
+			cursors, err := ConsumeNDJSON(context.Background(), strings.NewReader(string(c.response)), &recorder)
+			require.NoError(t, err)
+			requireGoldenCalls(t, c.expected, recorder.calls)
+
+			expected := Cursors{}
+			for _, call := range c.expected {
+				if call.Kind == "checkpoint" {
+					expected[call.Partition] = call.Cursor
+				}
+			}
+			require.Equal(t, expected, cursors)
+		})
+	}
+}
+
+func TestConsumeNDJSON(t *testing.T) {
+	stream := `{"partition":0,"data":1}` + "\n" +
+		`{"partition":0,"cursor":"1"}` + "\n" +
+		`{"partition":1,"cursor":"7","caughtUp":true}` + "\n" +
+		`{"partition":0,"error":"failed"}` + "\n" +
+		`{"partition":0,"data":2}` + "\n"
+
+	// a caught-up checkpoint, and the cursors up to an error line
+	var page caughtUpPage
+	cursors, err := ConsumeNDJSON(context.Background(), strings.NewReader(stream), &page)
+	require.Equal(t, &StreamError{PartitionID: 0, Message: "failed"}, err)
+	require.Equal(t, Cursors{0: "1", 1: "7"}, cursors)
+	require.Len(t, page.Events, 1)
+	require.Equal(t, map[int]string{1: "7"}, page.caughtUp)
+
+	// cancellation is noticed at the next line
+	ctx, cancel := context.WithCancel(context.Background())
+	receiver := cancellingReceiver{cancelAfter: 1, cancel: cancel}
+	cursors, err = ConsumeNDJSON(ctx, strings.NewReader(stream), &receiver)
+	require.True(t, errors.Is(err, context.Canceled))
+	require.Empty(t, cursors)
+	require.Len(t, receiver.Events, 1)
+
+	_, err = ConsumeNDJSON(context.Background(), strings.NewReader(`{"data":"`+strings.Repeat("x", MaxLineBytes)+`"}`), &page)
+	require.Equal(t, ErrLineTooLong, err)
+}
@@ -0,0 +1,20 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+)
+
+type requestContextKey struct{}
+
+func contextWithRequest(ctx context.Context, request *http.Request) context.Context {
+	return context.WithValue(ctx, requestContextKey{}, request)
+}
+
+// RequestFromContext returns the HTTP request being served, for publishers that need parameters beyond the
+// ones in the protocol (e.g. a tenant hint in the query string). It is only set when API.FetchEvents is
+// called from Handler; ok is false otherwise.
+func RequestFromContext(ctx context.Context) (request *http.Request, ok bool) {
+	request, ok = ctx.Value(requestContextKey{}).(*http.Request)
+	return
+}
@@ -0,0 +1,46 @@
+package zeroeventhub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tenantZeroEventHubAPI records the tenant query parameter of the request being served.
+type tenantZeroEventHubAPI struct {
+	TestZeroEventHubAPI
+	tenants chan string
+}
+
+func (t tenantZeroEventHubAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	request, ok := RequestFromContext(ctx)
+	if !ok {
+		return errors.New("request missing from context")
+	}
+	t.tenants <- request.URL.Query().Get("tenant")
+	return t.TestZeroEventHubAPI.FetchEvents(ctx, cursors, pageSizeHint, r, headers...)
+}
+
+func TestRequestFromContext(t *testing.T) {
+	_, ok := RequestFromContext(context.Background())
+	require.False(t, ok)
+
+	api := tenantZeroEventHubAPI{TestZeroEventHubAPI: *NewTestZeroEventHubAPI(), tenants: make(chan string, 1)}
+	server := httptest.NewServer(Handler(nil, api))
+	defer server.Close()
+	client := NewClient(server.URL, 2).WithRequestProcessor(func(r *http.Request) error {
+		q := r.URL.Query()
+		q.Set("tenant", "tenant-1")
+		r.URL.RawQuery = q.Encode()
+		return nil
+	})
+	var page EventPageRaw
+	err := client.FetchEvents(context.Background(), []Cursor{{Cursor: LastCursor}}, DefaultPageSize, &page)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	require.Equal(t, "tenant-1", <-api.tenants)
+}
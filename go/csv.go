@@ -0,0 +1,129 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CSVColumn is a column written by CSVReceiver.
+type CSVColumn struct {
+	// Name is the name of the column in the header row.
+	Name string
+	// Path is the value in the data of the event, as the keys of nested objects separated by dots, e.g.
+	// "customer.address.city"; keys that are numbers index arrays. The empty path is the data as a whole.
+	Path string
+}
+
+// CSVReceiver implements EventReceiver by writing a CSV row for each event, e.g. for exporting a feed to a
+// spreadsheet, starting with a header row of the column names. Strings are written as they are, other values as
+// JSON; missing values and null are written as empty cells. Checkpoints are ignored. Flush must be called at the
+// end.
+type CSVReceiver struct {
+	writer        *csv.Writer
+	columns       []CSVColumn
+	paths         [][]string
+	row           []string
+	headerWritten bool
+}
+
+// NewCSVReceiver returns a CSVReceiver writing the given columns to writer.
+func NewCSVReceiver(writer io.Writer, columns ...CSVColumn) *CSVReceiver {
+	paths := make([][]string, len(columns))
+	for i, column := range columns {
+		if column.Path != "" {
+			paths[i] = strings.Split(column.Path, ".")
+		}
+	}
+	return &CSVReceiver{
+		writer:  csv.NewWriter(writer),
+		columns: columns,
+		paths:   paths,
+		row:     make([]string, len(columns)),
+	}
+}
+
+func (c *CSVReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// numbers are written as they are, rather than through float64
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return errors.Wrapf(err, "event in partition %d", partitionID)
+	}
+	for i, path := range c.paths {
+		cell, err := csvCell(lookupPath(value, path))
+		if err != nil {
+			return err
+		}
+		c.row[i] = cell
+	}
+	return c.writer.Write(c.row)
+}
+
+func (c *CSVReceiver) Checkpoint(int, string) error {
+	return nil
+}
+
+// Flush writes any buffered rows, and the header row if there were no events, to the underlying writer.
+func (c *CSVReceiver) Flush() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *CSVReceiver) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+	for i, column := range c.columns {
+		c.row[i] = column.Name
+	}
+	return c.writer.Write(c.row)
+}
+
+// lookupPath returns the value at path in value, as decoded by encoding/json, or nil if there is none.
+func lookupPath(value interface{}, path []string) interface{} {
+	for _, key := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+func csvCell(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
+
+var _ EventReceiver = &CSVReceiver{}
@@ -0,0 +1,52 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVReceiver(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	receiver := NewCSVReceiver(&buf,
+		CSVColumn{Name: "ID", Path: "ID"},
+		CSVColumn{Name: "Version", Path: "Version"},
+		CSVColumn{Name: "Cursor", Path: "Cursor"},
+	)
+	require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 2, receiver))
+	require.NoError(t, receiver.Flush())
+	require.Equal(t, "ID,Version,Cursor\n"+
+		"00000000-0000-0000-0000-00000000000b,0,11\n"+
+		"00000000-0000-0000-0000-00000000000c,0,12\n", buf.String())
+}
+
+func TestCSVReceiverValues(t *testing.T) {
+	var buf bytes.Buffer
+	receiver := NewCSVReceiver(&buf,
+		CSVColumn{Name: "name", Path: "customer.name"},
+		CSVColumn{Name: "first tag", Path: "tags.0"},
+		CSVColumn{Name: "amount", Path: "amount"},
+		CSVColumn{Name: "tags", Path: "tags"},
+		CSVColumn{Name: "missing", Path: "customer.missing.value"},
+	)
+	require.NoError(t, receiver.Event(0, nil, json.RawMessage(`{"customer":{"name":"Doe, Jane"},"tags":["a","b"],"amount":12345678901234567890}`)))
+	require.NoError(t, receiver.Event(0, nil, json.RawMessage(`{"customer":null,"tags":[],"amount":null}`)))
+	require.NoError(t, receiver.Checkpoint(0, "1"))
+	require.Error(t, receiver.Event(0, nil, json.RawMessage(`not json`)))
+	require.NoError(t, receiver.Flush())
+	require.Equal(t, "name,first tag,amount,tags,missing\n"+
+		`"Doe, Jane",a,12345678901234567890,"[""a"",""b""]",`+"\n"+
+		",,,[],\n", buf.String())
+
+	// an empty export still has the header row
+	buf.Reset()
+	require.NoError(t, NewCSVReceiver(&buf, CSVColumn{Name: "data"}).Flush())
+	require.Equal(t, "data\n", buf.String())
+}
@@ -0,0 +1,113 @@
+package zeroeventhub
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Cursors is the cursor of each partition of a feed, as stored by a consumer between pages. It has the same
+// underlying type as EventPageRaw.Cursors and DrainReceiver.Cursors, which convert to it with Cursors(page.Cursors).
+type Cursors map[int]string
+
+// ForPartition returns the cursor of the partition; ok is false if there is none.
+func (c Cursors) ForPartition(partitionID int) (cursor string, ok bool) {
+	cursor, ok = c[partitionID]
+	return
+}
+
+// Merge returns the cursors of c updated with those of other, e.g. to combine the cursors of workers reading
+// different partitions. Where both have a cursor for a partition, the one of other wins.
+func (c Cursors) Merge(other Cursors) Cursors {
+	merged := make(Cursors, len(c)+len(other))
+	for partitionID, cursor := range c {
+		merged[partitionID] = cursor
+	}
+	for partitionID, cursor := range other {
+		merged[partitionID] = cursor
+	}
+	return merged
+}
+
+// Equal returns whether c and other have the same cursors for the same partitions.
+func (c Cursors) Equal(other Cursors) bool {
+	if len(c) != len(other) {
+		return false
+	}
+	for partitionID, cursor := range c {
+		if otherCursor, ok := other[partitionID]; !ok || otherCursor != cursor {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns the cursors ordered by partition, e.g. for FetchEvents.
+func (c Cursors) List() []Cursor {
+	list := make([]Cursor, 0, len(c))
+	for partitionID, cursor := range c {
+		list = append(list, Cursor{PartitionID: partitionID, Cursor: cursor})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].PartitionID < list[j].PartitionID
+	})
+	return list
+}
+
+// QueryValues returns the cursors as the query parameters of a request for events in the protocol: cursor0,
+// cursor1, ...
+func (c Cursors) QueryValues() url.Values {
+	values := make(url.Values, len(c))
+	for partitionID, cursor := range c {
+		values.Set("cursor"+strconv.Itoa(partitionID), cursor)
+	}
+	return values
+}
+
+// CursorTracker implements EventReceiver by passing everything on to another receiver, recording the latest
+// cursor of each partition on the way, for consumers passing the events somewhere else than an EventPageRaw. It is
+// safe to read Cursors while a fetch is in progress.
+type CursorTracker struct {
+	receiver EventReceiver
+	lock     sync.Mutex
+	cursors  Cursors
+}
+
+// NewCursorTracker returns a CursorTracker passing everything on to receiver, starting from the given cursors,
+// which may be nil.
+func NewCursorTracker(receiver EventReceiver, cursors Cursors) *CursorTracker {
+	return &CursorTracker{receiver: receiver, cursors: Cursors(nil).Merge(cursors)}
+}
+
+func (t *CursorTracker) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	return t.receiver.Event(partitionID, headers, data)
+}
+
+// Checkpoint records the cursor once receiver has accepted it.
+func (t *CursorTracker) Checkpoint(partitionID int, cursor string) error {
+	if err := t.receiver.Checkpoint(partitionID, cursor); err != nil {
+		return err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.cursors[partitionID] = cursor
+	return nil
+}
+
+func (t *CursorTracker) PollAfter(d time.Duration) {
+	if receiver, ok := t.receiver.(PollIntervalReceiver); ok {
+		receiver.PollAfter(d)
+	}
+}
+
+// Cursors returns a copy of the latest cursor of each partition.
+func (t *CursorTracker) Cursors() Cursors {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return Cursors(nil).Merge(t.cursors)
+}
+
+var _ EventReceiver = &CursorTracker{}
@@ -0,0 +1,75 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursors(t *testing.T) {
+	cursors := Cursors{1: "10", 0: "_first"}
+	cursor, ok := cursors.ForPartition(1)
+	require.True(t, ok)
+	require.Equal(t, "10", cursor)
+	_, ok = cursors.ForPartition(2)
+	require.False(t, ok)
+
+	require.Equal(t, []Cursor{{PartitionID: 0, Cursor: "_first"}, {PartitionID: 1, Cursor: "10"}}, cursors.List())
+	require.Equal(t, url.Values{"cursor0": {"_first"}, "cursor1": {"10"}}, cursors.QueryValues())
+
+	merged := cursors.Merge(Cursors{1: "20", 2: "5"})
+	require.Equal(t, Cursors{0: "_first", 1: "20", 2: "5"}, merged)
+	require.Equal(t, Cursors{1: "10", 0: "_first"}, cursors)
+	require.Equal(t, cursors, Cursors(nil).Merge(cursors))
+
+	require.True(t, cursors.Equal(Cursors{0: "_first", 1: "10"}))
+	require.False(t, cursors.Equal(merged))
+	require.False(t, cursors.Equal(Cursors{0: "_first", 2: "10"}))
+	require.False(t, cursors.Equal(Cursors{0: "_first", 1: "11"}))
+	require.True(t, Cursors{}.Equal(nil))
+}
+
+func TestCursorTracker(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	// workers reading a partition each, concurrently
+	start := Cursors{0: "10", 1: "100"}
+	trackers := make([]*CursorTracker, 2)
+	var wg sync.WaitGroup
+	for partitionID := range trackers {
+		trackers[partitionID] = NewCursorTracker(&countingReceiver{}, Cursors{partitionID: start[partitionID]})
+		wg.Add(1)
+		go func(tracker *CursorTracker, partitionID int) {
+			defer wg.Done()
+			cursor, _ := tracker.Cursors().ForPartition(partitionID)
+			for i := 0; i < 3; i++ {
+				assert.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: partitionID, Cursor: cursor}}, 5, tracker))
+				cursor, _ = tracker.Cursors().ForPartition(partitionID)
+			}
+		}(trackers[partitionID], partitionID)
+	}
+	// reading the cursors while the workers run is safe
+	_ = trackers[0].Cursors()
+	wg.Wait()
+
+	merged := start
+	for _, tracker := range trackers {
+		merged = merged.Merge(tracker.Cursors())
+	}
+	require.Equal(t, Cursors{0: "25", 1: "115"}, merged)
+
+	// the same, through a single fetch of both partitions
+	var page EventPageRaw
+	tracker := NewCursorTracker(&page, nil)
+	require.NoError(t, FetchEventsParallel(context.Background(), client, start.List(), 15, tracker))
+	require.True(t, merged.Equal(tracker.Cursors()))
+	require.True(t, Cursors(page.Cursors).Equal(tracker.Cursors()))
+	require.Len(t, page.Events, 30)
+}
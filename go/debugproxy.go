@@ -0,0 +1,247 @@
+package zeroeventhub
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugProxySettings configures a DebugProxy.
+type DebugProxySettings struct {
+	// HttpClient is used for the upstream requests (default http.DefaultClient).
+	HttpClient *http.Client
+	// RecordLines is the number of most recent lines kept for Lines; 0 disables recording.
+	RecordLines int
+	// LineLatency delays every line passed on to the consumer, for fault injection.
+	LineLatency time.Duration
+	// TruncateAfterLines ends every response after this many lines, for fault injection; 0 disables truncation.
+	TruncateAfterLines int
+	// StrictDecoding checks every line as with DecodeOptions.Strict, recording and logging violations; the lines
+	// are passed on as-is regardless.
+	StrictDecoding bool
+}
+
+// DebugProxyLine is a line of a response passed through a DebugProxy.
+type DebugProxyLine struct {
+	RequestURL string
+	// Elapsed is the time from receiving the request until the line was passed on.
+	Elapsed time.Duration
+	Line    Line
+	// ParseError is set, and Line empty, if the line couldn't be parsed; it was passed on as-is regardless.
+	ParseError error
+	// Violation is set for a line violating the protocol, with DebugProxySettings.StrictDecoding.
+	Violation *ProtocolViolationError
+}
+
+// DebugProxyCounters are the totals of everything passed through a DebugProxy.
+type DebugProxyCounters struct {
+	Requests      int64
+	UpstreamFails int64
+	Lines         int64
+	Events        int64
+	Checkpoints   int64
+	StreamErrors  int64
+	ParseErrors   int64
+	Violations    int64
+}
+
+// DebugProxy is an http.Handler sitting between a consumer and a feed for diagnosing feed traffic: it forwards
+// requests to the upstream server and streams the responses back line by line, flushing every line, while logging
+// (at debug level) and optionally recording each parsed line with its timing. Responses other than 2xx are passed
+// on without parsing.
+type DebugProxy struct {
+	upstream *url.URL
+	settings DebugProxySettings
+	logger   Logger
+
+	mu       sync.Mutex
+	lines    []DebugProxyLine
+	counters DebugProxyCounters
+}
+
+// NewDebugProxy returns a DebugProxy forwarding to the upstream base URL, i.e. what would be passed to NewClient.
+func NewDebugProxy(upstream string, settings DebugProxySettings, logger Logger) (*DebugProxy, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if settings.HttpClient == nil {
+		settings.HttpClient = http.DefaultClient
+	}
+	return &DebugProxy{upstream: u, settings: settings, logger: orNop(logger)}, nil
+}
+
+// Lines returns the recorded lines, oldest first.
+func (p *DebugProxy) Lines() []DebugProxyLine {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]DebugProxyLine(nil), p.lines...)
+}
+
+// Counters returns the totals so far.
+func (p *DebugProxy) Counters() DebugProxyCounters {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.counters
+}
+
+func (p *DebugProxy) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	start := time.Now()
+	p.count(func(c *DebugProxyCounters) { c.Requests++ })
+
+	target := *p.upstream
+	target.Path = strings.TrimSuffix(target.Path, "/") + request.URL.Path
+	target.RawQuery = request.URL.RawQuery
+	req, err := http.NewRequestWithContext(request.Context(), request.Method, target.String(), nil)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header = request.Header.Clone()
+	removeHopByHopHeaders(req.Header)
+	// leave compression to the transport, which then decompresses the response, so that it can be parsed
+	req.Header.Del("Accept-Encoding")
+	log := p.logger.WithField("requestUrl", target.String())
+
+	res, err := p.settings.HttpClient.Do(req)
+	if err != nil {
+		p.count(func(c *DebugProxyCounters) { c.UpstreamFails++ })
+		log.WithField("event", "zeroeventhub.debug_proxy.upstream_error").WithError(err).Warn()
+		http.Error(writer, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer closeBody(res.Body)
+	header := res.Header.Clone()
+	removeHopByHopHeaders(header)
+	for key, values := range header {
+		writer.Header()[key] = values
+	}
+	// the body is streamed, and may be truncated
+	writer.Header().Del("Content-Length")
+	writer.WriteHeader(res.StatusCode)
+	if logEnabled(p.logger, LevelDebug) {
+		log.WithField("event", "zeroeventhub.debug_proxy.response").
+			WithField("responseCode", res.StatusCode).
+			WithField("elapsed", time.Since(start)).
+			Debug()
+	}
+	if res.StatusCode/100 != 2 {
+		_, _ = io.Copy(writer, res.Body)
+		return
+	}
+
+	flusher, _ := writer.(http.Flusher)
+	reader := bufio.NewReader(res.Body)
+	for lines := 0; p.settings.TruncateAfterLines == 0 || lines < p.settings.TruncateAfterLines; lines++ {
+		b, err := reader.ReadBytes('\n')
+		if len(b) > 0 {
+			if p.settings.LineLatency > 0 {
+				select {
+				case <-time.After(p.settings.LineLatency):
+				case <-request.Context().Done():
+					return
+				}
+			}
+			if _, err := writer.Write(b); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			p.record(log, target.String(), time.Since(start), b, lines+1)
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.WithField("event", "zeroeventhub.debug_proxy.read_error").WithError(err).Warn()
+			return
+		}
+	}
+}
+
+// hopByHopHeaders are the headers that apply to a single connection, and so aren't forwarded by a proxy (RFC 9110
+// section 7.6.1).
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// removeHopByHopHeaders removes the hop-by-hop headers from header, including those listed in Connection.
+func removeHopByHopHeaders(header http.Header) {
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		header.Del(name)
+	}
+}
+
+func (p *DebugProxy) count(f func(c *DebugProxyCounters)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f(&p.counters)
+}
+
+// record records line number n of the response, b.
+func (p *DebugProxy) record(log Logger, requestURL string, elapsed time.Duration, b []byte, n int) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return
+	}
+	recorded := DebugProxyLine{RequestURL: requestURL, Elapsed: elapsed}
+	recorded.Line, recorded.ParseError = ParseLine(b)
+	if recorded.ParseError != nil {
+		recorded.Line = Line{}
+	} else if p.settings.StrictDecoding {
+		recorded.Violation = checkLine(b, recorded.Line.Kind, n)
+	}
+	if recorded.Violation != nil {
+		log.WithField("event", "zeroeventhub.debug_proxy.protocol_violation").WithError(recorded.Violation).Warn()
+	}
+	if logEnabled(p.logger, LevelDebug) {
+		log.WithField("event", "zeroeventhub.debug_proxy.line").
+			WithField("elapsed", elapsed).
+			WithField("line", string(b)).
+			Debug()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counters.Lines++
+	if recorded.Violation != nil {
+		p.counters.Violations++
+	}
+	switch {
+	case recorded.ParseError != nil:
+		p.counters.ParseErrors++
+	case recorded.Line.Kind == LineEvent:
+		p.counters.Events++
+	case recorded.Line.Kind == LineCheckpoint:
+		p.counters.Checkpoints++
+	case recorded.Line.Kind == LineError:
+		p.counters.StreamErrors++
+	}
+	if p.settings.RecordLines > 0 {
+		p.lines = append(p.lines, recorded)
+		if len(p.lines) > p.settings.RecordLines {
+			p.lines = p.lines[len(p.lines)-p.settings.RecordLines:]
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugProxy(t *testing.T) {
+	upstream := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer upstream.Close()
+	proxy, err := NewDebugProxy(upstream.URL, DebugProxySettings{RecordLines: 3}, nil)
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	cursors := []Cursor{{PartitionID: 0, Cursor: "100"}, {PartitionID: 1, Cursor: "200"}}
+	var direct, proxied EventPageRaw
+	require.NoError(t, NewClient(upstream.URL, 2).FetchEvents(context.Background(), cursors, 10, &direct, All))
+	require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), cursors, 10, &proxied, All))
+	require.Equal(t, direct, proxied)
+
+	// errors are passed on as-is
+	err = NewClient(server.URL, 3).FetchEvents(context.Background(), cursors, 10, &proxied)
+	require.Equal(t, &ResponseError{StatusCode: http.StatusBadRequest, Body: "handshake error: partition count mismatch\n"}, err)
+
+	require.Equal(t, DebugProxyCounters{Requests: 2, Lines: 40, Events: 20, Checkpoints: 20}, proxy.Counters())
+	lines := proxy.Lines()
+	require.Len(t, lines, 3)
+	require.Equal(t, LineCheckpoint, lines[0].Line.Kind)
+	require.Equal(t, LineEvent, lines[1].Line.Kind)
+	require.Equal(t, Line{Kind: LineCheckpoint, Checkpoint: &Cursor{PartitionID: 1, Cursor: "210"}}, lines[2].Line)
+	require.Contains(t, lines[2].RequestURL, upstream.URL+"/feed/v1?")
+	require.True(t, lines[1].Elapsed <= lines[2].Elapsed)
+}
+
+func TestDebugProxyTruncation(t *testing.T) {
+	upstream := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer upstream.Close()
+	proxy, err := NewDebugProxy(upstream.URL, DebugProxySettings{TruncateAfterLines: 5}, nil)
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	var page EventPageRaw
+	require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "100"}}, 10, &page))
+	require.Len(t, page.Events, 3)
+	require.Equal(t, map[int]string{0: "102"}, page.Cursors)
+	require.Empty(t, proxy.Lines())
+	require.Equal(t, int64(5), proxy.Counters().Lines)
+}
+
+func TestDebugProxyGzip(t *testing.T) {
+	upstream := httptest.NewServer(HandlerWithOptions(nil, NewTestZeroEventHubAPI(), HandlerOptions{Gzip: true}))
+	defer upstream.Close()
+	for _, truncate := range []int{0, 5} {
+		proxy, err := NewDebugProxy(upstream.URL, DebugProxySettings{RecordLines: 100, TruncateAfterLines: truncate}, nil)
+		require.NoError(t, err)
+		server := httptest.NewServer(proxy)
+
+		// the client asks for gzip, which mustn't reach the upstream
+		var page EventPageRaw
+		require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "100"}}, 10, &page))
+		server.Close()
+		if truncate == 0 {
+			require.Len(t, page.Events, 10)
+			require.Equal(t, DebugProxyCounters{Requests: 1, Lines: 20, Events: 10, Checkpoints: 10}, proxy.Counters())
+		} else {
+			require.Len(t, page.Events, 3)
+			require.Equal(t, map[int]string{0: "102"}, page.Cursors)
+			require.Equal(t, DebugProxyCounters{Requests: 1, Lines: 5, Events: 3, Checkpoints: 2}, proxy.Counters())
+		}
+	}
+}
+
+func TestDebugProxyHopByHopHeaders(t *testing.T) {
+	var upstreamHeader http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		upstreamHeader = request.Header.Clone()
+		writer.Header().Set("Connection", "X-Response-Hop")
+		writer.Header().Set("X-Response-Hop", "1")
+		writer.Header().Set("Keep-Alive", "timeout=5")
+		writer.Header().Set("X-Response-End", "1")
+	}))
+	defer upstream.Close()
+	proxy, err := NewDebugProxy(upstream.URL, DebugProxySettings{}, nil)
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodGet, "/feed/v1", nil)
+	request.Header.Set("Connection", "X-Request-Hop")
+	request.Header.Set("X-Request-Hop", "1")
+	request.Header.Set("Keep-Alive", "timeout=5")
+	request.Header.Set("Proxy-Authorization", "secret")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("X-Request-End", "1")
+	recorder := httptest.NewRecorder()
+	proxy.ServeHTTP(recorder, request)
+
+	require.Equal(t, "1", upstreamHeader.Get("X-Request-End"))
+	for _, name := range []string{"X-Request-Hop", "Keep-Alive", "Proxy-Authorization", "Upgrade"} {
+		require.Empty(t, upstreamHeader.Values(name), name)
+	}
+	require.Equal(t, "1", recorder.Header().Get("X-Response-End"))
+	for _, name := range []string{"Connection", "X-Response-Hop", "Keep-Alive"} {
+		require.Empty(t, recorder.Header().Values(name), name)
+	}
+}
+
+func TestDebugProxyLogging(t *testing.T) {
+	upstream := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer upstream.Close()
+	for _, min := range []LogLevel{LevelDebug, LevelInfo} {
+		log := &recordingLogger{}
+		proxy, err := NewDebugProxy(upstream.URL, DebugProxySettings{}, levelLogger{recordingLogger: log, min: min})
+		require.NoError(t, err)
+		server := httptest.NewServer(proxy)
+
+		var page EventPageRaw
+		require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "100"}}, 2, &page))
+		server.Close()
+		if min == LevelDebug {
+			entries := log.Entries()
+			require.Len(t, entries, 5)
+			require.Equal(t, "zeroeventhub.debug_proxy.response", entries[0].Fields["event"])
+			require.Equal(t, "zeroeventhub.debug_proxy.line", entries[1].Fields["event"])
+		} else {
+			// the lines aren't even formatted unless logged
+			require.Empty(t, log.Entries())
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package zeroeventhub
+
+import (
+	"context"
+	"fmt"
+)
+
+// Direction is the direction in which a page of events is read.
+type Direction int
+
+const (
+	// Forward reads events oldest-first; this is the default.
+	Forward Direction = iota
+	// Backward reads events newest-first, e.g. for showing the latest events. When paging backward, a cursor means
+	// "start strictly before" rather than "start strictly after", and LastCursor starts at (and includes) the last
+	// event. Publishers have to support it explicitly; check with the publisher before relying on it.
+	Backward
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Forward:
+		return "forward"
+	case Backward:
+		return "backward"
+	default:
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+}
+
+func parseDirection(s string) (Direction, error) {
+	switch s {
+	case "", "forward":
+		return Forward, nil
+	case "backward":
+		return Backward, nil
+	default:
+		return Forward, ErrIllegalDirection
+	}
+}
+
+type directionContextKey struct{}
+
+// WithDirection returns a context passing the direction to read in to API.FetchEvents; Handler passes the choice of
+// the client to the API this way. Publishers check it with DirectionFromContext.
+func WithDirection(ctx context.Context, direction Direction) context.Context {
+	return context.WithValue(ctx, directionContextKey{}, direction)
+}
+
+// DirectionFromContext returns the direction set by WithDirection, Forward by default.
+func DirectionFromContext(ctx context.Context) Direction {
+	direction, _ := ctx.Value(directionContextKey{}).(Direction)
+	return direction
+}
+
+// WithDirection is a Client method for reading pages in the given direction; see Backward.
+func (c Client) WithDirection(direction Direction) (r Client) {
+	r = c
+	r.direction = direction
+	return
+}
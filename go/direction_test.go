@@ -0,0 +1,53 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackwardPaging(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	client := NewClient(server.URL, 2).WithDirection(Backward)
+	ctx := context.Background()
+
+	var page EventPageSingleType[TestEvent]
+	err := client.FetchEvents(ctx, []Cursor{{PartitionID: 1, Cursor: LastCursor}}, 3, &page)
+	require.NoError(t, err)
+	var cursors []int
+	for _, e := range page.Events {
+		cursors = append(cursors, e.Data.Cursor)
+	}
+	require.Equal(t, []int{9999, 9998, 9997}, cursors)
+	require.Equal(t, map[int]string{1: "9997"}, page.Cursors)
+
+	// continuing from the checkpoint pages further back
+	page = EventPageSingleType[TestEvent]{}
+	err = client.FetchEvents(ctx, []Cursor{{PartitionID: 1, Cursor: "9997"}}, 2, &page)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 2)
+	require.Equal(t, 9996, page.Events[0].Data.Cursor)
+	require.Equal(t, 9995, page.Events[1].Data.Cursor)
+
+	// forward is the default
+	page = EventPageSingleType[TestEvent]{}
+	err = NewClient(server.URL, 2).FetchEvents(ctx, []Cursor{{PartitionID: 1, Cursor: "9997"}}, 2, &page)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 2)
+	require.Equal(t, 9998, page.Events[0].Data.Cursor)
+}
+
+func TestIllegalDirection(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	res, err := http.Get(server.URL + "/feed/v1?n=2&cursor0=_first&direction=sideways")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	require.Equal(t, "forward", Forward.String())
+	require.Equal(t, "backward", Backward.String())
+}
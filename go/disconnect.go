@@ -0,0 +1,78 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ErrClientDisconnected is returned by the EventReceiver Handler passes to API.FetchEvents once writing the
+// response has failed because the client went away. Publishers should stop producing the page when they get it;
+// Handler doesn't treat it as a failure.
+var ErrClientDisconnected = errors.New("client disconnected")
+
+// disconnectWriter is a http.ResponseWriter detecting that the client went away from a failed write, which can
+// happen before the request context is cancelled. The first failed write cancels the context of FetchEvents, and
+// it and all later writes return ErrClientDisconnected.
+type disconnectWriter struct {
+	http.ResponseWriter
+	cancel       context.CancelFunc
+	disconnected bool
+}
+
+func (w *disconnectWriter) Write(p []byte) (int, error) {
+	if w.disconnected {
+		return 0, ErrClientDisconnected
+	}
+	n, err := w.ResponseWriter.Write(p)
+	if err != nil {
+		w.disconnected = true
+		w.cancel()
+		return n, errors.Wrap(ErrClientDisconnected, err.Error())
+	}
+	return n, nil
+}
+
+func (w *disconnectWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *disconnectWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// receiver returns r guarded by the writer: once a write has failed, events and checkpoints aren't even serialized,
+// and ErrClientDisconnected is returned right away, also to publishers ignoring the first one.
+func (w *disconnectWriter) receiver(r EventReceiver) EventReceiver {
+	return disconnectReceiver{writer: w, receiver: r}
+}
+
+type disconnectReceiver struct {
+	writer   *disconnectWriter
+	receiver EventReceiver
+}
+
+func (r disconnectReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if r.writer.disconnected {
+		return ErrClientDisconnected
+	}
+	return r.receiver.Event(partitionID, headers, data)
+}
+
+func (r disconnectReceiver) Checkpoint(partitionID int, cursor string) error {
+	if r.writer.disconnected {
+		return ErrClientDisconnected
+	}
+	return r.receiver.Checkpoint(partitionID, cursor)
+}
+
+func (r disconnectReceiver) CaughtUp(partitionID int, cursor string) error {
+	if r.writer.disconnected {
+		return ErrClientDisconnected
+	}
+	return SendCaughtUp(r.receiver, partitionID, cursor)
+}
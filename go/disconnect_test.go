@@ -0,0 +1,129 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// ignorantAPI is an API producing a huge page without watching its context, stopping only when the receiver
+// fails.
+type ignorantAPI struct {
+	*TestZeroEventHubAPI
+	result chan ignorantResult
+}
+
+type ignorantResult struct {
+	events int
+	err    error
+	ctxErr error
+}
+
+func (a ignorantAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	data := json.RawMessage(`{"padding":"` + strings.Repeat("x", 1000) + `"}`)
+	var err error
+	events := 0
+	for ; events < 1000000; events++ {
+		if err = r.Event(0, nil, data); err != nil {
+			break
+		}
+	}
+	a.result <- ignorantResult{events: events, err: err, ctxErr: ctx.Err()}
+	return err
+}
+
+func TestClientDisconnectStopsPublisher(t *testing.T) {
+	api := ignorantAPI{TestZeroEventHubAPI: NewTestZeroEventHubAPI(), result: make(chan ignorantResult, 1)}
+	log := &recordingLogger{}
+	// initialized before the handler and the test use it concurrently
+	log.init()
+	server := httptest.NewServer(Handler(log, api))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := NewClient(server.URL, 2).FetchEvents(ctx, []Cursor{{Cursor: "10"}}, 5, &cancellingReceiver{cancelAfter: 10, cancel: cancel})
+	require.Error(t, err)
+
+	select {
+	case result := <-api.result:
+		require.True(t, errors.Is(result.err, ErrClientDisconnected), "%v", result.err)
+		require.Error(t, result.ctxErr)
+		require.True(t, result.events < 100000, result.events)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the publisher didn't stop after the client disconnected")
+	}
+
+	// the disconnect isn't logged as a failure
+	// the handler logs after the publisher returns
+	waitForEntries := func() []logEntry {
+		for i := 0; i < 100; i++ {
+			if entries := log.Entries(); len(entries) > 0 {
+				return entries
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil
+	}
+	entries := waitForEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "debug", entries[0].Level)
+	require.Equal(t, "TestZeroEventHubAPI.client_disconnected", entries[0].Fields["event"])
+}
+
+// secondWriteFails is a http.ResponseWriter failing from the second write on.
+type secondWriteFails struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *secondWriteFails) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes >= 2 {
+		return 0, errors.New("connection reset by peer")
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+// persistentAPI is an API carrying on after the receiver fails, recording every error it gets.
+type persistentAPI struct {
+	*TestZeroEventHubAPI
+	errs   *[]error
+	ctxErr *error
+}
+
+func (a persistentAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	*a.errs = append(*a.errs,
+		r.Event(0, nil, json.RawMessage(`1`)),
+		r.Event(0, nil, json.RawMessage(`2`)),
+		r.Event(0, nil, json.RawMessage(`{"not json`)),
+		r.Checkpoint(0, "2"),
+		SendCaughtUp(r, 0, "2"),
+	)
+	*a.ctxErr = ctx.Err()
+	return nil
+}
+
+func TestWriteErrorStopsSerializing(t *testing.T) {
+	var errs []error
+	var ctxErr error
+	writer := &secondWriteFails{ResponseRecorder: httptest.NewRecorder()}
+	handler := Handler(nil, persistentAPI{NewTestZeroEventHubAPI(), &errs, &ctxErr})
+	handler.ServeHTTP(writer, httptest.NewRequest("GET", "/feed/v1?n=2&cursor0=_first&caughtup=1", nil))
+
+	require.Equal(t, 2, writer.writes)
+	require.Equal(t, `{"partition":0,"data":1}`+"\n", writer.Body.String())
+	require.Len(t, errs, 5)
+	require.NoError(t, errs[0])
+	require.True(t, errors.Is(errs[1], ErrClientDisconnected), "%v", errs[1])
+	require.Contains(t, errs[1].Error(), "connection reset by peer")
+	// later calls fail right away, without serializing
+	require.Equal(t, []error{ErrClientDisconnected, ErrClientDisconnected, ErrClientDisconnected}, errs[2:])
+	require.Equal(t, context.Canceled, ctxErr)
+}
@@ -0,0 +1,158 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// lineBuffer encodes lines of the NDJSON stream like json.Encoder does for Cursor and Envelope, reusing its
+// buffers between lines to save the allocations of reflection.
+type lineBuffer struct {
+	line    []byte
+	keys    []string
+	compact bytes.Buffer
+	escaped bytes.Buffer
+	// rawHTML leaves <, > and & unescaped, see NDJSONEventSerializer.SetEscapeHTML
+	rawHTML bool
+	// caughtUpLines marks caught-up checkpoints, see NDJSONEventSerializer.SetCaughtUpLines
+	caughtUpLines bool
+}
+
+// lineBuffers are reused by Handler between requests.
+var lineBuffers = sync.Pool{
+	New: func() interface{} {
+		return &lineBuffer{}
+	},
+}
+
+// maxPooledLineBytes keeps a single huge event from keeping a huge buffer in the pool.
+const maxPooledLineBytes = 64 * 1024
+
+// release resets the buffer and puts it back in lineBuffers, unless it has grown too large.
+func (b *lineBuffer) release() {
+	if cap(b.line) > maxPooledLineBytes || b.compact.Cap() > maxPooledLineBytes || b.escaped.Cap() > maxPooledLineBytes {
+		return
+	}
+	// don't keep the header keys of the last event alive
+	for i := range b.keys {
+		b.keys[i] = ""
+	}
+	b.keys = b.keys[:0]
+	b.line = b.line[:0]
+	b.compact.Reset()
+	b.escaped.Reset()
+	b.rawHTML = false
+	b.caughtUpLines = false
+	lineBuffers.Put(b)
+}
+
+// checkpoint returns the line of a Cursor; it is valid until the next call.
+func (b *lineBuffer) checkpoint(partitionID int, cursor string) []byte {
+	b.line = append(b.line[:0], `{"partition":`...)
+	b.line = strconv.AppendInt(b.line, int64(partitionID), 10)
+	b.line = append(b.line, `,"cursor":`...)
+	b.line = appendJSONString(b.line, cursor, !b.rawHTML)
+	b.line = append(b.line, '}', '\n')
+	return b.line
+}
+
+// event returns the line of an Envelope; it is valid until the next call. It fails if data isn't valid JSON.
+func (b *lineBuffer) event(partitionID int, headers map[string]string, data json.RawMessage) ([]byte, error) {
+	b.line = append(b.line[:0], `{"partition":`...)
+	b.line = strconv.AppendInt(b.line, int64(partitionID), 10)
+	if len(headers) > 0 {
+		b.keys = b.keys[:0]
+		for key := range headers {
+			b.keys = append(b.keys, key)
+		}
+		sort.Strings(b.keys)
+		b.line = append(b.line, `,"headers":{`...)
+		for i, key := range b.keys {
+			if i > 0 {
+				b.line = append(b.line, ',')
+			}
+			b.line = appendJSONString(b.line, key, !b.rawHTML)
+			b.line = append(b.line, ':')
+			b.line = appendJSONString(b.line, headers[key], !b.rawHTML)
+		}
+		b.line = append(b.line, '}')
+	}
+	if len(data) > 0 {
+		// like json.Encoder, the data is compacted and HTML characters in it are escaped
+		b.compact.Reset()
+		if err := json.Compact(&b.compact, data); err != nil {
+			return nil, err
+		}
+		compacted := b.compact.Bytes()
+		if !b.rawHTML && bytes.ContainsAny(compacted, "<>&\u2028\u2029") {
+			b.escaped.Reset()
+			json.HTMLEscape(&b.escaped, compacted)
+			compacted = b.escaped.Bytes()
+		} else if b.rawHTML && bytes.ContainsAny(compacted, "\u2028\u2029") {
+			// json.Encoder escapes these even without HTML escaping; valid JSON only has them in strings
+			compacted = bytes.ReplaceAll(compacted, []byte("\u2028"), []byte(`\u2028`))
+			compacted = bytes.ReplaceAll(compacted, []byte("\u2029"), []byte(`\u2029`))
+		}
+		b.line = append(b.line, `,"data":`...)
+		b.line = append(b.line, compacted...)
+	}
+	b.line = append(b.line, '}', '\n')
+	return b.line, nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string, escaped like json.Marshal does: with HTML characters escaped, unless
+// escapeHTML is false, and invalid UTF-8 replaced by U+FFFD.
+func appendJSONString(dst []byte, s string, escapeHTML bool) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && (!escapeHTML || c != '<' && c != '>' && c != '&') {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				dst = append(dst, '\\', c)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			dst = append(dst, s[start:i]...)
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+		case r == '\u2028' || r == '\u2029':
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+		default:
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
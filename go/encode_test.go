@@ -0,0 +1,155 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// encodeWithEncoder is the output NDJSONEventSerializer must match.
+func encodeWithEncoder(t *testing.T, item interface{}) string {
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(item))
+	return buf.String()
+}
+
+func TestLineBufferMatchesEncoder(t *testing.T) {
+	strings := []string{"", "plain", `"quoted" \ back`, "<html> & co", "tab\tnew\nline\rcr\bbs\fff", "\x00\x01\x1f\x7f",
+		"æøå 日本", "line para ", "invalid \xff utf-8 \xe2\x80", "emoji 😀"}
+	var b lineBuffer
+	for _, s := range strings {
+		require.Equal(t, encodeWithEncoder(t, Cursor{PartitionID: 3, Cursor: s}), string(b.checkpoint(3, s)), "%q", s)
+
+		headers := map[string]string{s: s, "a": "1", "z": s}
+		line, err := b.event(-1, headers, nil)
+		require.NoError(t, err)
+		require.Equal(t, encodeWithEncoder(t, Envelope{PartitionID: -1, Headers: headers}), string(line), "%q", s)
+
+		data, err := json.Marshal(map[string]interface{}{"s": s, "n": []int{1, 2}})
+		require.NoError(t, err)
+		line, err = b.event(0, nil, data)
+		require.NoError(t, err)
+		require.Equal(t, encodeWithEncoder(t, Envelope{Data: data}), string(line), "%q", s)
+	}
+
+	line, err := b.event(1, map[string]string{}, json.RawMessage(" {\n \"a\" : [ 1 , \"<b>\" ] }\n"))
+	require.NoError(t, err)
+	require.Equal(t, `{"partition":1,"data":{"a":[1,"\u003cb\u003e"]}}`+"\n", string(line))
+	_, err = b.event(1, nil, json.RawMessage(`{"unterminated":`))
+	require.Error(t, err)
+}
+
+func TestSerializerWithoutHTMLEscaping(t *testing.T) {
+	headers := map[string]string{}
+	for i := 0; i < 20; i++ {
+		headers[strconv.Itoa(i)] = "<" + strconv.Itoa(i) + ">"
+	}
+	data := json.RawMessage(`{"html":"<p>a & b</p>","line":"\u2028"}`)
+	serialize := func(escapeHTML bool) string {
+		var buf bytes.Buffer
+		serializer := NewNDJSONEventSerializer(&buf)
+		serializer.SetEscapeHTML(escapeHTML)
+		require.NoError(t, serializer.Event(0, headers, data))
+		require.NoError(t, serializer.Checkpoint(0, "a&b"))
+		return buf.String()
+	}
+
+	// the same output every time, as with json.Encoder without HTML escaping
+	raw := serialize(false)
+	for i := 0; i < 10; i++ {
+		require.Equal(t, raw, serialize(false))
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	require.NoError(t, encoder.Encode(Envelope{Headers: headers, Data: data}))
+	require.NoError(t, encoder.Encode(Cursor{Cursor: "a&b"}))
+	require.Equal(t, buf.String(), raw)
+
+	// the data round-trips byte for byte
+	var page EventPageRaw
+	require.NoError(t, DecodeStream(bytes.NewReader([]byte(raw)), func(line Line) error {
+		if line.Kind == LineEvent {
+			return page.Event(line.Envelope.PartitionID, line.Envelope.Headers, line.Envelope.Data)
+		}
+		return nil
+	}))
+	require.Equal(t, string(data), string(page.Events[0].Data))
+	require.NotEqual(t, raw, serialize(true))
+}
+
+func FuzzLineBuffer(f *testing.F) {
+	f.Add("key", "value", []byte(`{"ID":"1"}`))
+	f.Add("<&>", " ", []byte(` [1, "\xff"] `))
+	f.Add("", "", []byte(``))
+	f.Fuzz(func(t *testing.T, key, value string, data []byte) {
+		var b lineBuffer
+		require.Equal(t, encodeWithEncoder(t, Cursor{Cursor: value}), string(b.checkpoint(0, value)))
+
+		envelope := Envelope{Headers: map[string]string{key: value}, Data: data}
+		line, err := b.event(0, envelope.Headers, data)
+		var buf bytes.Buffer
+		if encoderErr := json.NewEncoder(&buf).Encode(envelope); encoderErr != nil {
+			require.Error(t, err)
+			return
+		}
+		require.NoError(t, err)
+		require.Equal(t, buf.String(), string(line))
+
+		// the same without HTML escaping
+		b.rawHTML = true
+		line, err = b.event(0, envelope.Headers, data)
+		require.NoError(t, err)
+		buf.Reset()
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		require.NoError(t, encoder.Encode(envelope))
+		require.Equal(t, buf.String(), string(line))
+	})
+}
+
+func TestHandlerPooledBuffersConcurrently(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for fetch := 0; fetch < 10; fetch++ {
+				from := worker*500 + fetch*10
+				var page EventPageSingleType[TestEvent]
+				headers := []string{"content-type"}
+				if worker%2 == 0 {
+					headers = nil
+				}
+				err := client.FetchEvents(context.Background(), []Cursor{{PartitionID: worker % 2, Cursor: strconv.Itoa(from)}}, 10, &page, headers...)
+				if err != nil {
+					errs <- err
+					return
+				}
+				for i, event := range page.Events {
+					if event.Data.Cursor != from+1+i || len(event.Headers) != len(headers) {
+						errs <- fmt.Errorf("worker %d: unexpected event %+v after %d", worker, event, from+i)
+						return
+					}
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
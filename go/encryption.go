@@ -0,0 +1,157 @@
+package zeroeventhub
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EncryptionKeyIDHeader is the event header holding the ID of the key the data of an event is encrypted with by
+// EncryptingReceiver. Consumers must request it in FetchEvents for DecryptingReceiver to decrypt the events.
+const EncryptionKeyIDHeader = "enc-key-id"
+
+var (
+	ErrEventNotEncrypted    = errors.New("event has no " + EncryptionKeyIDHeader + " header")
+	ErrUnknownEncryptionKey = errors.New("unknown encryption key")
+	ErrDecryptionFailed     = errors.New("event data could not be decrypted")
+)
+
+// KeyProvider returns the AES key (16, 24 or 32 bytes) of a key ID, for DecryptingReceiver.
+type KeyProvider interface {
+	Key(id string) ([]byte, error)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptingReceiver implements EventReceiver by encrypting the data of each event with AES-GCM before passing it
+// on, for feeds whose data must not be readable by proxies or archives of the responses. A publisher wraps the
+// receiver given to FetchEvents with it. The encrypted data is a JSON string with the base64 of the nonce and
+// ciphertext, and the key ID is added to the headers in EncryptionKeyIDHeader; other headers and checkpoints are
+// passed on in plaintext.
+type EncryptingReceiver struct {
+	receiver EventReceiver
+	keyID    string
+	aead     cipher.AEAD
+}
+
+// NewEncryptingReceiver returns an EncryptingReceiver encrypting with key, an AES key of 16, 24 or 32 bytes.
+func NewEncryptingReceiver(receiver EventReceiver, keyID string, key []byte) (*EncryptingReceiver, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptingReceiver{receiver: receiver, keyID: keyID, aead: aead}, nil
+}
+
+func (e *EncryptingReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	sealed := make([]byte, e.aead.NonceSize(), e.aead.NonceSize()+len(data)+e.aead.Overhead())
+	if _, err := rand.Read(sealed); err != nil {
+		return err
+	}
+	// the key ID is authenticated, so an event can't be passed off as encrypted with another key
+	sealed = e.aead.Seal(sealed, sealed, data, []byte(e.keyID))
+	encrypted, err := json.Marshal(sealed)
+	if err != nil {
+		return err
+	}
+	withKeyID := make(map[string]string, len(headers)+1)
+	for key, value := range headers {
+		withKeyID[key] = value
+	}
+	withKeyID[EncryptionKeyIDHeader] = e.keyID
+	return e.receiver.Event(partitionID, withKeyID, encrypted)
+}
+
+func (e *EncryptingReceiver) Checkpoint(partitionID int, cursor string) error {
+	return e.receiver.Checkpoint(partitionID, cursor)
+}
+
+func (e *EncryptingReceiver) PollAfter(d time.Duration) {
+	if receiver, ok := e.receiver.(PollIntervalReceiver); ok {
+		receiver.PollAfter(d)
+	}
+}
+
+// DecryptingReceiver implements EventReceiver by decrypting the data of events encrypted by EncryptingReceiver
+// before passing them on. Events that can't be decrypted, because they aren't encrypted, the key is unknown or
+// the data has been tampered with, are passed to deadLetter as received, with the reason (ErrEventNotEncrypted,
+// ErrUnknownEncryptionKey or ErrDecryptionFailed); if it returns nil the event is skipped. Without a deadLetter
+// the error is returned, failing the fetch.
+type DecryptingReceiver struct {
+	receiver   EventReceiver
+	keys       KeyProvider
+	deadLetter func(partitionID int, headers map[string]string, data json.RawMessage, err error) error
+	aeads      map[string]cipher.AEAD
+}
+
+// NewDecryptingReceiver returns a DecryptingReceiver getting the keys from keys. deadLetter is optional.
+func NewDecryptingReceiver(
+	receiver EventReceiver,
+	keys KeyProvider,
+	deadLetter func(partitionID int, headers map[string]string, data json.RawMessage, err error) error,
+) *DecryptingReceiver {
+	return &DecryptingReceiver{receiver: receiver, keys: keys, deadLetter: deadLetter, aeads: make(map[string]cipher.AEAD)}
+}
+
+func (d *DecryptingReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	plaintext, err := d.decrypt(headers, data)
+	if err != nil {
+		if d.deadLetter == nil {
+			return err
+		}
+		return d.deadLetter(partitionID, headers, data, err)
+	}
+	return d.receiver.Event(partitionID, headers, plaintext)
+}
+
+func (d *DecryptingReceiver) decrypt(headers map[string]string, data json.RawMessage) (json.RawMessage, error) {
+	keyID, ok := headers[EncryptionKeyIDHeader]
+	if !ok {
+		return nil, ErrEventNotEncrypted
+	}
+	aead, ok := d.aeads[keyID]
+	if !ok {
+		key, err := d.keys.Key(keyID)
+		if err != nil {
+			return nil, errors.Wrapf(ErrUnknownEncryptionKey, "%q: %v", keyID, err)
+		}
+		if aead, err = newAEAD(key); err != nil {
+			return nil, errors.Wrapf(ErrUnknownEncryptionKey, "%q: %v", keyID, err)
+		}
+		d.aeads[keyID] = aead
+	}
+	var sealed []byte
+	if err := json.Unmarshal(data, &sealed); err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(keyID))
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+func (d *DecryptingReceiver) Checkpoint(partitionID int, cursor string) error {
+	return d.receiver.Checkpoint(partitionID, cursor)
+}
+
+func (d *DecryptingReceiver) PollAfter(interval time.Duration) {
+	if receiver, ok := d.receiver.(PollIntervalReceiver); ok {
+		receiver.PollAfter(interval)
+	}
+}
+
+var (
+	_ EventReceiver = &EncryptingReceiver{}
+	_ EventReceiver = &DecryptingReceiver{}
+)
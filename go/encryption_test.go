@@ -0,0 +1,117 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type keyMap map[string][]byte
+
+func (k keyMap) Key(id string) ([]byte, error) {
+	key, ok := k[id]
+	if !ok {
+		return nil, errors.Errorf("no key %q", id)
+	}
+	return key, nil
+}
+
+var encryptionKeys = keyMap{
+	"k1": bytes.Repeat([]byte{1}, 32),
+	"k2": bytes.Repeat([]byte{2}, 16),
+}
+
+// encryptedAPI is a TestZeroEventHubAPI encrypting its events with the key keyID.
+type encryptedAPI struct {
+	*TestZeroEventHubAPI
+	keyID string
+}
+
+func (e encryptedAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	encrypting, err := NewEncryptingReceiver(r, e.keyID, encryptionKeys[e.keyID])
+	if err != nil {
+		return err
+	}
+	return e.TestZeroEventHubAPI.FetchEvents(ctx, cursors, pageSizeHint, encrypting, headers...)
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	api := NewTestZeroEventHubAPI()
+	server := httptest.NewServer(Handler(nil, encryptedAPI{TestZeroEventHubAPI: api, keyID: "k1"}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+	cursors := []Cursor{{PartitionID: 0, Cursor: "10"}, {PartitionID: 1, Cursor: "20"}}
+
+	var plain, raw EventPageRaw
+	require.NoError(t, api.FetchEvents(context.Background(), cursors, 5, &plain))
+	require.NoError(t, client.FetchEvents(context.Background(), cursors, 5, &raw, EncryptionKeyIDHeader, "foo"))
+	require.Len(t, raw.Events, 10)
+	for i, event := range raw.Events {
+		require.Equal(t, map[string]string{EncryptionKeyIDHeader: "k1", "foo": "bar"}, event.Headers)
+		require.NotContains(t, string(event.Data), "Version")
+		require.NotEqual(t, plain.Events[i].Data, event.Data)
+	}
+
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), cursors, 5, NewDecryptingReceiver(&page, encryptionKeys, nil), EncryptionKeyIDHeader))
+	require.Len(t, page.Events, 10)
+	for i, event := range page.Events {
+		require.JSONEq(t, string(plain.Events[i].Data), string(event.Data))
+	}
+	require.Equal(t, plain.Cursors, page.Cursors)
+}
+
+func TestDecryptionFailures(t *testing.T) {
+	var encrypted EventPageRaw
+	for _, keyID := range []string{"k1", "k2"} {
+		encrypting, err := NewEncryptingReceiver(&encrypted, keyID, encryptionKeys[keyID])
+		require.NoError(t, err)
+		require.NoError(t, encrypting.Event(0, map[string]string{"type": "person"}, json.RawMessage(`{"ssn":"01019012345"}`)))
+	}
+	_, err := NewEncryptingReceiver(&encrypted, "short", []byte("short"))
+	require.Error(t, err)
+
+	tampered := append([]byte(nil), encrypted.Events[0].Data...)
+	// flip a bit inside the base64 of the sealed data
+	tampered[10] ^= 1
+	otherKeyID := map[string]string{"type": "person", EncryptionKeyIDHeader: "k2"}
+	events := []Envelope{
+		encrypted.Events[0],
+		{Headers: map[string]string{"type": "person", EncryptionKeyIDHeader: "unknown"}, Data: encrypted.Events[0].Data},
+		{Headers: encrypted.Events[0].Headers, Data: tampered},
+		{Headers: encrypted.Events[0].Headers, Data: json.RawMessage(`"c2hvcnQ="`)},
+		{Headers: otherKeyID, Data: encrypted.Events[0].Data},
+		{Headers: map[string]string{"type": "person"}, Data: json.RawMessage(`{"ssn":"01019012345"}`)},
+		encrypted.Events[1],
+	}
+	expected := []error{nil, ErrUnknownEncryptionKey, ErrDecryptionFailed, ErrDecryptionFailed, ErrDecryptionFailed, ErrEventNotEncrypted, nil}
+
+	var page EventPageRaw
+	var deadLetters []error
+	receiver := NewDecryptingReceiver(&page, encryptionKeys, func(partitionID int, headers map[string]string, data json.RawMessage, err error) error {
+		deadLetters = append(deadLetters, err)
+		return nil
+	})
+	for i, event := range events {
+		require.NoError(t, receiver.Event(0, event.Headers, event.Data))
+		if expected[i] != nil {
+			require.True(t, errors.Is(deadLetters[len(deadLetters)-1], expected[i]), "event %d: %v", i, deadLetters[len(deadLetters)-1])
+		}
+	}
+	require.NoError(t, receiver.Checkpoint(0, "1"))
+	require.Len(t, deadLetters, 5)
+	require.Len(t, page.Events, 2)
+	for _, event := range page.Events {
+		require.JSONEq(t, `{"ssn":"01019012345"}`, string(event.Data))
+	}
+	require.Equal(t, map[int]string{0: "1"}, page.Cursors)
+
+	// without a dead letter callback, the error fails the fetch
+	err = NewDecryptingReceiver(&page, encryptionKeys, nil).Event(0, events[2].Headers, events[2].Data)
+	require.True(t, errors.Is(err, ErrDecryptionFailed))
+}
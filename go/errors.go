@@ -2,8 +2,13 @@ package zeroeventhub
 
 import (
 	"net/http"
+
+	"github.com/pkg/errors"
 )
 
+// ErrResponseTooLarge is returned by Client.FetchEvents when the response exceeds the limit set by WithMaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response too large")
+
 // StatusError represents HTTP-friendly error (message + HTTP code).
 type StatusError interface {
 	error
@@ -29,9 +34,25 @@ func (ae APIError) Status() int {
 	return ae.code
 }
 
+// ResponseError is returned by Client when the server responds with a non-2xx status code.
+type ResponseError struct {
+	StatusCode int
+	Body       string
+}
+
+func (re *ResponseError) Error() string {
+	if re.Body == "\n" || re.Body == "" {
+		return "empty response body"
+	}
+	return "unexpected response body: " + re.Body
+}
+
 var (
 	ErrHandshakePartitionCountMissing  = NewAPIError("handshake error: partition count missing", http.StatusBadRequest)
 	ErrHandshakePartitionCountMismatch = NewAPIError("handshake error: partition count mismatch", http.StatusBadRequest)
 	ErrCursorsMissing                  = NewAPIError("cursors are missing", http.StatusBadRequest)
 	ErrPartitionDoesntExist            = NewAPIError("partition doesn't exist", http.StatusBadRequest)
+	ErrIllegalDirection                = NewAPIError("illegal direction", http.StatusBadRequest)
+	ErrIllegalPageSizeHint             = NewAPIError("illegal page size hint; expected a non-negative integer", http.StatusBadRequest)
+	ErrEmptyCursor                     = NewAPIError("empty cursor; use _first to start at the beginning", http.StatusBadRequest)
 )
@@ -0,0 +1,80 @@
+package zeroeventhub
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultFailbackInterval is how long a Client with fallback URLs stays on a fallback before probing the primary URL again.
+const DefaultFailbackInterval = time.Minute
+
+// endpoints keeps track of which of the primary and fallback URLs is currently healthy.
+type endpoints struct {
+	mu               sync.Mutex
+	urls             []string // urls[0] is the primary URL
+	current          int
+	failedOverAt     time.Time
+	failbackInterval time.Duration
+	now              func() time.Time
+}
+
+// candidates returns the indices of the URLs in the order they should be tried: starting at the current
+// healthy one, or at the primary URL if it's time to probe whether it's back.
+func (e *endpoints) candidates() []int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	start := e.current
+	if start != 0 && e.now().Sub(e.failedOverAt) >= e.failbackInterval {
+		start = 0
+	}
+	result := make([]int, len(e.urls))
+	for i := range result {
+		result[i] = (start + i) % len(e.urls)
+	}
+	return result
+}
+
+// succeeded records that a request to urls[i] succeeded.
+func (e *endpoints) succeeded(i int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if i != 0 && (i != e.current || e.now().Sub(e.failedOverAt) >= e.failbackInterval) {
+		// failed over, or the primary is still unhealthy after probing it
+		e.failedOverAt = e.now()
+	}
+	e.current = i
+}
+
+// WithFallbackURLs is a Client method for providing URLs serving the same feed as the primary URL, e.g. in other regions.
+// When a request fails with a connection error or a 5xx response, it is retried against the next URL, and the URL that
+// worked is used for subsequent requests. The primary URL is probed again after the failback interval.
+// Requests that failed after events were passed to the receiver are not retried.
+//
+// The returned Client and copies made from it share the record of which URL is healthy.
+func (c Client) WithFallbackURLs(urls ...string) (r Client) {
+	r = c
+	failbackInterval := DefaultFailbackInterval
+	if c.endpoints != nil {
+		failbackInterval = c.endpoints.failbackInterval
+	}
+	r.endpoints = &endpoints{
+		urls:             append([]string{c.url}, urls...),
+		failbackInterval: failbackInterval,
+		now:              time.Now,
+	}
+	return
+}
+
+// WithFailbackInterval is a Client method for setting how long to stay on a fallback URL before probing
+// the primary URL again. It has to be called after WithFallbackURLs.
+func (c Client) WithFailbackInterval(d time.Duration) (r Client) {
+	r = c
+	if r.endpoints != nil {
+		r.endpoints = &endpoints{
+			urls:             c.endpoints.urls,
+			failbackInterval: d,
+			now:              c.endpoints.now,
+		}
+	}
+	return
+}
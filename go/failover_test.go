@@ -0,0 +1,115 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingHandler counts the requests served, and fails them with 500 while failing is set.
+type countingHandler struct {
+	handler  http.Handler
+	requests int32
+	failing  int32
+}
+
+func (h *countingHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	atomic.AddInt32(&h.requests, 1)
+	if atomic.LoadInt32(&h.failing) != 0 {
+		http.Error(writer, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	h.handler.ServeHTTP(writer, request)
+}
+
+func TestFailoverWhenServerGoesAway(t *testing.T) {
+	api := NewTestZeroEventHubAPI()
+	primary := &countingHandler{handler: Handler(nil, api)}
+	fallback := &countingHandler{handler: Handler(nil, api)}
+	primaryServer := httptest.NewServer(primary)
+	fallbackServer := httptest.NewServer(fallback)
+	defer fallbackServer.Close()
+
+	client := NewClient(primaryServer.URL, 2).WithFallbackURLs(fallbackServer.URL)
+	cursor := FirstCursor
+	var cursors []int
+	for i := 0; i < 5; i++ {
+		if i == 2 {
+			primaryServer.Close()
+		}
+		var page EventPageSingleType[TestEvent]
+		err := client.FetchEvents(context.Background(), []Cursor{{Cursor: cursor}}, 100, &page)
+		require.NoError(t, err)
+		for _, e := range page.Events {
+			cursors = append(cursors, e.Data.Cursor)
+		}
+		cursor = page.Cursors[0]
+	}
+
+	require.Len(t, cursors, 500)
+	for i, c := range cursors {
+		require.Equal(t, i, c)
+	}
+	require.Equal(t, int32(2), atomic.LoadInt32(&primary.requests))
+	require.Equal(t, int32(3), atomic.LoadInt32(&fallback.requests))
+}
+
+func TestFailoverOnServerErrorAndFailback(t *testing.T) {
+	api := NewTestZeroEventHubAPI()
+	primary := &countingHandler{handler: Handler(nil, api)}
+	fallback := &countingHandler{handler: Handler(nil, api)}
+	primaryServer := httptest.NewServer(primary)
+	defer primaryServer.Close()
+	fallbackServer := httptest.NewServer(fallback)
+	defer fallbackServer.Close()
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := NewClient(primaryServer.URL, 2).
+		WithFallbackURLs(fallbackServer.URL).
+		WithFailbackInterval(time.Minute)
+	client.endpoints.now = func() time.Time { return now }
+	fetch := func() {
+		var page EventPageRaw
+		require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: LastCursor}}, DefaultPageSize, &page))
+		require.Len(t, page.Events, 1)
+	}
+	requireRequests := func(expectedPrimary, expectedFallback int32) {
+		require.Equal(t, expectedPrimary, atomic.LoadInt32(&primary.requests))
+		require.Equal(t, expectedFallback, atomic.LoadInt32(&fallback.requests))
+	}
+
+	atomic.StoreInt32(&primary.failing, 1)
+	fetch()
+	requireRequests(1, 1)
+	// stays on the fallback without trying the primary
+	fetch()
+	requireRequests(1, 2)
+
+	// probing the primary after the failback interval while it's still failing
+	now = now.Add(time.Minute)
+	fetch()
+	requireRequests(2, 3)
+	now = now.Add(30 * time.Second)
+	fetch()
+	requireRequests(2, 4)
+
+	// failing back once the primary is healthy again
+	atomic.StoreInt32(&primary.failing, 0)
+	now = now.Add(30 * time.Second)
+	fetch()
+	requireRequests(3, 4)
+	fetch()
+	requireRequests(4, 4)
+
+	// 4xx is the caller's fault, so there's no failover
+	var page EventPageRaw
+	err := NewClient(primaryServer.URL, 1).WithFallbackURLs(fallbackServer.URL).
+		FetchEvents(context.Background(), []Cursor{{Cursor: LastCursor}}, DefaultPageSize, &page)
+	require.EqualError(t, err, "unexpected response body: handshake error: partition count mismatch\n")
+	requireRequests(5, 4)
+}
@@ -0,0 +1,159 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoProgress is returned by FetchAllEvents when a page has events but no checkpoint after them, so fetching again
+// would return the same page.
+var ErrNoProgress = errors.New("page with events but no checkpoint; the cursor doesn't advance")
+
+// FetchAllOptions configures FetchAllEvents.
+type FetchAllOptions struct {
+	// Prefetch fetches the next page while the events of the previous one are passed to the receiver, so that the
+	// network isn't idle while a slow receiver, e.g. one writing to a database, works. Pages are then read into
+	// memory before they are passed on; at most one page is fetched ahead.
+	Prefetch bool
+}
+
+// FetchAllEvents fetches the pages of a partition, each from the last checkpoint of the previous one, starting at
+// cursor, until a page has no events or ends with a caught-up checkpoint, passing every event and checkpoint to r.
+// A Client only gets caught-up checkpoints if it asks for them with WithCaughtUp.
+// On error, r may have got part of a page, so only the checkpoints received should be trusted.
+func FetchAllEvents(ctx context.Context, fetcher EventFetcher, cursor Cursor, pageSizeHint int, r EventReceiver, opts FetchAllOptions, headers ...string) error {
+	if opts.Prefetch {
+		return fetchAllPrefetching(ctx, fetcher, cursor, pageSizeHint, r, headers...)
+	}
+	for {
+		page := progressReceiver{receiver: r, cursor: cursor.Cursor}
+		if err := fetcher.FetchEvents(ctx, []Cursor{cursor}, pageSizeHint, &page, headers...); err != nil {
+			return err
+		}
+		if page.events == 0 || page.caughtUp {
+			return nil
+		}
+		if page.cursor == cursor.Cursor {
+			return ErrNoProgress
+		}
+		cursor.Cursor = page.cursor
+	}
+}
+
+// prefetchedPage is the result of fetching a page into a bufferedPage.
+type prefetchedPage struct {
+	page *bufferedPage
+	err  error
+}
+
+func fetchAllPrefetching(ctx context.Context, fetcher EventFetcher, cursor Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	fetch := func(cursor Cursor) (*bufferedPage, error) {
+		page := &bufferedPage{cursor: cursor.Cursor}
+		return page, fetcher.FetchEvents(ctx, []Cursor{cursor}, pageSizeHint, page, headers...)
+	}
+
+	page, err := fetch(cursor)
+	for {
+		if err != nil {
+			return err
+		}
+		if page.events == 0 || page.caughtUp {
+			return page.replay(r)
+		}
+		// the next page can only be fetched once the last checkpoint of this one is known
+		var next chan prefetchedPage
+		progressed := page.cursor != cursor.Cursor
+		if progressed {
+			cursor.Cursor = page.cursor
+			next = make(chan prefetchedPage, 1)
+			go func(cursor Cursor) {
+				page, err := fetch(cursor)
+				next <- prefetchedPage{page: page, err: err}
+			}(cursor)
+		}
+		if err := page.replay(r); err != nil {
+			// stop the prefetch; its response body is drained when the request returns
+			cancel()
+			if next != nil {
+				<-next
+			}
+			return err
+		}
+		if !progressed {
+			return ErrNoProgress
+		}
+		result := <-next
+		page, err = result.page, result.err
+	}
+}
+
+// progressReceiver passes calls on to receiver, counting the events and keeping the last checkpoint, and whether it
+// was caught up.
+type progressReceiver struct {
+	receiver EventReceiver
+	events   int
+	cursor   string
+	caughtUp bool
+}
+
+func (p *progressReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	p.events++
+	return p.receiver.Event(partitionID, headers, data)
+}
+
+func (p *progressReceiver) Checkpoint(partitionID int, cursor string) error {
+	p.cursor = cursor
+	return p.receiver.Checkpoint(partitionID, cursor)
+}
+
+// bufferedCall is an event, or a checkpoint if checkpoint is set, caught up if caughtUp is set.
+type bufferedCall struct {
+	checkpoint  bool
+	caughtUp    bool
+	partitionID int
+	headers     map[string]string
+	data        json.RawMessage
+	cursor      string
+}
+
+// bufferedPage keeps the calls for a page, to pass them on later with replay.
+type bufferedPage struct {
+	calls    []bufferedCall
+	events   int
+	cursor   string
+	caughtUp bool
+}
+
+func (p *bufferedPage) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	p.events++
+	// the fetcher may reuse the data buffer
+	p.calls = append(p.calls, bufferedCall{partitionID: partitionID, headers: headers, data: append(json.RawMessage(nil), data...)})
+	return nil
+}
+
+func (p *bufferedPage) Checkpoint(partitionID int, cursor string) error {
+	p.cursor = cursor
+	p.calls = append(p.calls, bufferedCall{checkpoint: true, partitionID: partitionID, cursor: cursor})
+	return nil
+}
+
+func (p *bufferedPage) replay(r EventReceiver) error {
+	for _, call := range p.calls {
+		var err error
+		if call.caughtUp {
+			err = SendCaughtUp(r, call.partitionID, call.cursor)
+		} else if call.checkpoint {
+			err = r.Checkpoint(call.partitionID, call.cursor)
+		} else {
+			err = r.Event(call.partitionID, call.headers, call.data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedAPI serves eventCount events in partition 0, pageSize per page, after a latency, with the index of an event
+// as its cursor.
+type pagedAPI struct {
+	eventCount, pageSize int
+	latency              time.Duration
+	// fetching is the number of fetches running
+	fetching *int32
+}
+
+func (p pagedAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	atomic.AddInt32(p.fetching, 1)
+	defer atomic.AddInt32(p.fetching, -1)
+	select {
+	case <-time.After(p.latency):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	start := 0
+	if cursors[0].Cursor != FirstCursor {
+		after, err := strconv.Atoi(cursors[0].Cursor)
+		if err != nil {
+			return err
+		}
+		start = after + 1
+	}
+	for i := start; i < p.eventCount && i < start+p.pageSize; i++ {
+		if err := r.Event(0, nil, json.RawMessage(strconv.Itoa(i))); err != nil {
+			return err
+		}
+		if err := r.Checkpoint(0, strconv.Itoa(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slowReceiver records the data of the events and the checkpoints, taking delay for every event.
+type slowReceiver struct {
+	delay time.Duration
+	calls []string
+	// failAt fails the event with this data, if set
+	failAt string
+}
+
+func (s *slowReceiver) Event(_ int, _ map[string]string, data json.RawMessage) error {
+	if string(data) == s.failAt {
+		return errors.New("receiver failed")
+	}
+	time.Sleep(s.delay)
+	s.calls = append(s.calls, "event "+string(data))
+	return nil
+}
+
+func (s *slowReceiver) Checkpoint(_ int, cursor string) error {
+	s.calls = append(s.calls, "checkpoint "+cursor)
+	return nil
+}
+
+func TestFetchAllEventsPrefetch(t *testing.T) {
+	api := pagedAPI{eventCount: 25, pageSize: 5, latency: 40 * time.Millisecond, fetching: new(int32)}
+	fetchAll := func(prefetch bool) (*slowReceiver, time.Duration) {
+		r := &slowReceiver{delay: 8 * time.Millisecond}
+		start := time.Now()
+		require.NoError(t, FetchAllEvents(context.Background(), api, Cursor{Cursor: FirstCursor}, 5, r, FetchAllOptions{Prefetch: prefetch}))
+		return r, time.Since(start)
+	}
+	sequential, sequentialTime := fetchAll(false)
+	prefetched, prefetchedTime := fetchAll(true)
+
+	require.Len(t, sequential.calls, 50)
+	require.Equal(t, "event 0", sequential.calls[0])
+	require.Equal(t, "checkpoint 24", sequential.calls[49])
+	require.Equal(t, sequential.calls, prefetched.calls)
+	// 6 fetches and 5 pages of 40ms each one after the other, against the pages overlapping the next fetch
+	require.True(t, prefetchedTime < sequentialTime*8/10, "prefetched in %s, sequentially in %s", prefetchedTime, sequentialTime)
+}
+
+func TestFetchAllEventsReceiverError(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		api := pagedAPI{eventCount: 25, pageSize: 5, latency: 10 * time.Millisecond, fetching: new(int32)}
+		r := &slowReceiver{failAt: "7"}
+		err := FetchAllEvents(context.Background(), api, Cursor{Cursor: FirstCursor}, 5, r, FetchAllOptions{Prefetch: prefetch})
+		require.EqualError(t, err, "receiver failed")
+		require.Equal(t, "checkpoint 6", r.calls[len(r.calls)-1])
+		// the prefetch has been stopped
+		require.Equal(t, int32(0), atomic.LoadInt32(api.fetching))
+	}
+}
+
+func TestFetchAllEventsNoProgress(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		// events after the last checkpoint are passed on, then fetching stops
+		var page EventPageRaw
+		err := FetchAllEvents(context.Background(), eventsWithoutCheckpointAPI{}, Cursor{Cursor: "3"}, 5, &page, FetchAllOptions{Prefetch: prefetch})
+		require.Equal(t, ErrNoProgress, err)
+		require.Len(t, page.Events, 1)
+	}
+}
+
+// eventsWithoutCheckpointAPI returns an event without a checkpoint.
+type eventsWithoutCheckpointAPI struct{}
+
+func (eventsWithoutCheckpointAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	return r.Event(0, nil, json.RawMessage(`{}`))
+}
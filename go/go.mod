@@ -4,13 +4,11 @@ go 1.18
 
 require (
 	github.com/gorilla/mux v1.8.0
-	github.com/sirupsen/logrus v1.8.1
-	github.com/stretchr/testify v1.3.0
 	github.com/pkg/errors v0.9.1
+	github.com/stretchr/testify v1.3.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
 )
@@ -0,0 +1,19 @@
+// Command generate writes the golden NDJSON fixtures of package golden to a directory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vippsas/zeroeventhub/go/golden"
+)
+
+func main() {
+	dir := flag.String("dir", "testdata/golden", "directory to write the golden files to")
+	flag.Parse()
+	if err := golden.Generate(*dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
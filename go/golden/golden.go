@@ -0,0 +1,203 @@
+// Package golden generates golden NDJSON response files together with the expected result of parsing them,
+// so that clients in other languages can be tested against the same edge cases as the Go client.
+//
+// For every case, Generate writes <name>.ndjson (the response body served by the feed) and <name>.expected.json
+// (the calls a client is expected to make to its event receiver, in order). Regenerate the committed fixtures
+// in go/testdata/golden with:
+//
+//	go run ./golden/generate -dir testdata/golden
+//
+// The output is deterministic.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// Call is an expected call to the event receiver: either an event or a checkpoint.
+type Call struct {
+	Kind      string            `json:"kind"`
+	Partition int               `json:"partition"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Data      json.RawMessage   `json:"data,omitempty"`
+	Cursor    string            `json:"cursor,omitempty"`
+}
+
+// Event returns an expected event call.
+func Event(partition int, headers map[string]string, data string) Call {
+	return Call{Kind: "event", Partition: partition, Headers: headers, Data: json.RawMessage(data)}
+}
+
+// Checkpoint returns an expected checkpoint call.
+func Checkpoint(partition int, cursor string) Call {
+	return Call{Kind: "checkpoint", Partition: partition, Cursor: cursor}
+}
+
+// Case is a single golden response body and the calls expected from parsing it.
+type Case struct {
+	Name        string
+	Description string
+	Response    []byte
+	Expected    []Call
+}
+
+type expectedDocument struct {
+	Description string `json:"description"`
+	Calls       []Call `json:"calls"`
+}
+
+// serialize writes the calls using the server's serializer.
+func serialize(calls ...Call) []byte {
+	var buf bytes.Buffer
+	serializer := zeroeventhub.NewNDJSONEventSerializer(&buf)
+	for _, call := range calls {
+		var err error
+		if call.Kind == "event" {
+			err = serializer.Event(call.Partition, call.Headers, call.Data)
+		} else {
+			err = serializer.Checkpoint(call.Partition, call.Cursor)
+		}
+		if err != nil {
+			panic(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// Cases returns all the golden cases.
+func Cases() []Case {
+	basic := []Call{
+		Event(0, nil, `{"ID":"1","Version":0}`),
+		Checkpoint(0, "1"),
+		Event(0, nil, `{"ID":"2","Version":1}`),
+		Checkpoint(0, "2"),
+	}
+	hugeData := `{"payload":"` + strings.Repeat("0123456789abcdef", 8*1024) + `"}`
+	unicode := []Call{
+		Event(0, map[string]string{"name": "Blåbær <&>  "}, `{"escaped":"\u00e6\u00f8\u00e5 \ud83d\ude00","raw":"æøå 😀","control":"\t\n\"\\"}`),
+		Checkpoint(0, "æøå-1"),
+	}
+	multiplePartitions := []Call{
+		Event(0, nil, `{"n":1}`),
+		Checkpoint(0, "10"),
+		Event(1, nil, `{"n":2}`),
+		Checkpoint(1, "20"),
+		Event(0, nil, `{"n":3}`),
+		Event(1, nil, `{"n":4}`),
+		Checkpoint(1, "21"),
+		Checkpoint(0, "11"),
+	}
+	headers := []Call{
+		Event(0, map[string]string{"content-type": "application/json", "foo": "bar"}, `{}`),
+		Checkpoint(0, "1"),
+	}
+	checkpointsOnly := []Call{
+		Checkpoint(0, "100"),
+		Checkpoint(1, "200"),
+		Checkpoint(0, "101"),
+	}
+
+	return []Case{
+		{
+			Name:        "basic",
+			Description: "events followed by checkpoints, with a trailing newline",
+			Response:    serialize(basic...),
+			Expected:    basic,
+		},
+		{
+			Name:        "missing_trailing_newline",
+			Description: "the last line isn't terminated by a newline",
+			Response:    bytes.TrimSuffix(serialize(basic...), []byte("\n")),
+			Expected:    basic,
+		},
+		{
+			Name:        "blank_lines",
+			Description: "blank lines, whitespace-only lines and CRLF line endings are skipped",
+			Response: []byte("\n" +
+				`{"partition":0,"data":{"ID":"1","Version":0}}` + "\r\n" +
+				"   \n" +
+				"\t\n" +
+				`  {"partition":0,"cursor":"1"}  ` + "\n" +
+				"\n\n" +
+				`{"partition":0,"data":{"ID":"2","Version":1}}` + "\n" +
+				`{"partition":0,"cursor":"2"}` + "\n\n"),
+			Expected: basic,
+		},
+		{
+			Name:        "huge_event",
+			Description: "an event larger than common default line buffer sizes (64KiB)",
+			Response:    serialize(Event(0, nil, hugeData), Checkpoint(0, "1")),
+			Expected:    []Call{Event(0, nil, hugeData), Checkpoint(0, "1")},
+		},
+		{
+			Name:        "unicode",
+			Description: "escaped and raw unicode in data, headers and cursors; data must be passed on as-is",
+			Response:    serialize(unicode...),
+			Expected:    unicode,
+		},
+		{
+			Name:        "empty_headers",
+			Description: "absent, null and empty headers objects are all equivalent to no headers",
+			Response: []byte(`{"partition":0,"data":1}` + "\n" +
+				`{"partition":0,"headers":null,"data":2}` + "\n" +
+				`{"partition":0,"headers":{},"data":3}` + "\n" +
+				`{"partition":0,"cursor":"3"}` + "\n"),
+			Expected: []Call{
+				Event(0, nil, `1`),
+				Event(0, nil, `2`),
+				Event(0, nil, `3`),
+				Checkpoint(0, "3"),
+			},
+		},
+		{
+			Name:        "headers",
+			Description: "events with the headers selected by the headers query parameter",
+			Response:    serialize(headers...),
+			Expected:    headers,
+		},
+		{
+			Name:        "multiple_partitions",
+			Description: "events and checkpoints of several partitions interleaved",
+			Response:    serialize(multiplePartitions...),
+			Expected:    multiplePartitions,
+		},
+		{
+			Name:        "checkpoints_only",
+			Description: "a page with checkpoints but no events",
+			Response:    serialize(checkpointsOnly...),
+			Expected:    checkpointsOnly,
+		},
+		{
+			Name:        "empty",
+			Description: "an empty page",
+			Response:    []byte{},
+			Expected:    []Call{},
+		},
+	}
+}
+
+// Generate writes the golden files of all cases to dir.
+func Generate(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, c := range Cases() {
+		if err := os.WriteFile(filepath.Join(dir, c.Name+".ndjson"), c.Response, 0o644); err != nil {
+			return err
+		}
+		expected, err := json.MarshalIndent(expectedDocument{Description: c.Description, Calls: c.Expected}, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, c.Name+".expected.json"), append(expected, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestGoldenFilesUpToDate checks that the committed fixtures match the generator, and that it is deterministic.
+func TestGoldenFilesUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, Generate(dir))
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	committed, err := os.ReadDir(filepath.Join("..", "testdata", "golden"))
+	require.NoError(t, err)
+	require.Equal(t, len(committed), len(files), "run `go run ./golden/generate -dir testdata/golden` in the go directory")
+	for _, file := range files {
+		generated, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		require.NoError(t, err)
+		expected, err := os.ReadFile(filepath.Join("..", "testdata", "golden", file.Name()))
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(generated), "%s is outdated: run `go run ./golden/generate -dir testdata/golden` in the go directory", file.Name())
+	}
+}
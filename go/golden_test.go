@@ -0,0 +1,98 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// goldenCall mirrors golden.Call; the golden package can't be imported here as it imports this package.
+type goldenCall struct {
+	Kind      string            `json:"kind"`
+	Partition int               `json:"partition"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Data      json.RawMessage   `json:"data,omitempty"`
+	Cursor    string            `json:"cursor,omitempty"`
+}
+
+type goldenRecorder struct {
+	calls []goldenCall
+}
+
+func (r *goldenRecorder) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if len(headers) == 0 {
+		headers = nil
+	}
+	r.calls = append(r.calls, goldenCall{Kind: "event", Partition: partitionID, Headers: headers, Data: data})
+	return nil
+}
+
+func (r *goldenRecorder) Checkpoint(partitionID int, cursor string) error {
+	r.calls = append(r.calls, goldenCall{Kind: "checkpoint", Partition: partitionID, Cursor: cursor})
+	return nil
+}
+
+// goldenCase is a golden response and the calls expected from parsing it.
+type goldenCase struct {
+	name     string
+	response []byte
+	expected []goldenCall
+}
+
+func readGoldenCases(t *testing.T) []goldenCase {
+	files, err := filepath.Glob(filepath.Join("testdata", "golden", "*.ndjson"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	var cases []goldenCase
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".ndjson")
+		response, err := os.ReadFile(file)
+		require.NoError(t, err)
+		expectedFile, err := os.ReadFile(filepath.Join("testdata", "golden", name+".expected.json"))
+		require.NoError(t, err)
+		var expected struct {
+			Calls []goldenCall `json:"calls"`
+		}
+		require.NoError(t, json.Unmarshal(expectedFile, &expected))
+		cases = append(cases, goldenCase{name: name, response: response, expected: expected.Calls})
+	}
+	return cases
+}
+
+func requireGoldenCalls(t *testing.T, expected, calls []goldenCall) {
+	require.Len(t, calls, len(expected))
+	for i, call := range calls {
+		expectedCall := expected[i]
+		// a call without data in the golden file must have none
+		if expectedCall.Data == nil {
+			require.Empty(t, call.Data, "call %d", i)
+		} else {
+			require.JSONEq(t, string(expectedCall.Data), string(call.Data), "call %d", i)
+		}
+		call.Data, expectedCall.Data = nil, nil
+		require.Equal(t, expectedCall, call, "call %d", i)
+	}
+}
+
+func TestClientGoldenFiles(t *testing.T) {
+	for _, c := range readGoldenCases(t) {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				_, _ = writer.Write(c.response)
+			}))
+			defer server.Close()
+			var recorder goldenRecorder
+			err := NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{PartitionID: 0, Cursor: FirstCursor}}, DefaultPageSize, &recorder, All)
+			require.NoError(t, err)
+			requireGoldenCalls(t, c.expected, recorder.calls)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package zeroeventhubgrpc
+
+import (
+	"context"
+	"io"
+
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+	"google.golang.org/grpc"
+)
+
+// Client is a gRPC implementation of the zeroeventhub.EventFetcher interface.
+type Client struct {
+	client         ZeroEventHubClient
+	partitionCount int
+}
+
+var _ zeroeventhub.EventFetcher = Client{}
+
+// NewClient is a constructor for the Client.
+func NewClient(conn grpc.ClientConnInterface, partitionCount int) Client {
+	return Client{
+		client:         NewZeroEventHubClient(conn),
+		partitionCount: partitionCount,
+	}
+}
+
+// FetchEvents streams a page of events from the server and passes them to the receiver.
+func (c Client) FetchEvents(ctx context.Context, cursors []zeroeventhub.Cursor, pageSizeHint int, r zeroeventhub.EventReceiver, headers ...string) error {
+	if len(cursors) == 0 {
+		return zeroeventhub.ErrCursorsMissing
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req := &FetchEventsRequest{
+		PartitionCount: int32(c.partitionCount),
+		PageSizeHint:   int32(pageSizeHint),
+		Headers:        headers,
+	}
+	for _, cursor := range cursors {
+		req.Cursors = append(req.Cursors, &Cursor{PartitionId: int32(cursor.PartitionID), Cursor: cursor.Cursor})
+	}
+	stream, err := c.client.FetchEvents(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		l, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch line := l.Line.(type) {
+		case *Line_Checkpoint:
+			if err := r.Checkpoint(int(line.Checkpoint.PartitionId), line.Checkpoint.Cursor); err != nil {
+				return err
+			}
+		case *Line_Event:
+			if err := r.Event(int(line.Event.PartitionId), line.Event.Headers, line.Event.Data); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,21 @@
+module github.com/vippsas/zeroeventhub/go/grpc
+
+go 1.25.0
+
+require (
+	github.com/stretchr/testify v1.3.0
+	github.com/vippsas/zeroeventhub/go v0.0.0-20261016210015-7c830b856199
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gorilla/mux v1.8.0 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+)
@@ -0,0 +1,257 @@
+package zeroeventhubgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testAPI struct{}
+
+func (testAPI) GetName() string {
+	return "testAPI"
+}
+
+func (testAPI) GetPartitionCount() int {
+	return 2
+}
+
+// FetchEvents serves 10 events per partition, with the event's index as cursor.
+func (testAPI) FetchEvents(_ context.Context, cursors []zeroeventhub.Cursor, pageSizeHint int, r zeroeventhub.EventReceiver, headers ...string) error {
+	if pageSizeHint == zeroeventhub.DefaultPageSize {
+		pageSizeHint = 5
+	}
+	for _, cursor := range cursors {
+		if cursor.PartitionID < 0 || cursor.PartitionID > 1 {
+			return zeroeventhub.ErrPartitionDoesntExist
+		}
+		var after int
+		switch cursor.Cursor {
+		case zeroeventhub.FirstCursor:
+			after = -1
+		case zeroeventhub.LastCursor:
+			after = 8
+		default:
+			var err error
+			if after, err = strconv.Atoi(cursor.Cursor); err != nil {
+				return err
+			}
+		}
+		var h map[string]string
+		if len(headers) > 0 {
+			h = map[string]string{"content-type": "application/json"}
+		}
+		for i := after + 1; i < 10 && i <= after+pageSizeHint; i++ {
+			data, _ := json.Marshal(fmt.Sprintf("event-%d-%d", cursor.PartitionID, i))
+			if err := r.Event(cursor.PartitionID, h, data); err != nil {
+				return err
+			}
+			if err := r.Checkpoint(cursor.PartitionID, strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newTestConn(t *testing.T) *grpc.ClientConn {
+	return newTestConnWithServer(t, func(server *grpc.Server) {
+		RegisterServer(server, nil, testAPI{})
+	})
+}
+
+// newTestConnWithServer is newTestConn with the service registered by register.
+func newTestConnWithServer(t *testing.T, register func(server *grpc.Server)) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	register(server)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func TestFetchEvents(t *testing.T) {
+	client := NewClient(newTestConn(t), 2)
+	var page zeroeventhub.EventPageSingleType[string]
+	err := client.FetchEvents(context.Background(), []zeroeventhub.Cursor{
+		{PartitionID: 0, Cursor: zeroeventhub.FirstCursor},
+		{PartitionID: 1, Cursor: "7"},
+	}, 3, &page, "content-type")
+	require.NoError(t, err)
+	require.Equal(t, map[int]string{0: "2", 1: "9"}, page.Cursors)
+	var data []string
+	for _, e := range page.Events {
+		require.Equal(t, map[string]string{"content-type": "application/json"}, e.Headers)
+		data = append(data, e.Data)
+	}
+	require.Equal(t, []string{"event-0-0", "event-0-1", "event-0-2", "event-1-8", "event-1-9"}, data)
+
+	page = zeroeventhub.EventPageSingleType[string]{}
+	err = client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: zeroeventhub.LastCursor}}, zeroeventhub.DefaultPageSize, &page)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	require.Nil(t, page.Events[0].Headers)
+	require.Equal(t, "event-0-9", page.Events[0].Data)
+}
+
+func TestFetchEventsErrors(t *testing.T) {
+	conn := newTestConn(t)
+	var page zeroeventhub.EventPageRaw
+
+	err := NewClient(conn, 1).FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: zeroeventhub.FirstCursor}}, 1, &page)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, zeroeventhub.ErrHandshakePartitionCountMismatch.Error(), status.Convert(err).Message())
+
+	err = NewClient(conn, 2).FetchEvents(context.Background(), []zeroeventhub.Cursor{{PartitionID: 5, Cursor: zeroeventhub.FirstCursor}}, 1, &page)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, zeroeventhub.ErrPartitionDoesntExist.Error(), status.Convert(err).Message())
+
+	err = NewClient(conn, 2).FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: "qwerty"}}, 1, &page)
+	require.Equal(t, codes.Internal, status.Code(err))
+
+	err = NewClient(conn, 2).FetchEvents(context.Background(), nil, 1, &page)
+	require.Equal(t, zeroeventhub.ErrCursorsMissing, err)
+	require.Empty(t, page.Events)
+
+	// requests the Client doesn't send, but other clients might
+	for _, c := range []struct {
+		req *FetchEventsRequest
+		err error
+	}{
+		{&FetchEventsRequest{PartitionCount: 2}, zeroeventhub.ErrCursorsMissing},
+		{&FetchEventsRequest{PartitionCount: 2, Cursors: []*Cursor{{Cursor: "1"}}, PageSizeHint: -1}, zeroeventhub.ErrIllegalPageSizeHint},
+		{&FetchEventsRequest{PartitionCount: 2, Cursors: []*Cursor{{Cursor: "1"}, {PartitionId: 1}}}, zeroeventhub.ErrEmptyCursor},
+	} {
+		stream, err := NewZeroEventHubClient(conn).FetchEvents(context.Background(), c.req)
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err), c.err.Error())
+		require.Equal(t, c.err.Error(), status.Convert(err).Message())
+	}
+}
+
+// recordingLogger is a Logger recording the level and event field of the entries logged through it, logging only from
+// a minimum level.
+type recordingLogger struct {
+	mu      *sync.Mutex
+	entries *[]string
+	min     zeroeventhub.LogLevel
+	event   string
+}
+
+func newRecordingLogger(min zeroeventhub.LogLevel) recordingLogger {
+	return recordingLogger{mu: &sync.Mutex{}, entries: &[]string{}, min: min}
+}
+
+func (l recordingLogger) WithField(key string, value interface{}) zeroeventhub.Logger {
+	if key == "event" {
+		l.event = value.(string)
+	}
+	return l
+}
+
+func (l recordingLogger) WithError(error) zeroeventhub.Logger             { return l }
+func (l recordingLogger) WithContext(context.Context) zeroeventhub.Logger { return l }
+func (l recordingLogger) Enabled(level zeroeventhub.LogLevel) bool        { return level >= l.min }
+
+func (l recordingLogger) log(level string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	*l.entries = append(*l.entries, level+" "+l.event)
+}
+
+func (l recordingLogger) Debug() { l.log("debug") }
+func (l recordingLogger) Info()  { l.log("info") }
+func (l recordingLogger) Warn()  { l.log("warn") }
+func (l recordingLogger) Error() { l.log("error") }
+
+func (l recordingLogger) Entries() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), *l.entries...)
+}
+
+func TestServerRequestLogOptions(t *testing.T) {
+	log := newRecordingLogger(zeroeventhub.LevelInfo)
+	client := NewClient(newTestConnWithServer(t, func(server *grpc.Server) {
+		RegisterServerWithOptions(server, log, testAPI{}, ServerOptions{RequestLogLevel: zeroeventhub.LevelInfo, RequestLogSampling: 3})
+	}), 2)
+
+	var page zeroeventhub.EventPageRaw
+	for i := 0; i < 7; i++ {
+		require.NoError(t, client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: "5"}}, 2, &page))
+	}
+	// errors are logged whatever the sampling, and only once
+	require.Error(t, client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: "qwerty"}}, 2, &page))
+	require.Equal(t, []string{"info testAPI", "info testAPI", "info testAPI", "info testAPI.fetch_events_error"}, log.Entries())
+
+	// by default requests are logged at debug, which the logger skips
+	log = newRecordingLogger(zeroeventhub.LevelInfo)
+	client = NewClient(newTestConnWithServer(t, func(server *grpc.Server) {
+		RegisterServer(server, log, testAPI{})
+	}), 2)
+	require.NoError(t, client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: "5"}}, 2, &page))
+	require.Empty(t, log.Entries())
+}
+
+// errorAPI is a testAPI failing every fetch with err.
+type errorAPI struct {
+	testAPI
+	err error
+}
+
+func (api errorAPI) FetchEvents(context.Context, []zeroeventhub.Cursor, int, zeroeventhub.EventReceiver, ...string) error {
+	return api.err
+}
+
+func TestFetchEventsStatusCodes(t *testing.T) {
+	for _, c := range []struct {
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{zeroeventhub.ErrIllegalPageSizeHint, codes.InvalidArgument, zeroeventhub.ErrIllegalPageSizeHint.Error()},
+		{zeroeventhub.ErrBearerTokenInvalid, codes.Unauthenticated, zeroeventhub.ErrBearerTokenInvalid.Error()},
+		{zeroeventhub.ErrIPNotAllowed, codes.PermissionDenied, zeroeventhub.ErrIPNotAllowed.Error()},
+		{zeroeventhub.ErrEventNotFound, codes.NotFound, zeroeventhub.ErrEventNotFound.Error()},
+		{zeroeventhub.ErrRateLimited, codes.ResourceExhausted, zeroeventhub.ErrRateLimited.Error()},
+		// wrapped errors are passed on as well
+		{fmt.Errorf("reading cursor: %w", zeroeventhub.ErrEmptyCursor), codes.InvalidArgument, zeroeventhub.ErrEmptyCursor.Error()},
+		{fmt.Errorf("checking token: %w", zeroeventhub.ErrBearerTokenMissing), codes.Unauthenticated, zeroeventhub.ErrBearerTokenMissing.Error()},
+		{zeroeventhub.NewAPIError("unavailable", http.StatusServiceUnavailable), codes.Internal, "Internal server error"},
+	} {
+		api := errorAPI{err: c.err}
+		client := NewClient(newTestConnWithServer(t, func(server *grpc.Server) {
+			RegisterServer(server, nil, api)
+		}), 2)
+		var page zeroeventhub.EventPageRaw
+		err := client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: zeroeventhub.FirstCursor}}, 1, &page)
+		require.Equal(t, c.code, status.Code(err), c.err.Error())
+		require.Equal(t, c.message, status.Convert(err).Message())
+	}
+}
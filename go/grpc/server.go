@@ -0,0 +1,164 @@
+// Package zeroeventhubgrpc implements the ZeroEventHub protocol over gRPC.
+//
+// The service is defined in zeroeventhub.proto and mirrors the HTTP/NDJSON transport: a server-streaming
+// FetchEvents call carries the same handshake (partition count), cursors, page size hint and headers selection, and
+// streams back the events and checkpoints. Event data is carried as the same JSON as in the NDJSON lines.
+// It lives in a separate module to keep the gRPC dependency out of the core package.
+package zeroeventhubgrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative zeroeventhub.proto
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServerOptions configures RegisterServerWithOptions, like zeroeventhub.HandlerOptions does the HTTP handler.
+type ServerOptions struct {
+	// RequestLogLevel is the level successful requests are logged at; LevelDebug by default.
+	RequestLogLevel zeroeventhub.LogLevel
+	// RequestLogSampling logs only 1 in RequestLogSampling successful requests; 0 or 1 logs all of them.
+	// Failed requests are always logged.
+	RequestLogSampling int
+}
+
+// server implements the generated ZeroEventHubServer by serving an API.
+type server struct {
+	UnimplementedZeroEventHubServer
+	logger       zeroeventhub.Logger
+	api          zeroeventhub.API
+	opts         ServerOptions
+	requestCount atomic.Uint64
+}
+
+// RegisterServer registers api as the ZeroEventHub gRPC service on s; it's the gRPC counterpart of zeroeventhub.Handler.
+func RegisterServer(s grpc.ServiceRegistrar, logger zeroeventhub.Logger, api zeroeventhub.API) {
+	RegisterServerWithOptions(s, logger, api, ServerOptions{})
+}
+
+// RegisterServerWithOptions is RegisterServer with control over the logging of requests; see
+// zeroeventhub.HandlerWithOptions.
+func RegisterServerWithOptions(s grpc.ServiceRegistrar, logger zeroeventhub.Logger, api zeroeventhub.API, opts ServerOptions) {
+	if logger == nil {
+		logger = zeroeventhub.NopLogger()
+	}
+	RegisterZeroEventHubServer(s, &server{logger: logger, api: api, opts: opts})
+}
+
+func (s *server) FetchEvents(req *FetchEventsRequest, stream grpc.ServerStreamingServer[Line]) error {
+	if req.PartitionCount == 0 {
+		return status.Error(codes.InvalidArgument, zeroeventhub.ErrHandshakePartitionCountMissing.Error())
+	}
+	if int(req.PartitionCount) != s.api.GetPartitionCount() {
+		return status.Error(codes.InvalidArgument, zeroeventhub.ErrHandshakePartitionCountMismatch.Error())
+	}
+	if len(req.Cursors) == 0 {
+		return status.Error(codes.InvalidArgument, zeroeventhub.ErrCursorsMissing.Error())
+	}
+	if req.PageSizeHint < 0 {
+		return status.Error(codes.InvalidArgument, zeroeventhub.ErrIllegalPageSizeHint.Error())
+	}
+	cursors := make([]zeroeventhub.Cursor, 0, len(req.Cursors))
+	for _, cursor := range req.Cursors {
+		// refused as by zeroeventhub.Handler, since publishers disagree on what an empty cursor means
+		if cursor.Cursor == "" {
+			return status.Error(codes.InvalidArgument, zeroeventhub.ErrEmptyCursor.Error())
+		}
+		cursors = append(cursors, zeroeventhub.Cursor{PartitionID: int(cursor.PartitionId), Cursor: cursor.Cursor})
+	}
+	err := s.api.FetchEvents(stream.Context(), cursors, int(req.PageSizeHint), streamSerializer{stream: stream, requested: req.Headers}, req.Headers...)
+	if err != nil {
+		// a StatusError with a 4xx status, e.g. for a malformed cursor, is passed on to the client
+		var statusErr zeroeventhub.StatusError
+		if errors.As(err, &statusErr) && statusErr.Status()/100 == 4 {
+			return status.Error(statusCode(statusErr.Status()), statusErr.Error())
+		}
+		s.logger.WithField("event", s.api.GetName()+".fetch_events_error").WithError(err).Info()
+		return status.Error(codes.Internal, "Internal server error")
+	}
+	// the fields are only built for entries that are logged
+	if logEnabled(s.logger, s.opts.RequestLogLevel) && s.sampled() {
+		logAt(s.logger.
+			WithField("event", s.api.GetName()).
+			WithField("PartitionCount", req.PartitionCount).
+			WithField("Cursors", cursors).
+			WithField("PageSizeHint", req.PageSizeHint).
+			WithField("Headers", req.Headers), s.opts.RequestLogLevel)
+	}
+	return nil
+}
+
+// statusCode returns the gRPC status code for the 4xx HTTP status of a zeroeventhub.StatusError.
+func statusCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	default:
+		return codes.InvalidArgument
+	}
+}
+
+// sampled tells whether to log the current request, with ServerOptions.RequestLogSampling.
+func (s *server) sampled() bool {
+	if s.opts.RequestLogSampling <= 1 {
+		return true
+	}
+	return (s.requestCount.Add(1)-1)%uint64(s.opts.RequestLogSampling) == 0
+}
+
+// logEnabled tells whether logger logs entries at level.
+func logEnabled(logger zeroeventhub.Logger, level zeroeventhub.LogLevel) bool {
+	if enabler, ok := logger.(zeroeventhub.LevelEnabler); ok {
+		return enabler.Enabled(level)
+	}
+	return true
+}
+
+// logAt logs the entry at the given level.
+func logAt(logger zeroeventhub.Logger, level zeroeventhub.LogLevel) {
+	switch level {
+	case zeroeventhub.LevelDebug:
+		logger.Debug()
+	case zeroeventhub.LevelInfo:
+		logger.Info()
+	case zeroeventhub.LevelWarn:
+		logger.Warn()
+	default:
+		logger.Error()
+	}
+}
+
+// streamSerializer implements EventReceiver by sending the events and checkpoints on a gRPC stream.
+// Like zeroeventhub.Handler, it only sends the requested headers.
+type streamSerializer struct {
+	stream    grpc.ServerStreamingServer[Line]
+	requested []string
+}
+
+func (s streamSerializer) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	return s.stream.Send(&Line{Line: &Line_Event{Event: &Event{
+		PartitionId: int32(partitionID),
+		Headers:     zeroeventhub.FilterHeaders(s.requested, headers),
+		Data:        data,
+	}}})
+}
+
+func (s streamSerializer) Checkpoint(partitionID int, cursor string) error {
+	return s.stream.Send(&Line{Line: &Line_Checkpoint{Checkpoint: &Cursor{
+		PartitionId: int32(partitionID),
+		Cursor:      cursor,
+	}}})
+}
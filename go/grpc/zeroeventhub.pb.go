@@ -0,0 +1,381 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: zeroeventhub.proto
+
+package zeroeventhubgrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// FetchEventsRequest carries the same parameters as the query string of the HTTP transport.
+type FetchEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// partition_count is the handshake, the number of partitions the client expects.
+	PartitionCount int32     `protobuf:"varint,1,opt,name=partition_count,json=partitionCount,proto3" json:"partition_count,omitempty"`
+	Cursors        []*Cursor `protobuf:"bytes,2,rep,name=cursors,proto3" json:"cursors,omitempty"`
+	// page_size_hint is the number of events the server should aim to return; 0 lets the server decide.
+	PageSizeHint int32 `protobuf:"varint,3,opt,name=page_size_hint,json=pageSizeHint,proto3" json:"page_size_hint,omitempty"`
+	// headers are the event headers to return; "_all" returns all of them.
+	Headers       []string `protobuf:"bytes,4,rep,name=headers,proto3" json:"headers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchEventsRequest) Reset() {
+	*x = FetchEventsRequest{}
+	mi := &file_zeroeventhub_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchEventsRequest) ProtoMessage() {}
+
+func (x *FetchEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_zeroeventhub_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchEventsRequest.ProtoReflect.Descriptor instead.
+func (*FetchEventsRequest) Descriptor() ([]byte, []int) {
+	return file_zeroeventhub_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FetchEventsRequest) GetPartitionCount() int32 {
+	if x != nil {
+		return x.PartitionCount
+	}
+	return 0
+}
+
+func (x *FetchEventsRequest) GetCursors() []*Cursor {
+	if x != nil {
+		return x.Cursors
+	}
+	return nil
+}
+
+func (x *FetchEventsRequest) GetPageSizeHint() int32 {
+	if x != nil {
+		return x.PageSizeHint
+	}
+	return 0
+}
+
+func (x *FetchEventsRequest) GetHeaders() []string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+type Cursor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartitionId   int32                  `protobuf:"varint,1,opt,name=partition_id,json=partitionId,proto3" json:"partition_id,omitempty"`
+	Cursor        string                 `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Cursor) Reset() {
+	*x = Cursor{}
+	mi := &file_zeroeventhub_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Cursor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cursor) ProtoMessage() {}
+
+func (x *Cursor) ProtoReflect() protoreflect.Message {
+	mi := &file_zeroeventhub_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cursor.ProtoReflect.Descriptor instead.
+func (*Cursor) Descriptor() ([]byte, []int) {
+	return file_zeroeventhub_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Cursor) GetPartitionId() int32 {
+	if x != nil {
+		return x.PartitionId
+	}
+	return 0
+}
+
+func (x *Cursor) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type Event struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	PartitionId int32                  `protobuf:"varint,1,opt,name=partition_id,json=partitionId,proto3" json:"partition_id,omitempty"`
+	Headers     map[string]string      `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// data is the JSON encoded event, as in the data field of an NDJSON line.
+	Data          []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_zeroeventhub_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_zeroeventhub_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_zeroeventhub_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Event) GetPartitionId() int32 {
+	if x != nil {
+		return x.PartitionId
+	}
+	return 0
+}
+
+func (x *Event) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *Event) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Line is a single streamed message: either an event or a checkpoint.
+type Line struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Line:
+	//
+	//	*Line_Event
+	//	*Line_Checkpoint
+	Line          isLine_Line `protobuf_oneof:"line"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Line) Reset() {
+	*x = Line{}
+	mi := &file_zeroeventhub_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Line) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Line) ProtoMessage() {}
+
+func (x *Line) ProtoReflect() protoreflect.Message {
+	mi := &file_zeroeventhub_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Line.ProtoReflect.Descriptor instead.
+func (*Line) Descriptor() ([]byte, []int) {
+	return file_zeroeventhub_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Line) GetLine() isLine_Line {
+	if x != nil {
+		return x.Line
+	}
+	return nil
+}
+
+func (x *Line) GetEvent() *Event {
+	if x != nil {
+		if x, ok := x.Line.(*Line_Event); ok {
+			return x.Event
+		}
+	}
+	return nil
+}
+
+func (x *Line) GetCheckpoint() *Cursor {
+	if x != nil {
+		if x, ok := x.Line.(*Line_Checkpoint); ok {
+			return x.Checkpoint
+		}
+	}
+	return nil
+}
+
+type isLine_Line interface {
+	isLine_Line()
+}
+
+type Line_Event struct {
+	Event *Event `protobuf:"bytes,1,opt,name=event,proto3,oneof"`
+}
+
+type Line_Checkpoint struct {
+	Checkpoint *Cursor `protobuf:"bytes,2,opt,name=checkpoint,proto3,oneof"`
+}
+
+func (*Line_Event) isLine_Line() {}
+
+func (*Line_Checkpoint) isLine_Line() {}
+
+var File_zeroeventhub_proto protoreflect.FileDescriptor
+
+const file_zeroeventhub_proto_rawDesc = "" +
+	"\n" +
+	"\x12zeroeventhub.proto\x12\fzeroeventhub\"\xad\x01\n" +
+	"\x12FetchEventsRequest\x12'\n" +
+	"\x0fpartition_count\x18\x01 \x01(\x05R\x0epartitionCount\x12.\n" +
+	"\acursors\x18\x02 \x03(\v2\x14.zeroeventhub.CursorR\acursors\x12$\n" +
+	"\x0epage_size_hint\x18\x03 \x01(\x05R\fpageSizeHint\x12\x18\n" +
+	"\aheaders\x18\x04 \x03(\tR\aheaders\"C\n" +
+	"\x06Cursor\x12!\n" +
+	"\fpartition_id\x18\x01 \x01(\x05R\vpartitionId\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\"\xb6\x01\n" +
+	"\x05Event\x12!\n" +
+	"\fpartition_id\x18\x01 \x01(\x05R\vpartitionId\x12:\n" +
+	"\aheaders\x18\x02 \x03(\v2 .zeroeventhub.Event.HeadersEntryR\aheaders\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"s\n" +
+	"\x04Line\x12+\n" +
+	"\x05event\x18\x01 \x01(\v2\x13.zeroeventhub.EventH\x00R\x05event\x126\n" +
+	"\n" +
+	"checkpoint\x18\x02 \x01(\v2\x14.zeroeventhub.CursorH\x00R\n" +
+	"checkpointB\x06\n" +
+	"\x04line2U\n" +
+	"\fZeroEventHub\x12E\n" +
+	"\vFetchEvents\x12 .zeroeventhub.FetchEventsRequest\x1a\x12.zeroeventhub.Line0\x01B:Z8github.com/vippsas/zeroeventhub/go/grpc;zeroeventhubgrpcb\x06proto3"
+
+var (
+	file_zeroeventhub_proto_rawDescOnce sync.Once
+	file_zeroeventhub_proto_rawDescData []byte
+)
+
+func file_zeroeventhub_proto_rawDescGZIP() []byte {
+	file_zeroeventhub_proto_rawDescOnce.Do(func() {
+		file_zeroeventhub_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_zeroeventhub_proto_rawDesc), len(file_zeroeventhub_proto_rawDesc)))
+	})
+	return file_zeroeventhub_proto_rawDescData
+}
+
+var file_zeroeventhub_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_zeroeventhub_proto_goTypes = []any{
+	(*FetchEventsRequest)(nil), // 0: zeroeventhub.FetchEventsRequest
+	(*Cursor)(nil),             // 1: zeroeventhub.Cursor
+	(*Event)(nil),              // 2: zeroeventhub.Event
+	(*Line)(nil),               // 3: zeroeventhub.Line
+	nil,                        // 4: zeroeventhub.Event.HeadersEntry
+}
+var file_zeroeventhub_proto_depIdxs = []int32{
+	1, // 0: zeroeventhub.FetchEventsRequest.cursors:type_name -> zeroeventhub.Cursor
+	4, // 1: zeroeventhub.Event.headers:type_name -> zeroeventhub.Event.HeadersEntry
+	2, // 2: zeroeventhub.Line.event:type_name -> zeroeventhub.Event
+	1, // 3: zeroeventhub.Line.checkpoint:type_name -> zeroeventhub.Cursor
+	0, // 4: zeroeventhub.ZeroEventHub.FetchEvents:input_type -> zeroeventhub.FetchEventsRequest
+	3, // 5: zeroeventhub.ZeroEventHub.FetchEvents:output_type -> zeroeventhub.Line
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_zeroeventhub_proto_init() }
+func file_zeroeventhub_proto_init() {
+	if File_zeroeventhub_proto != nil {
+		return
+	}
+	file_zeroeventhub_proto_msgTypes[3].OneofWrappers = []any{
+		(*Line_Event)(nil),
+		(*Line_Checkpoint)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_zeroeventhub_proto_rawDesc), len(file_zeroeventhub_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_zeroeventhub_proto_goTypes,
+		DependencyIndexes: file_zeroeventhub_proto_depIdxs,
+		MessageInfos:      file_zeroeventhub_proto_msgTypes,
+	}.Build()
+	File_zeroeventhub_proto = out.File
+	file_zeroeventhub_proto_goTypes = nil
+	file_zeroeventhub_proto_depIdxs = nil
+}
@@ -0,0 +1,130 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: zeroeventhub.proto
+
+package zeroeventhubgrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ZeroEventHub_FetchEvents_FullMethodName = "/zeroeventhub.ZeroEventHub/FetchEvents"
+)
+
+// ZeroEventHubClient is the client API for ZeroEventHub service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ZeroEventHub is the gRPC counterpart of the HTTP/NDJSON transport described in SPEC.md.
+type ZeroEventHubClient interface {
+	// FetchEvents streams a page of events and checkpoints, like GET /feed/v1.
+	FetchEvents(ctx context.Context, in *FetchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Line], error)
+}
+
+type zeroEventHubClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewZeroEventHubClient(cc grpc.ClientConnInterface) ZeroEventHubClient {
+	return &zeroEventHubClient{cc}
+}
+
+func (c *zeroEventHubClient) FetchEvents(ctx context.Context, in *FetchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Line], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ZeroEventHub_ServiceDesc.Streams[0], ZeroEventHub_FetchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FetchEventsRequest, Line]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ZeroEventHub_FetchEventsClient = grpc.ServerStreamingClient[Line]
+
+// ZeroEventHubServer is the server API for ZeroEventHub service.
+// All implementations must embed UnimplementedZeroEventHubServer
+// for forward compatibility.
+//
+// ZeroEventHub is the gRPC counterpart of the HTTP/NDJSON transport described in SPEC.md.
+type ZeroEventHubServer interface {
+	// FetchEvents streams a page of events and checkpoints, like GET /feed/v1.
+	FetchEvents(*FetchEventsRequest, grpc.ServerStreamingServer[Line]) error
+	mustEmbedUnimplementedZeroEventHubServer()
+}
+
+// UnimplementedZeroEventHubServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedZeroEventHubServer struct{}
+
+func (UnimplementedZeroEventHubServer) FetchEvents(*FetchEventsRequest, grpc.ServerStreamingServer[Line]) error {
+	return status.Error(codes.Unimplemented, "method FetchEvents not implemented")
+}
+func (UnimplementedZeroEventHubServer) mustEmbedUnimplementedZeroEventHubServer() {}
+func (UnimplementedZeroEventHubServer) testEmbeddedByValue()                      {}
+
+// UnsafeZeroEventHubServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ZeroEventHubServer will
+// result in compilation errors.
+type UnsafeZeroEventHubServer interface {
+	mustEmbedUnimplementedZeroEventHubServer()
+}
+
+func RegisterZeroEventHubServer(s grpc.ServiceRegistrar, srv ZeroEventHubServer) {
+	// If the following call panics, it indicates UnimplementedZeroEventHubServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ZeroEventHub_ServiceDesc, srv)
+}
+
+func _ZeroEventHub_FetchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FetchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ZeroEventHubServer).FetchEvents(m, &grpc.GenericServerStream[FetchEventsRequest, Line]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ZeroEventHub_FetchEventsServer = grpc.ServerStreamingServer[Line]
+
+// ZeroEventHub_ServiceDesc is the grpc.ServiceDesc for ZeroEventHub service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ZeroEventHub_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "zeroeventhub.ZeroEventHub",
+	HandlerType: (*ZeroEventHubServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FetchEvents",
+			Handler:       _ZeroEventHub_FetchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "zeroeventhub.proto",
+}
@@ -0,0 +1,51 @@
+package zeroeventhub
+
+import "encoding/json"
+
+// FilterHeaders returns the subset of all headers that was requested by the client, honoring the special value All.
+// It returns nil when none of the requested headers are present.
+func FilterHeaders(requested []string, all map[string]string) map[string]string {
+	if len(requested) == 0 || len(all) == 0 {
+		return nil
+	}
+	var result map[string]string
+	for _, header := range requested {
+		if header == All {
+			return all
+		}
+		if value, ok := all[header]; ok {
+			if result == nil {
+				result = make(map[string]string, len(requested))
+			}
+			result[header] = value
+		}
+	}
+	return result
+}
+
+// HeaderFilter implements EventReceiver by passing on only the requested headers of each event to Receiver,
+// so that publishers can return all headers of an event and leave the selection to it. Handler applies it
+// to the headers requested by the client.
+//
+// If Allowed is set, it returns the allowlist of headers for an event, typically depending on an event type
+// header, and only the requested headers in the allowlist are passed on; requesting All then means all allowed
+// headers. The allowlist may contain All to allow every header of the event.
+type HeaderFilter struct {
+	Receiver  EventReceiver
+	Requested []string
+	Allowed   func(headers map[string]string) []string
+}
+
+func (f HeaderFilter) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	filtered := FilterHeaders(f.Requested, headers)
+	if f.Allowed != nil && filtered != nil {
+		filtered = FilterHeaders(f.Allowed(headers), filtered)
+	}
+	return f.Receiver.Event(partitionID, filtered, data)
+}
+
+func (f HeaderFilter) Checkpoint(partitionID int, cursor string) error {
+	return f.Receiver.Checkpoint(partitionID, cursor)
+}
+
+var _ EventReceiver = HeaderFilter{}
@@ -0,0 +1,116 @@
+package zeroeventhub
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterHeaders(t *testing.T) {
+	all := map[string]string{
+		"content-type": "application/json",
+		"foo":          "bar",
+		"baz":          "qux",
+	}
+	tests := []struct {
+		name      string
+		requested []string
+		all       map[string]string
+		expected  map[string]string
+	}{
+		{
+			name:     "nothing requested",
+			all:      all,
+			expected: nil,
+		},
+		{
+			name:      "subset",
+			requested: []string{"foo", "baz", "missing"},
+			all:       all,
+			expected:  map[string]string{"foo": "bar", "baz": "qux"},
+		},
+		{
+			name:      "none of the requested are present",
+			requested: []string{"missing"},
+			all:       all,
+			expected:  nil,
+		},
+		{
+			name:      "all",
+			requested: []string{"foo", All},
+			all:       all,
+			expected:  all,
+		},
+		{
+			name:      "no headers available",
+			requested: []string{All},
+			expected:  nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, FilterHeaders(test.requested, test.all))
+		})
+	}
+}
+
+func TestHeaderFilter(t *testing.T) {
+	headers := map[string]string{"type": "payment"}
+	for i := 1; i < 10; i++ {
+		headers[fmt.Sprintf("h%d", i)] = fmt.Sprintf("v%d", i)
+	}
+	require.Len(t, headers, 10)
+	allowed := func(headers map[string]string) []string {
+		if headers["type"] == "payment" {
+			return []string{"type", "h1", "h2", "h3"}
+		}
+		return []string{All}
+	}
+	refund := map[string]string{"type": "refund", "h1": "v1", "h9": "v9"}
+
+	tests := []struct {
+		name      string
+		requested []string
+		allowed   func(headers map[string]string) []string
+		expected  []map[string]string
+	}{
+		{
+			name:      "two of ten",
+			requested: []string{"h2", "h7"},
+			expected:  []map[string]string{{"h2": "v2", "h7": "v7"}, nil},
+		},
+		{
+			name:      "two of ten with allowlist",
+			requested: []string{"h2", "h7", "h9"},
+			allowed:   allowed,
+			expected:  []map[string]string{{"h2": "v2"}, {"h9": "v9"}},
+		},
+		{
+			name:      "all with allowlist",
+			requested: []string{All},
+			allowed:   allowed,
+			expected:  []map[string]string{{"type": "payment", "h1": "v1", "h2": "v2", "h3": "v3"}, refund},
+		},
+		{
+			name:     "nothing requested",
+			allowed:  allowed,
+			expected: []map[string]string{nil, nil},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var page EventPageRaw
+			filter := HeaderFilter{Receiver: &page, Requested: test.requested, Allowed: test.allowed}
+			require.NoError(t, filter.Event(0, headers, json.RawMessage(`1`)))
+			require.NoError(t, filter.Event(0, refund, json.RawMessage(`2`)))
+			require.NoError(t, filter.Checkpoint(0, "2"))
+			require.Len(t, page.Events, 2)
+			require.Equal(t, test.expected[0], page.Events[0].Headers)
+			require.Equal(t, test.expected[1], page.Events[1].Headers)
+			require.Equal(t, map[int]string{0: "2"}, page.Cursors)
+		})
+	}
+	require.Len(t, headers, 10)
+}
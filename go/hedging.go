@@ -0,0 +1,99 @@
+package zeroeventhub
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithHedging is a Client method for enabling request hedging: if a request hasn't returned response headers
+// within delay, an identical second request is issued and whichever responds first is used, cancelling the other.
+// Reading events is idempotent, so this is safe, and only the winning response body is ever passed on to the
+// EventReceiver. onHedge (optional) is called every time a second request is issued, e.g. to record a metric.
+// Pass delay 0 to disable hedging.
+func (c Client) WithHedging(delay time.Duration, onHedge func()) (r Client) {
+	r = c
+	r.hedgeDelay = delay
+	r.onHedge = onHedge
+	return
+}
+
+type hedgeResult struct {
+	res     *http.Response
+	err     error
+	attempt int
+}
+
+// cancelOnClose is a response body cancelling the context of its request once closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// do sends the request, hedging it if enabled.
+func (c Client) do(req *http.Request) (*http.Response, error) {
+	req = c.traceConn(req)
+	if c.hedgeDelay <= 0 {
+		if err := c.hmacSigner.sign(req); err != nil {
+			return nil, err
+		}
+		return c.send(req)
+	}
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	send := func() {
+		ctx, cancel := context.WithCancel(req.Context())
+		attempt := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			clone := req.Clone(ctx)
+			if err := c.hmacSigner.sign(clone); err != nil {
+				results <- hedgeResult{err: err, attempt: attempt}
+				return
+			}
+			res, err := c.send(clone)
+			results <- hedgeResult{res: res, err: err, attempt: attempt}
+		}()
+	}
+
+	send()
+	timer := time.NewTimer(c.hedgeDelay)
+	defer timer.Stop()
+	var winner hedgeResult
+	select {
+	case winner = <-results:
+	case <-timer.C:
+		if c.onHedge != nil {
+			c.onHedge()
+		}
+		send()
+		if winner = <-results; winner.err != nil {
+			// the other request may still succeed
+			winner = <-results
+		} else {
+			go func() {
+				if res := <-results; res.err == nil {
+					closeBody(res.res.Body)
+				}
+			}()
+		}
+	}
+	for attempt, cancel := range cancels {
+		if winner.err != nil || attempt != winner.attempt {
+			cancel()
+		}
+	}
+	if winner.err != nil {
+		return nil, winner.err
+	}
+	winner.res.Body = cancelOnClose{ReadCloser: winner.res.Body, cancel: cancels[winner.attempt]}
+	return winner.res, nil
+}
@@ -0,0 +1,56 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedging(t *testing.T) {
+	handler := Handler(nil, NewTestZeroEventHubAPI())
+	var requests int32
+	var slowRequestCancelled int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// the first attempt is stuck until cancelled
+			select {
+			case <-request.Context().Done():
+				atomic.StoreInt32(&slowRequestCancelled, 1)
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+		handler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	var hedges int32
+	client := NewClient(server.URL, 2).WithHedging(50*time.Millisecond, func() {
+		atomic.AddInt32(&hedges, 1)
+	})
+	var page EventPageSingleType[TestEvent]
+	start := time.Now()
+	err := client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, 10, &page)
+	require.NoError(t, err)
+	require.True(t, time.Since(start) < time.Second)
+	require.Len(t, page.Events, 10)
+	require.Equal(t, map[int]string{0: "9"}, page.Cursors)
+	require.Equal(t, int32(1), atomic.LoadInt32(&hedges))
+	for i := 0; i < 100 && atomic.LoadInt32(&slowRequestCancelled) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&slowRequestCancelled))
+
+	// a fast response doesn't trigger a hedge
+	page = EventPageSingleType[TestEvent]{}
+	err = client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, 10, &page)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 10)
+	require.Equal(t, int32(1), atomic.LoadInt32(&hedges))
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
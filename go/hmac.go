@@ -0,0 +1,175 @@
+package zeroeventhub
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The headers of a request signed by Client.WithHMACSigning, besides Authorization.
+const (
+	HMACTimestampHeader = "X-Zeh-Timestamp"
+	HMACNonceHeader     = "X-Zeh-Nonce"
+)
+
+// hmacScheme is the scheme of the Authorization header: `ZEH-HMAC-SHA256 keyId=<key ID>,signature=<base64>`.
+const hmacScheme = "ZEH-HMAC-SHA256"
+
+var (
+	ErrHMACMissing        = NewAPIError("missing or malformed HMAC signature", http.StatusUnauthorized)
+	ErrHMACUnknownKey     = NewAPIError("unknown HMAC key", http.StatusUnauthorized)
+	ErrHMACBadSignature   = NewAPIError("bad HMAC signature", http.StatusUnauthorized)
+	ErrHMACStaleTimestamp = NewAPIError("stale HMAC timestamp", http.StatusUnauthorized)
+	ErrHMACReplayed       = NewAPIError("replayed HMAC nonce", http.StatusUnauthorized)
+)
+
+// hmacSigner holds the key of Client.WithHMACSigning.
+type hmacSigner struct {
+	keyID  string
+	secret []byte
+}
+
+// WithHMACSigning is a Client method for authenticating requests with a shared secret, for servers wrapped in
+// RequireHMAC: every request is signed with HMAC-SHA256 over its method, path, sorted query, timestamp and a
+// random nonce. Requests are signed after the request processor has run, and hedged requests are signed
+// separately, so that they have nonces of their own.
+func (c Client) WithHMACSigning(keyID string, secret []byte) (r Client) {
+	r = c
+	r.hmacSigner = &hmacSigner{keyID: keyID, secret: secret}
+	return
+}
+
+// sign signs the request, if signing is enabled.
+func (s *hmacSigner) sign(req *http.Request) error {
+	if s == nil {
+		return nil
+	}
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+	signRequest(req, s.keyID, s.secret, time.Now(), hex.EncodeToString(nonce[:]))
+	return nil
+}
+
+func signRequest(req *http.Request, keyID string, secret []byte, at time.Time, nonce string) {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req.Header.Set(HMACTimestampHeader, timestamp)
+	req.Header.Set(HMACNonceHeader, nonce)
+	req.Header.Set("Authorization", hmacScheme+" keyId="+keyID+",signature="+
+		base64.StdEncoding.EncodeToString(hmacSignature(secret, req, timestamp, nonce)))
+}
+
+// hmacSignature signs the canonical form of the request: its method, path, query sorted by key, timestamp and
+// nonce, each on a line of its own.
+func hmacSignature(secret []byte, req *http.Request, timestamp, nonce string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	for _, part := range []string{req.Method, req.URL.EscapedPath(), req.URL.Query().Encode(), timestamp, nonce} {
+		mac.Write([]byte(part))
+		mac.Write([]byte{'\n'})
+	}
+	return mac.Sum(nil)
+}
+
+// NonceCache remembers the nonces of signed requests for RequireHMAC, to reject replayed requests.
+type NonceCache interface {
+	// Seen records the nonce of the key until expiry, returning true if it was already recorded.
+	Seen(keyID, nonce string, expiry time.Time) bool
+}
+
+// MemoryNonceCache is a NonceCache in memory, for a single server instance.
+type MemoryNonceCache struct {
+	lock   sync.Mutex
+	expiry map[string]time.Time
+	// pruneAt is the size of expiry at which expired nonces are deleted
+	pruneAt int
+}
+
+// NewMemoryNonceCache returns an empty MemoryNonceCache.
+func NewMemoryNonceCache() *MemoryNonceCache {
+	return &MemoryNonceCache{expiry: make(map[string]time.Time), pruneAt: 1024}
+}
+
+func (m *MemoryNonceCache) Seen(keyID, nonce string, expiry time.Time) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now := time.Now()
+	key := keyID + "\n" + nonce
+	if e, ok := m.expiry[key]; ok && e.After(now) {
+		return true
+	}
+	// pruning whenever the size has doubled keeps the cost per request constant
+	if len(m.expiry) >= m.pruneAt {
+		for k, e := range m.expiry {
+			if !e.After(now) {
+				delete(m.expiry, k)
+			}
+		}
+		m.pruneAt = 2 * len(m.expiry)
+		if m.pruneAt < 1024 {
+			m.pruneAt = 1024
+		}
+	}
+	m.expiry[key] = expiry
+	return false
+}
+
+// RequireHMAC wraps a handler, e.g. the one returned by Handler, rejecting requests not signed by a client with
+// WithHMACSigning with 401. lookup returns the secret of a key ID, or an error for unknown keys. Requests with a
+// timestamp more than maxClockSkew from now are rejected as stale; if nonces is given, requests with a nonce
+// already seen within that window are rejected as replayed.
+func RequireHMAC(handler http.Handler, lookup func(keyID string) ([]byte, error), maxClockSkew time.Duration, nonces NonceCache) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if err := verifyHMAC(request, lookup, maxClockSkew, nonces); err != nil {
+			http.Error(writer, err.Error(), err.Status())
+			return
+		}
+		handler.ServeHTTP(writer, request)
+	})
+}
+
+func verifyHMAC(request *http.Request, lookup func(keyID string) ([]byte, error), maxClockSkew time.Duration, nonces NonceCache) StatusError {
+	authorization := request.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, hmacScheme+" ") {
+		return ErrHMACMissing
+	}
+	var keyID, signature string
+	for _, param := range strings.Split(strings.TrimPrefix(authorization, hmacScheme+" "), ",") {
+		key, value, _ := strings.Cut(param, "=")
+		switch key {
+		case "keyId":
+			keyID = value
+		case "signature":
+			signature = value
+		}
+	}
+	mac, err := base64.StdEncoding.DecodeString(signature)
+	timestamp, nonce := request.Header.Get(HMACTimestampHeader), request.Header.Get(HMACNonceHeader)
+	seconds, timestampErr := strconv.ParseInt(timestamp, 10, 64)
+	if keyID == "" || err != nil || timestampErr != nil || nonce == "" {
+		return ErrHMACMissing
+	}
+	secret, err := lookup(keyID)
+	if err != nil {
+		return ErrHMACUnknownKey
+	}
+	if !hmac.Equal(mac, hmacSignature(secret, request, timestamp, nonce)) {
+		return ErrHMACBadSignature
+	}
+	// only checked once the signature is known to be good, so that the timestamp can be trusted
+	at := time.Unix(seconds, 0)
+	if skew := time.Since(at); skew > maxClockSkew || skew < -maxClockSkew {
+		return ErrHMACStaleTimestamp
+	}
+	if nonces != nil && nonces.Seen(keyID, nonce, at.Add(maxClockSkew)) {
+		return ErrHMACReplayed
+	}
+	return nil
+}
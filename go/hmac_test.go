@@ -0,0 +1,126 @@
+package zeroeventhub
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+var hmacSecrets = map[string][]byte{"partner": []byte("partner secret")}
+
+func lookupHMACSecret(keyID string) ([]byte, error) {
+	secret, ok := hmacSecrets[keyID]
+	if !ok {
+		return nil, errors.Errorf("no key %q", keyID)
+	}
+	return secret, nil
+}
+
+func TestHMACSigning(t *testing.T) {
+	server := httptest.NewServer(RequireHMAC(Handler(nil, NewTestZeroEventHubAPI()), lookupHMACSecret, time.Minute, NewMemoryNonceCache()))
+	defer server.Close()
+
+	client := NewClient(server.URL, 2).WithHMACSigning("partner", hmacSecrets["partner"])
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: 1, Cursor: "10"}}, 5, &page, "foo"))
+	require.Len(t, page.Events, 5)
+	_, err := client.TailCursor(context.Background(), 0)
+	require.NoError(t, err)
+	// both hedged requests are signed, with nonces of their own
+	hedged := 0
+	require.NoError(t, client.WithHedging(time.Nanosecond, func() { hedged++ }).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	require.Equal(t, 1, hedged)
+
+	tests := []struct {
+		name   string
+		client Client
+		err    StatusError
+	}{
+		{name: "unsigned", client: NewClient(server.URL, 2), err: ErrHMACMissing},
+		{name: "unknown key", client: NewClient(server.URL, 2).WithHMACSigning("other", hmacSecrets["partner"]), err: ErrHMACUnknownKey},
+		{name: "wrong secret", client: NewClient(server.URL, 2).WithHMACSigning("partner", []byte("guess")), err: ErrHMACBadSignature},
+	}
+	for _, test := range tests {
+		err := test.client.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page)
+		require.Equal(t, &ResponseError{StatusCode: http.StatusUnauthorized, Body: test.err.Error() + "\n"}, err, test.name)
+	}
+}
+
+func TestRequireHMAC(t *testing.T) {
+	server := httptest.NewServer(RequireHMAC(Handler(nil, NewTestZeroEventHubAPI()), lookupHMACSecret, time.Minute, NewMemoryNonceCache()))
+	defer server.Close()
+	send := func(sign func(req *http.Request)) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/feed/v1?n=2&cursor0=10&pagesizehint=1", nil)
+		require.NoError(t, err)
+		sign(req)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, string(body)
+	}
+
+	status, _ := send(func(req *http.Request) {
+		signRequest(req, "partner", hmacSecrets["partner"], time.Now().Add(-30*time.Second), "nonce 1")
+	})
+	require.Equal(t, http.StatusOK, status)
+
+	tests := []struct {
+		name string
+		sign func(req *http.Request)
+		err  StatusError
+	}{
+		{name: "replayed", err: ErrHMACReplayed, sign: func(req *http.Request) {
+			signRequest(req, "partner", hmacSecrets["partner"], time.Now().Add(-30*time.Second), "nonce 1")
+		}},
+		{name: "stale", err: ErrHMACStaleTimestamp, sign: func(req *http.Request) {
+			signRequest(req, "partner", hmacSecrets["partner"], time.Now().Add(-2*time.Minute), "nonce 2")
+		}},
+		{name: "future", err: ErrHMACStaleTimestamp, sign: func(req *http.Request) {
+			signRequest(req, "partner", hmacSecrets["partner"], time.Now().Add(2*time.Minute), "nonce 3")
+		}},
+		{name: "query changed after signing", err: ErrHMACBadSignature, sign: func(req *http.Request) {
+			signRequest(req, "partner", hmacSecrets["partner"], time.Now(), "nonce 4")
+			req.URL.RawQuery += "&cursor1=_first"
+		}},
+		{name: "nonce changed after signing", err: ErrHMACBadSignature, sign: func(req *http.Request) {
+			signRequest(req, "partner", hmacSecrets["partner"], time.Now(), "nonce 5")
+			req.Header.Set(HMACNonceHeader, "nonce 6")
+		}},
+		{name: "malformed", err: ErrHMACMissing, sign: func(req *http.Request) {
+			req.Header.Set("Authorization", hmacScheme+" keyId=partner,signature=!")
+		}},
+		{name: "bearer", err: ErrHMACMissing, sign: func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer token")
+		}},
+	}
+	for _, test := range tests {
+		status, body := send(test.sign)
+		require.Equal(t, http.StatusUnauthorized, status, test.name)
+		require.Equal(t, test.err.Error()+"\n", body, test.name)
+	}
+}
+
+func TestMemoryNonceCache(t *testing.T) {
+	cache := NewMemoryNonceCache()
+	expiry := time.Now().Add(time.Minute)
+	require.False(t, cache.Seen("a", "1", expiry))
+	require.True(t, cache.Seen("a", "1", expiry))
+	require.False(t, cache.Seen("b", "1", expiry))
+
+	// expired nonces are forgotten, and pruned as the cache grows
+	require.False(t, cache.Seen("a", "expired", time.Now().Add(-time.Second)))
+	require.False(t, cache.Seen("a", "expired", time.Now().Add(-time.Second)))
+	for i := 0; i < 2000; i++ {
+		cache.Seen("c", string(rune(i)), time.Now().Add(-time.Second))
+	}
+	require.True(t, len(cache.expiry) < 1100)
+	require.True(t, cache.Seen("a", "1", expiry))
+}
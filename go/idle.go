@@ -0,0 +1,58 @@
+package zeroeventhub
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrStreamIdle is returned by Client.FetchEvents when the server sends nothing for longer than the timeout set by
+// WithIdleReadTimeout in the middle of a response.
+var ErrStreamIdle = errors.New("no data received from the server within the idle read timeout")
+
+// WithIdleReadTimeout is a Client method for detecting a stalled response, e.g. from a half-open connection after a
+// NAT timeout, on which reading would otherwise block until the request timeout, if any. If no data is received
+// for d while reading the response body, the connection is closed and FetchEvents returns ErrStreamIdle, which
+// WithResumeFromCheckpoint resumes like other failures in the middle of a page. Every byte received resets the
+// timeout, so a slow server trickling out a large page is fine. Pass 0 to disable it.
+func (c Client) WithIdleReadTimeout(d time.Duration) (r Client) {
+	r = c
+	r.idleReadTimeout = d
+	return
+}
+
+// idleReader closes body if reading it makes no progress for timeout, turning the blocked Read into ErrStreamIdle.
+// stop must be called when done reading.
+type idleReader struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	idle    int32
+}
+
+func newIdleReader(body io.ReadCloser, timeout time.Duration) *idleReader {
+	r := &idleReader{body: body, timeout: timeout}
+	r.timer = time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&r.idle, 1)
+		_ = body.Close()
+	})
+	return r
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if err != nil && atomic.LoadInt32(&r.idle) == 1 {
+		return n, ErrStreamIdle
+	}
+	// if the timer has already fired, the next Read fails
+	if n > 0 && r.timer.Stop() {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+func (r *idleReader) stop() {
+	r.timer.Stop()
+}
@@ -0,0 +1,62 @@
+package zeroeventhub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdleReadTimeout(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		cursor := request.URL.Query().Get("cursor0")
+		for i := 0; i < 3; i++ {
+			_, _ = fmt.Fprintf(writer, `{"partition":0,"data":{"i":%d}}`+"\n", i)
+			if request.URL.Query().Get("stall") == "" {
+				// a slow server, which the timeout must not mistake for a stalled one
+				writer.(http.Flusher).Flush()
+				time.Sleep(50 * time.Millisecond)
+			}
+		}
+		_, _ = fmt.Fprintf(writer, `{"partition":0,"cursor":"%s+3"}`+"\n", cursor)
+		writer.(http.Flusher).Flush()
+		if request.URL.Query().Get("stall") != "" && cursor == "0" {
+			// stops sending without closing the connection
+			select {
+			case <-request.Context().Done():
+			case <-done:
+			}
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, 1).WithIdleReadTimeout(100 * time.Millisecond)
+
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "0"}}, 3, &page))
+	require.Len(t, page.Events, 3)
+	require.Equal(t, "0+3", page.Cursors[0])
+
+	stalling := client.WithQueryParam("stall", "1")
+	page = EventPageRaw{}
+	start := time.Now()
+	require.Equal(t, ErrStreamIdle, stalling.FetchEvents(context.Background(), []Cursor{{Cursor: "0"}}, 3, &page))
+	require.True(t, time.Since(start) < time.Second)
+	require.Len(t, page.Events, 3)
+	require.Equal(t, "0+3", page.Cursors[0])
+
+	// resumed from the last checkpoint
+	var resumed []Cursor
+	resuming := stalling.WithResumeFromCheckpoint(1, func(cursors []Cursor, err error) {
+		require.Equal(t, ErrStreamIdle, err)
+		resumed = cursors
+	})
+	require.NoError(t, resuming.FetchEvents(context.Background(), []Cursor{{Cursor: "0"}}, 3, &page))
+	require.Equal(t, []Cursor{{Cursor: "0+3"}}, resumed)
+	require.Equal(t, "0+3+3", page.Cursors[0])
+}
@@ -0,0 +1,50 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+)
+
+// ErrIllegalInclusive is returned by Handler for an inclusive parameter other than 0 or 1.
+var ErrIllegalInclusive = NewAPIError("illegal inclusive; expected 0 or 1", http.StatusBadRequest)
+
+// InclusiveParam is the query parameter asking for inclusive cursors: with inclusive=1, a page starts at the event
+// of the cursor rather than after it, re-delivering the boundary event, e.g. to verify that no events were missed
+// between pages. FirstCursor and LastCursor are not affected. Cursors are exclusive by default. Publishers have to
+// support it explicitly, e.g. by using >= instead of > in their queries; check with the publisher before relying on
+// it.
+const InclusiveParam = "inclusive"
+
+func parseInclusive(s string) (bool, error) {
+	switch s {
+	case "", "0":
+		return false, nil
+	case "1":
+		return true, nil
+	default:
+		return false, ErrIllegalInclusive
+	}
+}
+
+type inclusiveContextKey struct{}
+
+// WithInclusiveCursors returns a context marking the cursors passed to API.FetchEvents as inclusive; Handler passes
+// the choice of the client to the API this way. Publishers check it with InclusiveCursorsFromContext.
+func WithInclusiveCursors(ctx context.Context, inclusive bool) context.Context {
+	return context.WithValue(ctx, inclusiveContextKey{}, inclusive)
+}
+
+// InclusiveCursorsFromContext returns whether the cursors are inclusive, as set by WithInclusiveCursors; false by
+// default. For a publisher built on ServeFromRows, the query function checks it to use >= afterID.
+func InclusiveCursorsFromContext(ctx context.Context) bool {
+	inclusive, _ := ctx.Value(inclusiveContextKey{}).(bool)
+	return inclusive
+}
+
+// WithInclusiveCursors is a Client method for requesting pages that start at the event of each cursor rather than
+// after it; see InclusiveParam.
+func (c Client) WithInclusiveCursors(inclusive bool) (r Client) {
+	r = c
+	r.inclusive = inclusive
+	return
+}
@@ -0,0 +1,44 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInclusiveCursors(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+	cursors := []Cursor{{Cursor: "10"}}
+
+	// exclusive by default
+	var page EventPageSingleType[TestEvent]
+	require.NoError(t, client.FetchEvents(context.Background(), cursors, 2, &page))
+	require.Equal(t, 11, page.Events[0].Data.Cursor)
+	require.Equal(t, "12", page.Cursors[0])
+
+	page = EventPageSingleType[TestEvent]{}
+	require.NoError(t, client.WithInclusiveCursors(true).FetchEvents(context.Background(), cursors, 2, &page))
+	require.Len(t, page.Events, 2)
+	require.Equal(t, 10, page.Events[0].Data.Cursor)
+	require.Equal(t, "11", page.Cursors[0])
+
+	page = EventPageSingleType[TestEvent]{}
+	require.NoError(t, client.WithInclusiveCursors(true).WithDirection(Backward).FetchEvents(context.Background(), cursors, 2, &page))
+	require.Equal(t, 10, page.Events[0].Data.Cursor)
+	require.Equal(t, "9", page.Cursors[0])
+
+	// FirstCursor is not affected
+	page = EventPageSingleType[TestEvent]{}
+	require.NoError(t, client.WithInclusiveCursors(true).FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, 1, &page))
+	require.Equal(t, 0, page.Events[0].Data.Cursor)
+
+	res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=10&inclusive=yes")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
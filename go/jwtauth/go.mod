@@ -0,0 +1,16 @@
+module github.com/vippsas/zeroeventhub/go/jwtauth
+
+go 1.18
+
+require (
+	github.com/golang-jwt/jwt/v5 v5.2.2
+	github.com/stretchr/testify v1.3.0
+	github.com/vippsas/zeroeventhub/go v0.0.0-20261016210015-7c830b856199
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gorilla/mux v1.8.0 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
@@ -0,0 +1,31 @@
+// Package jwtauth verifies JWT bearer tokens for zeroeventhub.RequireBearer. It is a module of its own, to keep the
+// JWT dependency out of the zeroeventhub package.
+package jwtauth
+
+import (
+	"context"
+
+	"github.com/golang-jwt/jwt/v5"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// Verifier returns a verifier for zeroeventhub.RequireBearer accepting JWTs signed with the keys returned by
+// keyFunc, and valid according to the parser options, e.g. jwt.WithValidMethods, jwt.WithAudience or
+// jwt.WithIssuer. Expired tokens are rejected. The principal is the jwt.MapClaims of the token.
+func Verifier(keyFunc jwt.Keyfunc, options ...jwt.ParserOption) func(ctx context.Context, token string) (zeroeventhub.Principal, error) {
+	parser := jwt.NewParser(options...)
+	return func(ctx context.Context, token string) (zeroeventhub.Principal, error) {
+		claims := jwt.MapClaims{}
+		if _, err := parser.ParseWithClaims(token, claims, keyFunc); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	}
+}
+
+// Claims returns the claims of the JWT authenticated by a Verifier for the request being served.
+func Claims(ctx context.Context) (claims jwt.MapClaims, ok bool) {
+	principal, _ := zeroeventhub.PrincipalFromContext(ctx)
+	claims, ok = principal.(jwt.MapClaims)
+	return
+}
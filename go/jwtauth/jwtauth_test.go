@@ -0,0 +1,100 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+var secret = []byte("test secret")
+
+// subjectAPI serves an event with the subject of the caller in partition 0.
+type subjectAPI struct{}
+
+func (subjectAPI) GetName() string {
+	return "subjectAPI"
+}
+
+func (subjectAPI) GetPartitionCount() int {
+	return 1
+}
+
+func (subjectAPI) FetchEvents(ctx context.Context, cursors []zeroeventhub.Cursor, pageSizeHint int, r zeroeventhub.EventReceiver, headers ...string) error {
+	claims, ok := Claims(ctx)
+	if !ok {
+		return zeroeventhub.NewAPIError("no claims", http.StatusForbidden)
+	}
+	data, err := json.Marshal(claims["sub"])
+	if err != nil {
+		return err
+	}
+	if err := r.Event(0, nil, data); err != nil {
+		return err
+	}
+	return r.Checkpoint(0, "1")
+}
+
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	require.NoError(t, err)
+	return token
+}
+
+func TestVerifier(t *testing.T) {
+	keyFunc := func(*jwt.Token) (interface{}, error) {
+		return secret, nil
+	}
+	verify := Verifier(keyFunc, jwt.WithValidMethods([]string{"HS256"}), jwt.WithAudience("feed"))
+	server := httptest.NewServer(zeroeventhub.RequireBearer(zeroeventhub.Handler(nil, subjectAPI{}), verify))
+	defer server.Close()
+	fetch := func(token string) (zeroeventhub.EventPageRaw, error) {
+		var page zeroeventhub.EventPageRaw
+		client := zeroeventhub.NewClient(server.URL, 1).WithRequestProcessor(func(r *http.Request) error {
+			if token != "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			return nil
+		})
+		err := client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: zeroeventhub.FirstCursor}}, zeroeventhub.DefaultPageSize, &page)
+		return page, err
+	}
+
+	page, err := fetch(sign(t, jwt.MapClaims{"sub": "alice", "aud": "feed", "exp": time.Now().Add(time.Minute).Unix()}))
+	require.NoError(t, err)
+	require.Equal(t, `"alice"`, string(page.Events[0].Data))
+
+	unauthorized := func(err zeroeventhub.StatusError) error {
+		return &zeroeventhub.ResponseError{StatusCode: http.StatusUnauthorized, Body: err.Error() + "\n"}
+	}
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "missing", token: "", err: unauthorized(zeroeventhub.ErrBearerTokenMissing)},
+		{name: "expired", token: sign(t, jwt.MapClaims{"sub": "alice", "aud": "feed", "exp": time.Now().Add(-time.Minute).Unix()}),
+			err: unauthorized(zeroeventhub.ErrBearerTokenInvalid)},
+		{name: "other audience", token: sign(t, jwt.MapClaims{"sub": "alice", "aud": "other"}),
+			err: unauthorized(zeroeventhub.ErrBearerTokenInvalid)},
+		{name: "not a JWT", token: "alice", err: unauthorized(zeroeventhub.ErrBearerTokenInvalid)},
+	}
+	for _, test := range tests {
+		_, err := fetch(test.token)
+		require.Equal(t, test.err, err, test.name)
+	}
+
+	// tampered and unsigned tokens are rejected
+	_, err = verify(context.Background(), sign(t, jwt.MapClaims{"aud": "feed"})[:10]+"x")
+	require.Error(t, err)
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"aud": "feed"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = verify(context.Background(), none)
+	require.Error(t, err)
+}
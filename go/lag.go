@@ -0,0 +1,58 @@
+package zeroeventhub
+
+import (
+	"strconv"
+)
+
+// CursorComparator compares two cursors of the same partition, returning a negative number if a is before b,
+// 0 if they are equal and a positive number if a is after b. ok is false if the cursors can't be compared,
+// as is the case for opaque cursors.
+type CursorComparator func(a, b string) (cmp int, ok bool)
+
+// NumericCursors is a CursorComparator for publishers whose cursors are integers increasing with every event.
+func NumericCursors(a, b string) (int, bool) {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	switch {
+	case errA != nil || errB != nil:
+		return 0, false
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// Lag returns how many events the consumer at the current cursor is behind the tail cursor of a partition
+// (see Client.TailCursor), e.g. for showing consumer lag on a dashboard. cmp defaults to NumericCursors.
+//
+// The count is the difference between the cursors, which is exact for cursors numbering the events
+// consecutively, and an upper bound if there are gaps. ok is false if the lag is unknown: if cmp can't compare
+// the cursors, if the cursors aren't numeric, or if the consumer hasn't checkpointed yet (FirstCursor).
+// A consumer at or after the tail, and any consumer of an empty partition, has lag 0.
+func Lag(tail, current string, cmp CursorComparator) (lag int, ok bool) {
+	if cmp == nil {
+		cmp = NumericCursors
+	}
+	if tail == FirstCursor {
+		return 0, true
+	}
+	if IsSpecialCursor(current) {
+		return 0, false
+	}
+	order, ok := cmp(current, tail)
+	if !ok {
+		return 0, false
+	}
+	if order >= 0 {
+		return 0, true
+	}
+	x, errCurrent := strconv.ParseInt(current, 10, 64)
+	y, errTail := strconv.ParseInt(tail, 10, 64)
+	if errCurrent != nil || errTail != nil {
+		return 0, false
+	}
+	return int(y - x), true
+}
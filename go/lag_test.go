@@ -0,0 +1,42 @@
+package zeroeventhub
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLag(t *testing.T) {
+	opaque := func(a, b string) (int, bool) {
+		return 0, false
+	}
+	lexical := func(a, b string) (int, bool) {
+		return strings.Compare(a, b), true
+	}
+	tests := []struct {
+		name    string
+		tail    string
+		current string
+		cmp     CursorComparator
+		lag     int
+		ok      bool
+	}{
+		{name: "behind", tail: "9999", current: "9000", lag: 999, ok: true},
+		{name: "caught up", tail: "9999", current: "9999", lag: 0, ok: true},
+		{name: "ahead of a stale tail", tail: "9999", current: "10005", lag: 0, ok: true},
+		{name: "empty partition", tail: FirstCursor, current: FirstCursor, lag: 0, ok: true},
+		{name: "no checkpoint yet", tail: "9999", current: FirstCursor, ok: false},
+		{name: "opaque cursors", tail: "AAAB", current: "AAAA", ok: false},
+		{name: "opaque comparator", tail: "10", current: "5", cmp: opaque, ok: false},
+		{name: "comparable but not numeric", tail: "b", current: "a", cmp: lexical, ok: false},
+		{name: "comparable and caught up", tail: "a", current: "b", cmp: lexical, lag: 0, ok: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lag, ok := Lag(test.tail, test.current, test.cmp)
+			require.Equal(t, test.ok, ok)
+			require.Equal(t, test.lag, lag)
+		})
+	}
+}
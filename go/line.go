@@ -0,0 +1,306 @@
+package zeroeventhub
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// MaxLineBytes is the maximum size of a single NDJSON line accepted by DecodeStream (and therefore by Client).
+const MaxLineBytes = 16 * 1024 * 1024
+
+// ErrLineTooLong is returned by DecodeStream when a line exceeds MaxLineBytes.
+var ErrLineTooLong = errors.New("NDJSON line too long")
+
+// LineKind tells which kind of line of the NDJSON stream a Line is.
+type LineKind int
+
+const (
+	// LineEvent is an event; Line.Envelope is set.
+	LineEvent LineKind = iota
+	// LineCheckpoint is a checkpoint; Line.Checkpoint is set.
+	LineCheckpoint
+	// LineError is an error reported by the server in the middle of the stream; Line.Error is set.
+	LineError
+)
+
+func (k LineKind) String() string {
+	switch k {
+	case LineEvent:
+		return "event"
+	case LineCheckpoint:
+		return "checkpoint"
+	case LineError:
+		return "error"
+	default:
+		return fmt.Sprintf("LineKind(%d)", int(k))
+	}
+}
+
+// StreamError is an error reported by the server as a line of the NDJSON stream, i.e. `{"error":"..."}`.
+type StreamError struct {
+	PartitionID int    `json:"partition"`
+	Message     string `json:"error"`
+}
+
+func (e *StreamError) Error() string {
+	return "stream error: " + e.Message
+}
+
+// Line is a single parsed line of the NDJSON stream: either an event, a checkpoint or an error.
+type Line struct {
+	Kind       LineKind
+	Envelope   *Envelope
+	Checkpoint *Cursor
+	Error      *StreamError
+	// CaughtUp is set for a checkpoint at the tail of the partition; see CaughtUpParam.
+	CaughtUp bool
+}
+
+type rawLine struct {
+	PartitionID int `json:"partition"`
+	// either this is set, and maybe CaughtUp:
+	Cursor   lineCursor `json:"cursor"`
+	CaughtUp bool       `json:"caughtUp"`
+	// OR this:
+	Error string `json:"error"`
+	// OR, these are set:
+	Headers map[string]string `json:"headers"`
+	Data    json.RawMessage   `json:"data"`
+}
+
+// lineCursor is the cursor of a checkpoint line. Cursors are strings, but some publishers write them as JSON numbers;
+// a number is taken as written, e.g. 12345 as "12345", rather than going through float64, which would lose the
+// precision of large int64 values. null, like a missing cursor, makes the line no checkpoint.
+type lineCursor string
+
+func (c *lineCursor) UnmarshalJSON(b []byte) error {
+	switch {
+	case bytes.Equal(b, []byte("null")):
+		return nil
+	case b[0] == '"':
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*c = lineCursor(s)
+	case b[0] == '-' || b[0] >= '0' && b[0] <= '9':
+		// json.Unmarshal has checked that it is a valid number
+		*c = lineCursor(b)
+	default:
+		return errors.Errorf("cursor must be a string or a number, got %s", b)
+	}
+	return nil
+}
+
+// parseCheckpointLine is a fast path for the most common line, a checkpoint in the form written by
+// NDJSONEventSerializer and json.Encoder: {"partition":N,"cursor":"..."} with no whitespace and no escapes in the
+// cursor. It sets the partition and cursor of parsed, as json.Unmarshal would, if b is in that form, and returns
+// false without touching parsed otherwise, leaving the line to json.Unmarshal.
+func parseCheckpointLine(b []byte, parsed *rawLine) bool {
+	const prefix, separator, suffix = `{"partition":`, `,"cursor":"`, `"}`
+	if !bytes.HasPrefix(b, []byte(prefix)) || !bytes.HasSuffix(b, []byte(suffix)) {
+		return false
+	}
+	b = b[len(prefix) : len(b)-len(suffix)]
+	end := bytes.IndexByte(b, ',')
+	if end < 0 || !bytes.HasPrefix(b[end:], []byte(separator)) {
+		return false
+	}
+	digits, cursor := b[:end], b[end+len(separator):]
+	if len(digits) == 0 || len(digits) > 1 && digits[0] == '0' {
+		// leading zeros are invalid JSON
+		return false
+	}
+	partitionID := 0
+	for _, c := range digits {
+		if c < '0' || c > '9' || partitionID > (maxPartitionID-int(c-'0'))/10 {
+			return false
+		}
+		partitionID = partitionID*10 + int(c-'0')
+	}
+	for _, c := range cursor {
+		// json.Unmarshal unescapes and replaces invalid UTF-8, so leave anything but plain ASCII to it
+		if c < 0x20 || c == '"' || c == '\\' || c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	parsed.PartitionID = partitionID
+	parsed.Cursor = lineCursor(cursor)
+	return true
+}
+
+// maxPartitionID bounds the partition IDs parseCheckpointLine parses itself, well clear of overflow.
+const maxPartitionID = 1<<31 - 1
+
+// ParseLine parses a single (non-blank) line of the NDJSON stream. A line with a cursor is a checkpoint,
+// a line with an error is an error and everything else is an event. The returned Line doesn't refer to b.
+func ParseLine(b []byte) (Line, error) {
+	// we only partially parse at this point, as "data" is json.RawMessage
+	var parsed rawLine
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		return Line{}, err
+	}
+	return parsed.line(), nil
+}
+
+// line returns the Line of the parsed line, referring to its headers and data.
+func (parsed *rawLine) line() Line {
+	switch parsed.kind() {
+	case LineCheckpoint:
+		return Line{
+			Kind:       LineCheckpoint,
+			Checkpoint: &Cursor{PartitionID: parsed.PartitionID, Cursor: string(parsed.Cursor)},
+			CaughtUp:   parsed.CaughtUp,
+		}
+	case LineError:
+		return Line{
+			Kind:  LineError,
+			Error: &StreamError{PartitionID: parsed.PartitionID, Message: parsed.Error},
+		}
+	default:
+		return Line{
+			Kind:     LineEvent,
+			Envelope: &Envelope{PartitionID: parsed.PartitionID, Headers: parsed.Headers, Data: parsed.Data},
+		}
+	}
+}
+
+func (parsed *rawLine) kind() LineKind {
+	switch {
+	case parsed.Cursor != "":
+		return LineCheckpoint
+	case parsed.Error != "":
+		return LineError
+	default:
+		return LineEvent
+	}
+}
+
+// EncodeLine is the inverse of ParseLine: it returns the NDJSON line, including the trailing newline.
+func EncodeLine(line Line) ([]byte, error) {
+	var item interface{}
+	switch {
+	case line.Kind == LineEvent && line.Envelope != nil:
+		item = line.Envelope
+	case line.Kind == LineCheckpoint && line.Checkpoint != nil && line.CaughtUp:
+		item = struct {
+			*Cursor
+			CaughtUp bool `json:"caughtUp"`
+		}{line.Checkpoint, true}
+	case line.Kind == LineCheckpoint && line.Checkpoint != nil:
+		item = line.Checkpoint
+	case line.Kind == LineError && line.Error != nil:
+		item = line.Error
+	default:
+		return nil, errors.Errorf("%s line without content", line.Kind)
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(item); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeStream reads an NDJSON stream, calling fn for each line. Blank lines are skipped and a missing trailing
+// newline is accepted. It stops at the first error, either from parsing or from fn.
+func DecodeStream(r io.Reader, fn func(Line) error) error {
+	return DecodeStreamWithOptions(r, DecodeOptions{}, fn)
+}
+
+// DecodeStreamWithOptions is DecodeStream with options.
+func DecodeStreamWithOptions(r io.Reader, opts DecodeOptions, fn func(Line) error) error {
+	return decodeRawLines(r, false, opts, func(parsed *rawLine) error {
+		return fn(parsed.line())
+	})
+}
+
+// scanBuffers are the initial buffers of the line scanners; nothing passed on refers to them, as ParseLine and
+// json.Unmarshal copy what they return. Buffers a scanner grows for long lines are left to the GC.
+var scanBuffers = sync.Pool{
+	New: func() interface{} {
+		buffer := make([]byte, 0, 64*1024)
+		return &buffer
+	},
+}
+
+// decodeRawLines is DecodeStream without the conversion to Line, for the client's hot path. The same rawLine is
+// passed to fn for every line; its headers are only allocated when present. If reuseData is set, its data also
+// reuses the same buffer for every line, so it is only valid until fn returns.
+func decodeRawLines(r io.Reader, reuseData bool, opts DecodeOptions, fn func(*rawLine) error) error {
+	reader := &errorRecordingReader{reader: r}
+	scanner := bufio.NewScanner(reader)
+	scanBuffer := scanBuffers.Get().(*[]byte)
+	defer scanBuffers.Put(scanBuffer)
+	scanner.Buffer((*scanBuffer)[:0], MaxLineBytes)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && reader.err != io.EOF && bytes.IndexByte(data, '\n') < 0 {
+			// the last line was cut short by a read error, which is what should be reported
+			return 0, nil, reader.err
+		}
+		return bufio.ScanLines(data, atEOF)
+	})
+	var parsed rawLine
+	var buffer json.RawMessage
+	for n := 1; scanner.Scan(); n++ {
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		// json.Unmarshal leaves absent fields alone and merges into an existing map, so start from scratch,
+		// except that a json.RawMessage is copied into its existing capacity
+		parsed = rawLine{}
+		if parseCheckpointLine(b, &parsed) {
+			if err := fn(&parsed); err != nil {
+				return err
+			}
+			continue
+		}
+		if reuseData {
+			parsed.Data = buffer[:0]
+		}
+		if err := json.Unmarshal(b, &parsed); err != nil {
+			return err
+		}
+		if opts.Strict {
+			// the lines taking the fast path above are always valid
+			if violation := checkLine(b, parsed.kind(), n); violation != nil {
+				return violation
+			}
+		}
+		if reuseData {
+			buffer = parsed.Data[:0]
+			if len(parsed.Data) == 0 {
+				parsed.Data = nil
+			}
+		}
+		if err := fn(&parsed); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err == bufio.ErrTooLong {
+		return ErrLineTooLong
+	} else {
+		return err
+	}
+}
+
+// errorRecordingReader records the error returned by the underlying reader.
+type errorRecordingReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *errorRecordingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
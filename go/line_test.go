@@ -0,0 +1,245 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected Line
+		err      string
+	}{
+		{
+			name: "event",
+			line: `{"partition":1,"headers":{"h1":"v1"},"data":{"ID":"1"}}`,
+			expected: Line{Kind: LineEvent, Envelope: &Envelope{
+				PartitionID: 1,
+				Headers:     map[string]string{"h1": "v1"},
+				Data:        json.RawMessage(`{"ID":"1"}`),
+			}},
+		},
+		{
+			name:     "event without data",
+			line:     `{"partition":1}`,
+			expected: Line{Kind: LineEvent, Envelope: &Envelope{PartitionID: 1}},
+		},
+		{
+			name:     "checkpoint",
+			line:     `{"partition":1,"cursor":"123"}`,
+			expected: Line{Kind: LineCheckpoint, Checkpoint: &Cursor{PartitionID: 1, Cursor: "123"}},
+		},
+		{
+			name:     "error",
+			line:     `{"partition":1,"error":"failed"}`,
+			expected: Line{Kind: LineError, Error: &StreamError{PartitionID: 1, Message: "failed"}},
+		},
+		{
+			name:     "numeric checkpoint",
+			line:     `{"partition":1,"cursor":12345}`,
+			expected: Line{Kind: LineCheckpoint, Checkpoint: &Cursor{PartitionID: 1, Cursor: "12345"}},
+		},
+		{
+			name:     "large numeric checkpoint",
+			line:     `{"partition":1, "cursor": 9223372036854775807}`,
+			expected: Line{Kind: LineCheckpoint, Checkpoint: &Cursor{PartitionID: 1, Cursor: "9223372036854775807"}},
+		},
+		{
+			name:     "null cursor",
+			line:     `{"partition":1,"cursor":null,"data":{"ID":"1"}}`,
+			expected: Line{Kind: LineEvent, Envelope: &Envelope{PartitionID: 1, Data: json.RawMessage(`{"ID":"1"}`)}},
+		},
+		{
+			name: "boolean cursor",
+			line: `{"partition":1,"cursor":true}`,
+			err:  "cursor must be a string or a number, got true",
+		},
+		{
+			name: "malformed",
+			line: `{"partition":1`,
+			err:  "unexpected end of JSON input",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line, err := ParseLine([]byte(test.line))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, line)
+
+			encoded, err := EncodeLine(line)
+			require.NoError(t, err)
+			roundTripped, err := ParseLine(encoded)
+			require.NoError(t, err)
+			require.Equal(t, line, roundTripped)
+		})
+	}
+
+	_, err := EncodeLine(Line{Kind: LineCheckpoint})
+	require.EqualError(t, err, "checkpoint line without content")
+}
+
+func TestEncodeLineMatchesSerializer(t *testing.T) {
+	var buf strings.Builder
+	serializer := NewNDJSONEventSerializer(&buf)
+	require.NoError(t, serializer.Event(0, map[string]string{"h": "<v>"}, json.RawMessage(`{"a":"&"}`)))
+	require.NoError(t, serializer.Checkpoint(0, "1"))
+
+	event, err := EncodeLine(Line{Kind: LineEvent, Envelope: &Envelope{Headers: map[string]string{"h": "<v>"}, Data: json.RawMessage(`{"a":"&"}`)}})
+	require.NoError(t, err)
+	checkpoint, err := EncodeLine(Line{Kind: LineCheckpoint, Checkpoint: &Cursor{Cursor: "1"}})
+	require.NoError(t, err)
+	require.Equal(t, buf.String(), string(event)+string(checkpoint))
+}
+
+func TestDecodeStream(t *testing.T) {
+	stream := "\n" +
+		`{"partition":0,"data":1}` + "\n" +
+		"  \n" +
+		`{"partition":0,"cursor":"1"}` + "\r\n" +
+		`{"partition":0,"error":"failed"}`
+	var kinds []LineKind
+	err := DecodeStream(strings.NewReader(stream), func(line Line) error {
+		kinds = append(kinds, line.Kind)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []LineKind{LineEvent, LineCheckpoint, LineError}, kinds)
+
+	err = DecodeStream(strings.NewReader(`{"partition":0,"data":"`+strings.Repeat("x", MaxLineBytes)+`"}`), func(Line) error {
+		return nil
+	})
+	require.Equal(t, ErrLineTooLong, err)
+
+	err = DecodeStream(strings.NewReader("{}\nnot json\n"), func(Line) error {
+		return nil
+	})
+	require.Error(t, err)
+}
+
+func TestClientNumericCursors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"partition":0,"data":{"ID":"1"}}` + "\n"))
+		_, _ = writer.Write([]byte(`{"partition":0,"cursor":9007199254740993}` + "\n"))
+		_, _ = writer.Write([]byte(`{"partition":1,"cursor":-42}` + "\n"))
+	}))
+	defer server.Close()
+
+	var page EventPageRaw
+	require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page))
+	require.Len(t, page.Events, 1)
+	// 2^53+1, which float64 can't represent
+	require.Equal(t, map[int]string{0: "9007199254740993", 1: "-42"}, page.Cursors)
+}
+
+func TestClientLargeEventsAndStreamErrors(t *testing.T) {
+	largeData := `"` + strings.Repeat("x", 100*1024) + `"`
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"partition":0,"data":` + largeData + "}\n"))
+		_, _ = writer.Write([]byte(`{"partition":0,"cursor":"1"}` + "\n"))
+		if request.URL.Query().Get("cursor0") == "fail" {
+			_, _ = writer.Write([]byte(`{"partition":0,"error":"database unavailable"}` + "\n"))
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, 1)
+
+	var page EventPageRaw
+	err := client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	require.Equal(t, largeData, string(page.Events[0].Data))
+
+	page = EventPageRaw{}
+	err = client.FetchEvents(context.Background(), []Cursor{{Cursor: "fail"}}, DefaultPageSize, &page)
+	require.Equal(t, &StreamError{Message: "database unavailable"}, err)
+	require.EqualError(t, err, "stream error: database unavailable")
+	require.Equal(t, map[int]string{0: "1"}, page.Cursors)
+}
+
+func FuzzParseLine(f *testing.F) {
+	f.Add([]byte(`{"partition":0,"headers":{"h1":"v1"},"data":{"ID":"1"}}`))
+	f.Add([]byte(`{"partition":0,"cursor":"123"}`))
+	f.Add([]byte(`{"partition":0,"error":"failed"}`))
+	f.Add([]byte(`{"partition":"0","data":null}`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte(``))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		line, err := ParseLine(b)
+		if err != nil {
+			return
+		}
+		encoded, err := EncodeLine(line)
+		if err != nil {
+			t.Fatalf("parsed line %q can't be encoded: %v", b, err)
+		}
+		if _, err := ParseLine(encoded); err != nil {
+			t.Fatalf("encoded line %q can't be parsed: %v", encoded, err)
+		}
+	})
+}
+
+func TestParseCheckpointLine(t *testing.T) {
+	for line, fast := range map[string]bool{
+		`{"partition":0,"cursor":"123"}`:                  true,
+		`{"partition":17,"cursor":"a/b=c+d"}`:             true,
+		`{"partition":0,"cursor":""}`:                     true,
+		`{"partition":2147483647,"cursor":"1"}`:           true,
+		`{"partition":2147483648,"cursor":"1"}`:           false,
+		`{"partition":99999999999999999999,"cursor":"1"}`: false,
+		`{"partition":01,"cursor":"1"}`:                   false,
+		`{"partition":-1,"cursor":"1"}`:                   false,
+		`{"partition":,"cursor":"1"}`:                     false,
+		`{"partition":0, "cursor":"1"}`:                   false,
+		`{"partition":0,"cursor":"1","data":{}}`:          false,
+		`{"partition":0,"cursor":"1\"}`:                   false,
+		`{"partition":0,"cursor":"aæ"}`:                   false,
+		`{"partition":0,"cursor":"æ"}`:                    false,
+		`{"partition":0,"cursor":"1","extra":"x"}`:        false,
+		`{"partition":0,"cursor":"1"},"cursor":"2"}`:      false,
+		`{"cursor":"1","partition":0}`:                    false,
+		`{"partition":0,"error":"failed"}`:                false,
+		`{"partition":0,"headers":{"a":"b"},"data":"x"}`:  false,
+	} {
+		var parsed rawLine
+		require.Equal(t, fast, parseCheckpointLine([]byte(line), &parsed), line)
+		if fast {
+			var expected rawLine
+			require.NoError(t, json.Unmarshal([]byte(line), &expected))
+			require.Equal(t, expected, parsed, line)
+		} else {
+			require.Equal(t, rawLine{}, parsed, line)
+		}
+	}
+}
+
+func FuzzParseCheckpointLine(f *testing.F) {
+	f.Add([]byte(`{"partition":0,"cursor":"123"}`))
+	f.Add([]byte(`{"partition":12,"cursor":"a\"b"}`))
+	f.Add([]byte(`{"partition":0,"cursor":"1","data":1}`))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var parsed rawLine
+		if !parseCheckpointLine(b, &parsed) {
+			return
+		}
+		// the fast path must agree with json.Unmarshal on every line it accepts
+		var expected rawLine
+		if err := json.Unmarshal(b, &expected); err != nil {
+			t.Fatalf("line %q parsed by parseCheckpointLine is invalid: %v", b, err)
+		}
+		if parsed.PartitionID != expected.PartitionID || parsed.Cursor != expected.Cursor || expected.Data != nil || expected.Headers != nil || expected.Error != "" {
+			t.Fatalf("line %q parsed as %+v rather than %+v", b, parsed, expected)
+		}
+	})
+}
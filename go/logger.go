@@ -0,0 +1,94 @@
+package zeroeventhub
+
+import (
+	"context"
+)
+
+// Logger is the minimal structured logger used by Handler, Client and DebugProxy, so that this package doesn't
+// depend on a particular logging library. Entries have no message: what happened is in the "event" field.
+// Package logrusadapter adapts a logrus logger; when no logger is given, nothing is logged.
+type Logger interface {
+	WithField(key string, value interface{}) Logger
+	WithError(err error) Logger
+	// WithContext passes the context of a request on to the logging library, e.g. for tracing.
+	WithContext(ctx context.Context) Logger
+	Debug()
+	Info()
+	Warn()
+	Error()
+}
+
+// NopLogger returns a Logger discarding everything, which is the default.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (l nopLogger) WithField(string, interface{}) Logger {
+	return l
+}
+
+func (l nopLogger) WithError(error) Logger {
+	return l
+}
+
+func (l nopLogger) WithContext(context.Context) Logger {
+	return l
+}
+
+func (nopLogger) Debug() {}
+func (nopLogger) Info()  {}
+func (nopLogger) Warn()  {}
+func (nopLogger) Error() {}
+
+// orNop returns logger, or NopLogger if it is nil.
+func orNop(logger Logger) Logger {
+	if logger == nil {
+		return NopLogger()
+	}
+	return logger
+}
+
+// LogLevel is the level of a log entry, for LevelEnabler and HandlerOptions.RequestLogLevel.
+// The zero value is LevelDebug.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// LevelEnabler may be implemented by a Logger to tell whether entries at a level would be logged at all, so that
+// the fields of entries that would be discarded aren't built. Loggers not implementing it log every level.
+type LevelEnabler interface {
+	Enabled(level LogLevel) bool
+}
+
+func (nopLogger) Enabled(LogLevel) bool {
+	return false
+}
+
+// logEnabled tells whether logger logs entries at level.
+func logEnabled(logger Logger, level LogLevel) bool {
+	if enabler, ok := logger.(LevelEnabler); ok {
+		return enabler.Enabled(level)
+	}
+	return true
+}
+
+// logAt logs the entry at the given level.
+func logAt(logger Logger, level LogLevel) {
+	switch level {
+	case LevelDebug:
+		logger.Debug()
+	case LevelInfo:
+		logger.Info()
+	case LevelWarn:
+		logger.Warn()
+	default:
+		logger.Error()
+	}
+}
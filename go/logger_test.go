@@ -0,0 +1,130 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type logEntry struct {
+	Level  string
+	Fields map[string]interface{}
+}
+
+// recordingLogger is a Logger recording the entries logged through it and its derived loggers.
+type recordingLogger struct {
+	mu      *sync.Mutex
+	entries *[]logEntry
+	fields  map[string]interface{}
+}
+
+func (l *recordingLogger) init() {
+	if l.mu == nil {
+		l.mu = &sync.Mutex{}
+		l.entries = &[]logEntry{}
+	}
+}
+
+func (l *recordingLogger) WithField(key string, value interface{}) Logger {
+	l.init()
+	fields := map[string]interface{}{key: value}
+	for k, v := range l.fields {
+		if k != key {
+			fields[k] = v
+		}
+	}
+	return &recordingLogger{mu: l.mu, entries: l.entries, fields: fields}
+}
+
+func (l *recordingLogger) WithError(err error) Logger {
+	return l.WithField("error", err)
+}
+
+func (l *recordingLogger) WithContext(context.Context) Logger {
+	l.init()
+	return l
+}
+
+func (l *recordingLogger) log(level string) {
+	l.init()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	*l.entries = append(*l.entries, logEntry{Level: level, Fields: l.fields})
+}
+
+func (l *recordingLogger) Debug() { l.log("debug") }
+func (l *recordingLogger) Info()  { l.log("info") }
+func (l *recordingLogger) Warn()  { l.log("warn") }
+func (l *recordingLogger) Error() { l.log("error") }
+
+func (l *recordingLogger) Entries() []logEntry {
+	l.init()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]logEntry(nil), *l.entries...)
+}
+
+func TestHandlerLogging(t *testing.T) {
+	log := &recordingLogger{}
+	server := httptest.NewServer(Handler(log, NewTestZeroEventHubAPI()))
+	defer server.Close()
+
+	var page EventPageRaw
+	require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{PartitionID: 1, Cursor: "10"}}, 5, &page, "foo"))
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "debug", entries[0].Level)
+	require.Equal(t, "TestZeroEventHubAPI", entries[0].Fields["event"])
+	require.Equal(t, []Cursor{{PartitionID: 1, Cursor: "10"}}, entries[0].Fields["Cursors"])
+	require.Equal(t, 5, entries[0].Fields["PageSizeHint"])
+	require.Equal(t, []string{"foo"}, entries[0].Fields["Headers"])
+
+	// nothing is logged by default
+	require.NotPanics(t, func() {
+		server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+		defer server.Close()
+		_ = NewClient(server.URL, 3).WithLogger(nil).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page)
+	})
+}
+
+// levelLogger is a recordingLogger logging only from a minimum level.
+type levelLogger struct {
+	*recordingLogger
+	min LogLevel
+}
+
+func (l levelLogger) Enabled(level LogLevel) bool {
+	return level >= l.min
+}
+
+func TestHandlerRequestLogOptions(t *testing.T) {
+	log := &recordingLogger{}
+	server := httptest.NewServer(HandlerWithOptions(levelLogger{recordingLogger: log, min: LevelInfo}, NewTestZeroEventHubAPI(),
+		HandlerOptions{RequestLogLevel: LevelInfo, RequestLogSampling: 3}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	var page EventPageRaw
+	for i := 0; i < 7; i++ {
+		require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	}
+	// errors are logged whatever the sampling
+	require.Error(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: cursorReturn500}}, 5, &page))
+	entries := log.Entries()
+	require.Len(t, entries, 4)
+	for _, entry := range entries[:3] {
+		require.Equal(t, "info", entry.Level)
+		require.Equal(t, "TestZeroEventHubAPI", entry.Fields["event"])
+	}
+	require.Equal(t, "TestZeroEventHubAPI.fetch_events_error", entries[3].Fields["event"])
+
+	// by default requests are logged at debug, which the logger skips
+	log = &recordingLogger{}
+	server = httptest.NewServer(Handler(levelLogger{recordingLogger: log, min: LevelInfo}, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	require.Empty(t, log.Entries())
+}
@@ -0,0 +1,17 @@
+module github.com/vippsas/zeroeventhub/go/logrusadapter
+
+go 1.18
+
+require (
+	github.com/sirupsen/logrus v1.8.1
+	github.com/stretchr/testify v1.3.0
+	github.com/vippsas/zeroeventhub/go v0.0.0-20261016210015-7c830b856199
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gorilla/mux v1.8.0 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
+)
@@ -0,0 +1,69 @@
+// Package logrusadapter adapts a logrus logger to zeroeventhub.Logger.
+package logrusadapter
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+type logger struct {
+	logger logrus.FieldLogger
+}
+
+// New returns a zeroeventhub.Logger logging to the given logrus logger (or entry).
+func New(l logrus.FieldLogger) zeroeventhub.Logger {
+	return logger{logger: l}
+}
+
+func (l logger) WithField(key string, value interface{}) zeroeventhub.Logger {
+	return logger{logger: l.logger.WithField(key, value)}
+}
+
+func (l logger) WithError(err error) zeroeventhub.Logger {
+	return logger{logger: l.logger.WithError(err)}
+}
+
+func (l logger) WithContext(ctx context.Context) zeroeventhub.Logger {
+	return logger{logger: l.logger.WithFields(nil).WithContext(ctx)}
+}
+
+// Enabled tells whether the level is enabled on the logrus logger; loggers other than *logrus.Logger and
+// *logrus.Entry log every level.
+func (l logger) Enabled(level zeroeventhub.LogLevel) bool {
+	var base *logrus.Logger
+	switch x := l.logger.(type) {
+	case *logrus.Logger:
+		base = x
+	case *logrus.Entry:
+		base = x.Logger
+	}
+	if base == nil {
+		return true
+	}
+	return base.IsLevelEnabled(logrusLevels[level])
+}
+
+var logrusLevels = map[zeroeventhub.LogLevel]logrus.Level{
+	zeroeventhub.LevelDebug: logrus.DebugLevel,
+	zeroeventhub.LevelInfo:  logrus.InfoLevel,
+	zeroeventhub.LevelWarn:  logrus.WarnLevel,
+	zeroeventhub.LevelError: logrus.ErrorLevel,
+}
+
+func (l logger) Debug() {
+	l.logger.Debug()
+}
+
+func (l logger) Info() {
+	l.logger.Info()
+}
+
+func (l logger) Warn() {
+	l.logger.Warn()
+}
+
+func (l logger) Error() {
+	l.logger.Error()
+}
@@ -0,0 +1,58 @@
+package logrusadapter
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	hookstest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+type emptyAPI struct{}
+
+func (emptyAPI) GetName() string {
+	return "emptyAPI"
+}
+
+func (emptyAPI) GetPartitionCount() int {
+	return 1
+}
+
+func (emptyAPI) FetchEvents(context.Context, []zeroeventhub.Cursor, int, zeroeventhub.EventReceiver, ...string) error {
+	return nil
+}
+
+func TestLogrusAdapter(t *testing.T) {
+	logger, hook := hookstest.NewNullLogger()
+	server := httptest.NewServer(zeroeventhub.Handler(New(logger), emptyAPI{}))
+	defer server.Close()
+
+	client := zeroeventhub.NewClient(server.URL, 2).WithLogger(New(logger.WithField("client", "test")))
+	var page zeroeventhub.EventPageRaw
+	require.Error(t, client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: zeroeventhub.FirstCursor}}, zeroeventhub.DefaultPageSize, &page))
+
+	entries := hook.AllEntries()
+	require.Len(t, entries, 1)
+	require.Equal(t, logrus.ErrorLevel, entries[0].Level)
+	require.Equal(t, "zeroeventhub.unexpected_response_body", entries[0].Data["event"])
+	require.Equal(t, "400", entries[0].Data["responseCode"])
+	require.Equal(t, "test", entries[0].Data["client"])
+	require.EqualError(t, entries[0].Data[logrus.ErrorKey].(error), "unexpected response body: handshake error: partition count mismatch\n")
+	require.NotNil(t, entries[0].Context)
+
+	hook.Reset()
+	client = zeroeventhub.NewClient(server.URL, 1)
+	require.NoError(t, client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: zeroeventhub.FirstCursor}}, zeroeventhub.DefaultPageSize, &page))
+	// requests are logged at debug, which the logger doesn't log
+	require.Len(t, hook.AllEntries(), 0)
+	require.False(t, New(logger).(zeroeventhub.LevelEnabler).Enabled(zeroeventhub.LevelDebug))
+
+	logger.SetLevel(logrus.DebugLevel)
+	require.NoError(t, client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: zeroeventhub.FirstCursor}}, zeroeventhub.DefaultPageSize, &page))
+	require.Len(t, hook.AllEntries(), 1)
+	require.Equal(t, logrus.DebugLevel, hook.LastEntry().Level)
+	require.Equal(t, "emptyAPI", hook.LastEntry().Data["event"])
+}
@@ -0,0 +1,91 @@
+package zeroeventhub
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EventByID can optionally be implemented by an API able to look up a single event by its ID, e.g. for debugging a
+// specific event rather than reading a range. Handler serves it on /feed/v1/event, and Client.FetchEventByID
+// fetches it.
+type EventByID interface {
+	// FetchEventByID returns the event with the given ID in the partition, with all its headers, or
+	// ErrEventNotFound if there is none.
+	FetchEventByID(ctx context.Context, partitionID int, id string) (Envelope, error)
+}
+
+var (
+	// ErrEventByIDNotSupported is returned when the API doesn't implement EventByID.
+	ErrEventByIDNotSupported = NewAPIError("looking up events by ID not supported", http.StatusNotImplemented)
+	// ErrEventNotFound is returned by EventByID for an ID without an event.
+	ErrEventNotFound = NewAPIError("event not found", http.StatusNotFound)
+	// ErrEventIDMissing is returned for a request without the id parameter.
+	ErrEventIDMissing = NewAPIError("event ID missing", http.StatusBadRequest)
+)
+
+func eventByIDHandler(logger Logger, api API) http.HandlerFunc {
+	lookup, ok := api.(EventByID)
+	if !ok {
+		return unsupportedHandler(ErrEventByIDNotSupported)
+	}
+	return func(writer http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		partitionID, badRequest := parsePartition(api, query)
+		if badRequest != nil {
+			http.Error(writer, badRequest.Error(), badRequest.Status())
+			return
+		}
+		id := query.Get("id")
+		if id == "" {
+			http.Error(writer, ErrEventIDMissing.Error(), ErrEventIDMissing.Status())
+			return
+		}
+		var headers []string
+		if query.Has("headers") {
+			headers = strings.Split(strings.TrimSuffix(query.Get("headers"), ","), ",")
+		}
+		event, err := lookup.FetchEventByID(contextWithRequest(request.Context(), request), partitionID, id)
+		if err != nil {
+			respondWithError(logger, api, "event_by_id", writer, err)
+			return
+		}
+		setNDJSONHeaders(writer.Header())
+		serializer := HeaderFilter{Receiver: NewNDJSONEventSerializer(writer), Requested: headers}
+		if err := serializer.Event(partitionID, event.Headers, event.Data); err != nil {
+			logger.WithField("event", api.GetName()+".event_by_id_write_error").WithError(err).Info()
+		}
+	}
+}
+
+// FetchEventByID fetches the event with the given ID in the partition, with the requested headers, from a server
+// whose API implements EventByID. An unknown ID is responded to with 404, and a server without support responds
+// with 501, both returned as a *ResponseError.
+func (c Client) FetchEventByID(ctx context.Context, partitionID int, id string, headers ...string) (Envelope, error) {
+	params := url.Values{"id": {id}}
+	if len(headers) != 0 {
+		params.Set("headers", strings.Join(headers, ","))
+	}
+	var event *Envelope
+	err := c.fetchFromPartition(ctx, "event", partitionID, params, func(body io.Reader) error {
+		err := DecodeStream(body, func(line Line) error {
+			if line.Kind != LineEvent || line.Envelope.PartitionID != partitionID || event != nil {
+				return errors.Errorf("unexpected %s line in event response", line.Kind)
+			}
+			event = line.Envelope
+			return nil
+		})
+		if err == nil && event == nil {
+			return errors.New("no event in event response")
+		}
+		return err
+	})
+	if err != nil {
+		return Envelope{}, err
+	}
+	return *event, nil
+}
@@ -0,0 +1,62 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// lookupAPI is a TestZeroEventHubAPI looking up events by their ID, which it also sends as the id header.
+type lookupAPI struct {
+	*TestZeroEventHubAPI
+}
+
+func (api lookupAPI) FetchEventByID(ctx context.Context, partitionID int, id string) (Envelope, error) {
+	for _, event := range api.partitions[partitionID] {
+		if event.ID == id {
+			headers := map[string]string{"id": id, "content-type": "application/json"}
+			return Envelope{PartitionID: partitionID, Headers: headers, Data: mustMarshalJson(event)}, nil
+		}
+	}
+	return Envelope{}, ErrEventNotFound
+}
+
+func TestFetchEventByID(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, lookupAPI{NewTestZeroEventHubAPI()}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	event, err := client.FetchEventByID(context.Background(), 1, "11111111-0000-0000-0000-00000000002a", "id")
+	require.NoError(t, err)
+	require.Equal(t, Envelope{
+		PartitionID: 1,
+		Headers:     map[string]string{"id": "11111111-0000-0000-0000-00000000002a"},
+		Data:        mustMarshalJson(TestEvent{ID: "11111111-0000-0000-0000-00000000002a", Cursor: 42}),
+	}, event)
+
+	// no headers unless requested, as for FetchEvents
+	event, err = client.FetchEventByID(context.Background(), 0, "00000000-0000-0000-0000-000000000000")
+	require.NoError(t, err)
+	require.Nil(t, event.Headers)
+	require.Equal(t, mustMarshalJson(TestEvent{ID: "00000000-0000-0000-0000-000000000000"}), event.Data)
+
+	// the event is in another partition
+	_, err = client.FetchEventByID(context.Background(), 0, "11111111-0000-0000-0000-00000000002a")
+	require.Equal(t, &ResponseError{StatusCode: http.StatusNotFound, Body: "event not found\n"}, err)
+
+	_, err = client.FetchEventByID(context.Background(), 2, "11111111-0000-0000-0000-00000000002a")
+	require.Equal(t, &ResponseError{StatusCode: http.StatusBadRequest, Body: "partition doesn't exist\n"}, err)
+	_, err = client.FetchEventByID(context.Background(), 0, "")
+	require.Equal(t, &ResponseError{StatusCode: http.StatusBadRequest, Body: "event ID missing\n"}, err)
+}
+
+func TestFetchEventByIDNotSupported(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, 2).FetchEventByID(context.Background(), 0, "00000000-0000-0000-0000-000000000000")
+	require.Equal(t, &ResponseError{StatusCode: http.StatusNotImplemented, Body: "looking up events by ID not supported\n"}, err)
+}
@@ -0,0 +1,85 @@
+package zeroeventhub
+
+import "time"
+
+const (
+	// TimestampHeader is the header with the time an event occurred, in RFC 3339 format, so that consumers can get it
+	// without parsing the data.
+	TimestampHeader = "_ts"
+	// KeyHeader is the header with the ordering key of an event, e.g. the ID of the entity it is about; events with
+	// the same key are in the order they occurred.
+	KeyHeader = "_key"
+)
+
+// Time returns the time of the event from TimestampHeader, and false if it is missing or malformed.
+func (e Envelope) Time() (time.Time, bool) {
+	return EventTime(e.Headers)
+}
+
+// Key returns the ordering key of the event from KeyHeader, and false if it is missing.
+func (e Envelope) Key() (string, bool) {
+	return EventKey(e.Headers)
+}
+
+// Time returns the time of the event from TimestampHeader, and false if it is missing or malformed.
+func (e TypedEnvelope[T]) Time() (time.Time, bool) {
+	return EventTime(e.Headers)
+}
+
+// Key returns the ordering key of the event from KeyHeader, and false if it is missing.
+func (e TypedEnvelope[T]) Key() (string, bool) {
+	return EventKey(e.Headers)
+}
+
+// EventTime returns the time in TimestampHeader of the headers of an event, for EventReceiver implementations.
+func EventTime(headers map[string]string) (time.Time, bool) {
+	value, ok := headers[TimestampHeader]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	return t, err == nil
+}
+
+// EventKey returns the key in KeyHeader of the headers of an event, for EventReceiver implementations.
+func EventKey(headers map[string]string) (string, bool) {
+	key, ok := headers[KeyHeader]
+	return key, ok
+}
+
+// WithMetadata returns a copy of the headers of an event with TimestampHeader and KeyHeader added, for publishers.
+// A zero time or an empty key is left out.
+func WithMetadata(headers map[string]string, t time.Time, key string) map[string]string {
+	result := make(map[string]string, len(headers)+2)
+	for k, v := range headers {
+		result[k] = v
+	}
+	if !t.IsZero() {
+		result[TimestampHeader] = t.Format(time.RFC3339Nano)
+	}
+	if key != "" {
+		result[KeyHeader] = key
+	}
+	return result
+}
+
+// withMetadataHeaders returns the requested headers with TimestampHeader and KeyHeader added, unless already there;
+// see HandlerOptions.MetadataHeaders.
+func withMetadataHeaders(requested []string) []string {
+	result := append([]string(nil), requested...)
+	for _, header := range []string{TimestampHeader, KeyHeader} {
+		if !containsHeader(requested, header) {
+			result = append(result, header)
+		}
+	}
+	return result
+}
+
+func containsHeader(headers []string, header string) bool {
+	for _, h := range headers {
+		if h == header || h == All {
+			return true
+		}
+	}
+	return false
+}
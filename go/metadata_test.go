@@ -0,0 +1,85 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventMetadata(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 500, time.UTC)
+	headers := WithMetadata(map[string]string{"type": "entity"}, at, "a")
+	require.Equal(t, map[string]string{"type": "entity", TimestampHeader: "2024-03-01T12:30:00.0000005Z", KeyHeader: "a"}, headers)
+
+	envelope := Envelope{Headers: headers}
+	ts, ok := envelope.Time()
+	require.True(t, ok)
+	require.True(t, at.Equal(ts))
+	key, ok := envelope.Key()
+	require.True(t, ok)
+	require.Equal(t, "a", key)
+
+	// absent
+	typed := TypedEnvelope[entity]{Headers: WithMetadata(nil, time.Time{}, "")}
+	require.Empty(t, typed.Headers)
+	_, ok = typed.Time()
+	require.False(t, ok)
+	_, ok = typed.Key()
+	require.False(t, ok)
+
+	// malformed
+	for _, value := range []string{"", "2024-03-01", "2024-03-01 12:30:00Z", "1709296200"} {
+		_, ok = EventTime(map[string]string{TimestampHeader: value})
+		require.False(t, ok, value)
+	}
+	ts, ok = EventTime(map[string]string{TimestampHeader: "2024-03-01T13:30:00+01:00"})
+	require.True(t, ok)
+	require.True(t, at.Truncate(time.Second).Equal(ts))
+}
+
+// metadataAPI serves a single event with metadata in partition 0.
+type metadataAPI struct {
+	*TestZeroEventHubAPI
+	requested *[]string
+}
+
+func (api metadataAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	*api.requested = headers
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	if err := r.Event(0, WithMetadata(map[string]string{"type": "entity"}, at, "a"), json.RawMessage(`{}`)); err != nil {
+		return err
+	}
+	return r.Checkpoint(0, "1")
+}
+
+func TestMetadataHeaders(t *testing.T) {
+	var requested []string
+	api := metadataAPI{NewTestZeroEventHubAPI(), &requested}
+	fetch := func(opts HandlerOptions, headers ...string) map[string]string {
+		server := httptest.NewServer(HandlerWithOptions(nil, api, opts))
+		defer server.Close()
+		var page EventPageRaw
+		require.NoError(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page, headers...))
+		require.Len(t, page.Events, 1)
+		return page.Events[0].Headers
+	}
+
+	// only sent on request by default
+	require.Nil(t, fetch(HandlerOptions{}))
+	require.Nil(t, requested)
+	require.Equal(t, map[string]string{KeyHeader: "a"}, fetch(HandlerOptions{}, KeyHeader))
+
+	// always with MetadataHeaders
+	metadata := map[string]string{TimestampHeader: "2024-03-01T12:30:00Z", KeyHeader: "a"}
+	require.Equal(t, metadata, fetch(HandlerOptions{MetadataHeaders: true}))
+	require.Equal(t, []string{TimestampHeader, KeyHeader}, requested)
+	metadata["type"] = "entity"
+	require.Equal(t, metadata, fetch(HandlerOptions{MetadataHeaders: true}, "type", KeyHeader))
+	require.Equal(t, []string{"type", KeyHeader, TimestampHeader}, requested)
+	require.Equal(t, metadata, fetch(HandlerOptions{MetadataHeaders: true}, All))
+	require.Equal(t, []string{All}, requested)
+}
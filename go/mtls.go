@@ -0,0 +1,138 @@
+package zeroeventhub
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WithClientCertificate is a Client method for mutual TLS: the client presents the certificate in certFile, with
+// the private key in keyFile (both PEM), and trusts the server certificates issued by the CAs in caFile (PEM; the
+// system roots if empty). The certificate is read again when either file has changed, at the next new connection,
+// so rotated certificates are picked up without a restart; if the new files can't be loaded, the previous
+// certificate is used. The transport of the HTTP client is copied as by WithTransportDefaults.
+func (c Client) WithClientCertificate(certFile, keyFile, caFile string) (r Client, err error) {
+	certificates := &certificateReloader{certFile: certFile, keyFile: keyFile}
+	if _, err := certificates.get(); err != nil {
+		return c, err
+	}
+	var roots *x509.CertPool
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return c, err
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return c, errors.Errorf("no certificates in %s", caFile)
+		}
+	}
+	return c.withTransport(func(transport *http.Transport) {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if transport.TLSClientConfig != nil {
+			config = transport.TLSClientConfig.Clone()
+		}
+		config.RootCAs = roots
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return certificates.get()
+		}
+		transport.TLSClientConfig = config
+	}), nil
+}
+
+// WithTLSConfig is a Client method for setting the TLS configuration of its transport, e.g. a pool of custom root
+// CAs or a client certificate, without building a transport from scratch; the transport of the HTTP client is
+// copied as by WithTransportDefaults, and config is cloned. It and WithHttpClient are mutually exclusive: a later
+// WithHttpClient replaces the transport, including the TLS configuration.
+func (c Client) WithTLSConfig(config *tls.Config) (r Client) {
+	return c.withTransport(func(transport *http.Transport) {
+		transport.TLSClientConfig = config.Clone()
+	})
+}
+
+// certificateReloader loads a key pair, loading it again when the files have changed.
+type certificateReloader struct {
+	certFile, keyFile string
+
+	lock        sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time
+}
+
+func (c *certificateReloader) get() (*tls.Certificate, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var modTime time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			if c.certificate != nil {
+				return c.certificate, nil
+			}
+			return nil, err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	if c.certificate != nil && !modTime.After(c.modTime) {
+		return c.certificate, nil
+	}
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		// e.g. the certificate has been replaced, but not yet the key
+		if c.certificate != nil {
+			return c.certificate, nil
+		}
+		return nil, err
+	}
+	c.certificate, c.modTime = &certificate, modTime
+	return c.certificate, nil
+}
+
+// PeerIdentity is the identity in the verified client certificate of a request, as found by WithPeerIdentity.
+type PeerIdentity struct {
+	// Subject is the distinguished name of the subject, e.g. "CN=consumer,O=Example".
+	Subject    string
+	CommonName string
+	DNSNames   []string
+	URIs       []string
+}
+
+type peerIdentityContextKey struct{}
+
+// PeerIdentityFromContext returns the identity of the client certificate of the request being served, as found by
+// WithPeerIdentity; ok is false if there is none.
+func PeerIdentityFromContext(ctx context.Context) (identity PeerIdentity, ok bool) {
+	identity, ok = ctx.Value(peerIdentityContextKey{}).(PeerIdentity)
+	return
+}
+
+// WithPeerIdentity wraps a handler, e.g. the one returned by Handler, putting the identity of the client
+// certificate of requests into the request context, where API.FetchEvents can get it with PeerIdentityFromContext,
+// e.g. to know which service consumes which partition; Handler also logs its subject. Only certificates verified
+// by the TLS server are used, so the server must have a tls.Config with ClientCAs and a ClientAuth of
+// tls.VerifyClientCertIfGiven or stricter. Requests without a verified certificate are passed on unchanged.
+func WithPeerIdentity(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.TLS != nil && len(request.TLS.VerifiedChains) > 0 && len(request.TLS.VerifiedChains[0]) > 0 {
+			leaf := request.TLS.VerifiedChains[0][0]
+			identity := PeerIdentity{
+				Subject:    leaf.Subject.String(),
+				CommonName: leaf.Subject.CommonName,
+				DNSNames:   leaf.DNSNames,
+			}
+			for _, uri := range leaf.URIs {
+				identity.URIs = append(identity.URIs, uri.String())
+			}
+			request = request.WithContext(context.WithValue(request.Context(), peerIdentityContextKey{}, identity))
+		}
+		handler.ServeHTTP(writer, request)
+	})
+}
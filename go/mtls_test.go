@@ -0,0 +1,183 @@
+package zeroeventhub
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates for the mutual TLS tests.
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	serial      int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	ca := &testCA{}
+	der, key := ca.issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	})
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	ca.certificate, ca.key = certificate, key
+	return ca
+}
+
+// issue signs the template with the CA, or self-signs it if the CA has no certificate yet.
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate) ([]byte, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ca.serial++
+	template.SerialNumber = big.NewInt(ca.serial)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	parent, signer := template, key
+	if ca.certificate != nil {
+		parent, signer = ca.certificate, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	require.NoError(t, err)
+	return der, key
+}
+
+// writeClientCertificate issues a client certificate and writes it and its key to certFile and keyFile.
+func (ca *testCA) writeClientCertificate(t *testing.T, commonName, certFile, keyFile string) {
+	uri, err := url.Parse("spiffe://example/" + commonName)
+	require.NoError(t, err)
+	der, key := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName, Organization: []string{"Example"}},
+		DNSNames:    []string{commonName + ".example"},
+		URIs:        []*url.URL{uri},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+}
+
+// peerAPI is a TestZeroEventHubAPI recording the peer identity of the last request.
+type peerAPI struct {
+	*TestZeroEventHubAPI
+	peer *PeerIdentity
+}
+
+func (p peerAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	*p.peer, _ = PeerIdentityFromContext(ctx)
+	return p.TestZeroEventHubAPI.FetchEvents(ctx, cursors, pageSizeHint, r, headers...)
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.certificate.Raw}), 0600))
+	ca.writeClientCertificate(t, "consumer", certFile, keyFile)
+
+	serverDER, serverKey := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.certificate)
+	api := peerAPI{TestZeroEventHubAPI: NewTestZeroEventHubAPI(), peer: &PeerIdentity{}}
+	log := &recordingLogger{}
+	server := httptest.NewUnstartedServer(WithPeerIdentity(Handler(log, api)))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := NewClient(server.URL, 2).WithClientCertificate(certFile, keyFile, caFile)
+	require.NoError(t, err)
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	require.Len(t, page.Events, 5)
+	require.Equal(t, PeerIdentity{
+		Subject:    "CN=consumer,O=Example",
+		CommonName: "consumer",
+		DNSNames:   []string{"consumer.example"},
+		URIs:       []string{"spiffe://example/consumer"},
+	}, *api.peer)
+	require.Equal(t, "CN=consumer,O=Example", log.Entries()[0].Fields["Peer"])
+
+	// a rotated certificate is used for new connections
+	ca.writeClientCertificate(t, "rotated", certFile, keyFile)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	client.httpClient.CloseIdleConnections()
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	require.Equal(t, "rotated", api.peer.CommonName)
+
+	// a broken rotation keeps the previous certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0600))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+	client.httpClient.CloseIdleConnections()
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	require.Equal(t, "rotated", api.peer.CommonName)
+
+	// without a client certificate there is no identity, and the server certificate is still checked
+	anonymous, err := NewClient(server.URL, 2).WithClientCertificate(certFile, filepath.Join(dir, "missing.key"), caFile)
+	require.Error(t, err)
+	require.Nil(t, anonymous.httpClient.Transport)
+	anonymous = NewClient(server.URL, 2).WithHttpClient(server.Client())
+	require.NoError(t, anonymous.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	require.Equal(t, PeerIdentity{}, *api.peer)
+	require.Error(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	ca := newTestCA(t)
+	serverDER, serverKey := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	server := httptest.NewUnstartedServer(Handler(nil, NewTestZeroEventHubAPI()))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}}}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+	config := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	client := NewClient(server.URL, 2).WithTLSConfig(config)
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	require.Len(t, page.Events, 5)
+
+	// the configuration is copied, and the default transport left alone
+	config.RootCAs = x509.NewCertPool()
+	client.httpClient.CloseIdleConnections()
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	if defaults := http.DefaultTransport.(*http.Transport).TLSClientConfig; defaults != nil {
+		require.Nil(t, defaults.RootCAs)
+	}
+	require.Error(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+}
@@ -0,0 +1,104 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+// FetchEventsOrdered fetches a page from all the given cursors in a single FetchEvents call and delivers
+// the events to the receiver merged across partitions in the order defined by less (e.g. comparing a
+// sequence number in the data). Within a partition the original order is kept, and every checkpoint is
+// delivered right after the events preceding it in its partition, so the cursors stay consistent.
+//
+// The ordering is only global within the fetched window: the pages of different partitions may end at
+// different points in the ordering, so an event in the next page of one partition can sort before events
+// already delivered from another one. Consumers needing a strict global order must choose page sizes
+// (or an ordering key) where this can't happen, or tolerate it.
+func FetchEventsOrdered(
+	ctx context.Context,
+	fetcher EventFetcher,
+	cursors []Cursor,
+	pageSizeHint int,
+	less func(a, b Envelope) bool,
+	r EventReceiver,
+	headers ...string,
+) error {
+	merger := orderedMerger{streams: make(map[int][]Line)}
+	if err := fetcher.FetchEvents(ctx, cursors, pageSizeHint, &merger, headers...); err != nil {
+		return err
+	}
+	return merger.flush(less, r)
+}
+
+// orderedMerger buffers the events and checkpoints of each partition in arrival order.
+type orderedMerger struct {
+	streams map[int][]Line
+}
+
+func (m *orderedMerger) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	// the fetcher may reuse the data buffer
+	m.streams[partitionID] = append(m.streams[partitionID], Line{
+		Kind:     LineEvent,
+		Envelope: &Envelope{PartitionID: partitionID, Headers: headers, Data: append(json.RawMessage(nil), data...)},
+	})
+	return nil
+}
+
+func (m *orderedMerger) Checkpoint(partitionID int, cursor string) error {
+	m.streams[partitionID] = append(m.streams[partitionID], Line{
+		Kind:       LineCheckpoint,
+		Checkpoint: &Cursor{PartitionID: partitionID, Cursor: cursor},
+	})
+	return nil
+}
+
+// flush does a k-way merge of the partition streams into r; ties are broken by partition ID.
+func (m *orderedMerger) flush(less func(a, b Envelope) bool, r EventReceiver) error {
+	partitionIDs := make([]int, 0, len(m.streams))
+	for partitionID := range m.streams {
+		partitionIDs = append(partitionIDs, partitionID)
+	}
+	sort.Ints(partitionIDs)
+
+	for {
+		// checkpoints at the head of a stream are safe to deliver as all preceding events were delivered
+		for _, partitionID := range partitionIDs {
+			stream := m.streams[partitionID]
+			for len(stream) > 0 && stream[0].Kind == LineCheckpoint {
+				var err error
+				if stream[0].CaughtUp {
+					err = SendCaughtUp(r, partitionID, stream[0].Checkpoint.Cursor)
+				} else {
+					err = r.Checkpoint(partitionID, stream[0].Checkpoint.Cursor)
+				}
+				if err != nil {
+					return err
+				}
+				stream = stream[1:]
+			}
+			m.streams[partitionID] = stream
+		}
+
+		next := -1
+		var nextEnvelope Envelope
+		for _, partitionID := range partitionIDs {
+			stream := m.streams[partitionID]
+			if len(stream) == 0 {
+				continue
+			}
+			envelope := *stream[0].Envelope
+			if next == -1 || less(envelope, nextEnvelope) {
+				next = partitionID
+				nextEnvelope = envelope
+			}
+		}
+		if next == -1 {
+			return nil
+		}
+		if err := r.Event(next, nextEnvelope.Headers, nextEnvelope.Data); err != nil {
+			return err
+		}
+		m.streams[next] = m.streams[next][1:]
+	}
+}
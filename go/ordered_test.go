@@ -0,0 +1,114 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sequencedEvent struct {
+	Seq int
+}
+
+// sequenceFetcher serves a fixed page per partition, with a checkpoint after every event.
+type sequenceFetcher map[int][]int
+
+func (f sequenceFetcher) FetchEvents(_ context.Context, cursors []Cursor, _ int, r EventReceiver, _ ...string) error {
+	for _, cursor := range cursors {
+		for i, seq := range f[cursor.PartitionID] {
+			if err := r.Event(cursor.PartitionID, nil, mustMarshalJson(sequencedEvent{Seq: seq})); err != nil {
+				return err
+			}
+			if err := r.Checkpoint(cursor.PartitionID, fmt.Sprintf("%d", i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// callLog records the calls made to it as strings.
+type callLog []string
+
+func (l *callLog) Event(partitionID int, _ map[string]string, data json.RawMessage) error {
+	*l = append(*l, fmt.Sprintf("event %d %s", partitionID, data))
+	return nil
+}
+
+func (l *callLog) Checkpoint(partitionID int, cursor string) error {
+	*l = append(*l, fmt.Sprintf("checkpoint %d %s", partitionID, cursor))
+	return nil
+}
+
+func TestFetchEventsOrdered(t *testing.T) {
+	fetcher := sequenceFetcher{
+		0: {1, 4, 5, 9},
+		1: {2, 3, 6, 7, 8},
+		2: {5},
+	}
+	bySeq := func(a, b Envelope) bool {
+		var ea, eb sequencedEvent
+		_ = json.Unmarshal(a.Data, &ea)
+		_ = json.Unmarshal(b.Data, &eb)
+		return ea.Seq < eb.Seq
+	}
+
+	var log callLog
+	err := FetchEventsOrdered(context.Background(), fetcher, []Cursor{
+		{PartitionID: 0, Cursor: FirstCursor},
+		{PartitionID: 1, Cursor: FirstCursor},
+		{PartitionID: 2, Cursor: FirstCursor},
+	}, DefaultPageSize, bySeq, &log)
+	require.NoError(t, err)
+	require.Equal(t, callLog{
+		`event 0 {"Seq":1}`,
+		`checkpoint 0 0`,
+		`event 1 {"Seq":2}`,
+		`checkpoint 1 0`,
+		`event 1 {"Seq":3}`,
+		`checkpoint 1 1`,
+		`event 0 {"Seq":4}`,
+		`checkpoint 0 1`,
+		`event 0 {"Seq":5}`,
+		`checkpoint 0 2`,
+		`event 2 {"Seq":5}`,
+		`checkpoint 2 0`,
+		`event 1 {"Seq":6}`,
+		`checkpoint 1 2`,
+		`event 1 {"Seq":7}`,
+		`checkpoint 1 3`,
+		`event 1 {"Seq":8}`,
+		`checkpoint 1 4`,
+		`event 0 {"Seq":9}`,
+		`checkpoint 0 3`,
+	}, log)
+
+	var page EventPageRaw
+	err = FetchEventsOrdered(context.Background(), fetcher, []Cursor{{PartitionID: 1, Cursor: FirstCursor}}, DefaultPageSize, bySeq, &page)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 5)
+	require.Equal(t, map[int]string{1: "4"}, page.Cursors)
+}
+
+func TestFetchEventsOrderedReusedEventData(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+	cursors := []Cursor{{PartitionID: 0, Cursor: "10"}, {PartitionID: 1, Cursor: "20"}}
+	byCursor := func(a, b Envelope) bool {
+		var ea, eb TestEvent
+		_ = json.Unmarshal(a.Data, &ea)
+		_ = json.Unmarshal(b.Data, &eb)
+		return ea.Cursor < eb.Cursor
+	}
+
+	var expected, reused callLog
+	require.NoError(t, FetchEventsOrdered(context.Background(), client, cursors, 5, byCursor, &expected))
+	require.NoError(t, FetchEventsOrdered(context.Background(), client.WithReusedEventData(true), cursors, 5, byCursor, &reused))
+	require.Len(t, reused, 20)
+	require.Equal(t, expected, reused)
+}
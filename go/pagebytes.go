@@ -0,0 +1,72 @@
+package zeroeventhub
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// ErrPageFull is returned by PagedSerializer.Event once the page has reached its size. Handler ends the page
+// normally when FetchEvents returns it, so publishers can simply pass it on.
+var ErrPageFull = errors.New("page full")
+
+// PagedSerializer is an NDJSONEventSerializer limiting the size of a page in bytes rather than events, to keep a
+// publisher streaming a huge result set from overwhelming proxies and clients. Once maxBytes have been written,
+// the next event after a checkpoint is refused with ErrPageFull, so the page always ends with a checkpoint and the
+// client, seeing a short page, fetches the next one from there. Events after the limit are still written until a
+// checkpoint follows them, so a page may exceed maxBytes by the events between two checkpoints. In a page of
+// several partitions, the partitions after the limit get no events; the consumer reads them in a later page.
+type PagedSerializer struct {
+	serializer NDJSONEventSerializer
+	writer     *countingWriter
+	maxBytes   int64
+	// checkpointed is true if the last line written is a checkpoint
+	checkpointed bool
+}
+
+// NewPagedSerializer returns a PagedSerializer writing pages of about maxBytes to writer.
+func NewPagedSerializer(writer io.Writer, maxBytes int) *PagedSerializer {
+	return newPagedSerializer(writer, maxBytes, &lineBuffer{})
+}
+
+func newPagedSerializer(writer io.Writer, maxBytes int, buffer *lineBuffer) *PagedSerializer {
+	counter := &countingWriter{writer: writer}
+	return &PagedSerializer{
+		serializer: NDJSONEventSerializer{writer: counter, buffer: buffer},
+		writer:     counter,
+		maxBytes:   int64(maxBytes),
+	}
+}
+
+func (s *PagedSerializer) Checkpoint(partitionID int, cursor string) error {
+	s.checkpointed = true
+	return s.serializer.Checkpoint(partitionID, cursor)
+}
+
+func (s *PagedSerializer) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if s.checkpointed && s.writer.written >= s.maxBytes {
+		return ErrPageFull
+	}
+	s.checkpointed = false
+	return s.serializer.Event(partitionID, headers, data)
+}
+
+// Written returns the number of bytes written so far.
+func (s *PagedSerializer) Written() int64 {
+	return s.writer.written
+}
+
+var _ EventReceiver = &PagedSerializer{}
+
+// countingWriter counts the bytes written to writer.
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+	return n, err
+}
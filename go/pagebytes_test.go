@@ -0,0 +1,67 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagedSerializer(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewPagedSerializer(&buf, 100)
+	// events are 38 bytes, checkpoints 34
+	event := json.RawMessage(`"0123456789ab"`)
+	require.NoError(t, s.Event(0, nil, event))
+	require.NoError(t, s.Checkpoint(0, "000001"))
+	require.NoError(t, s.Event(0, nil, event))
+	require.NoError(t, s.Event(0, nil, event))
+	require.Equal(t, int64(148), s.Written())
+	// past the limit, but the events need a checkpoint
+	require.NoError(t, s.Event(0, nil, event))
+	require.NoError(t, s.Checkpoint(0, "000004"))
+	require.Equal(t, ErrPageFull, s.Event(0, nil, event))
+	require.Equal(t, ErrPageFull, s.Event(1, nil, event))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 6)
+	require.Equal(t, `{"partition":0,"cursor":"000004"}`, lines[5])
+}
+
+func TestHandlerMaxPageBytes(t *testing.T) {
+	server := httptest.NewServer(HandlerWithOptions(nil, NewTestZeroEventHubAPI(), HandlerOptions{MaxPageBytes: 2000}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	// the pages are cut short by size, and a consumer reading page after page gets every event
+	cursor := FirstCursor
+	next := 0
+	pages := 0
+	for next < 100 {
+		var page EventPageSingleType[TestEvent]
+		require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: cursor}}, 1000, &page))
+		require.NotEmpty(t, page.Events)
+		for _, event := range page.Events {
+			require.Equal(t, next, event.Data.Cursor)
+			next++
+		}
+		require.Equal(t, strconv.Itoa(next-1), page.Cursors[0])
+		cursor = page.Cursors[0]
+		pages++
+	}
+	require.True(t, pages > 3, "%d pages", pages)
+
+	res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=_first&pagesizehint=1000")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	var body bytes.Buffer
+	_, err = body.ReadFrom(res.Body)
+	require.NoError(t, err)
+	// an event of TestZeroEventHubAPI and its checkpoint are less than 200 bytes
+	require.True(t, body.Len() >= 2000 && body.Len() < 2200, "%d bytes", body.Len())
+}
@@ -0,0 +1,116 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// FetchEventsParallel is like fetcher.FetchEvents, but fetches every cursor with a request of its own, all of them
+// concurrently, which helps when the server reads the partitions one after another. The calls to r are
+// serialized, so r doesn't have to be goroutine-safe; the calls for a partition come in order, but those of
+// different partitions are interleaved.
+//
+// When a request fails, the context of the others is cancelled so they stop promptly, and the first error is
+// returned. The receiver may have got a partial page of any partition by then, so on error only the
+// checkpoints already received should be trusted.
+func FetchEventsParallel(ctx context.Context, fetcher EventFetcher, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	return fetchParallel(ctx, fetcher, cursors, pageSizeHint, r, ParallelOptions{}, headers...)
+}
+
+// ParallelOptions configures Client.FetchEventsAllPartitions.
+type ParallelOptions struct {
+	// Parallelism limits the number of requests running at once; 0 means no limit.
+	Parallelism int
+	// ContinueOnError lets the other requests run to completion when one fails, instead of cancelling them.
+	// The first error is returned either way.
+	ContinueOnError bool
+}
+
+// FetchEventsAllPartitions fetches a page of every partition of the feed like FetchEventsParallel, with a request
+// per partition, e.g. to drain a feed with many partitions faster than a single request would. cursors maps
+// partition IDs to cursors; partitions missing from it are read from FirstCursor.
+func (c Client) FetchEventsAllPartitions(ctx context.Context, cursors map[int]string, pageSizeHint int, r EventReceiver, opts ParallelOptions, headers ...string) error {
+	all := make([]Cursor, c.partitionCount)
+	for partitionID := range all {
+		all[partitionID] = Cursor{PartitionID: partitionID, Cursor: FirstCursor}
+	}
+	for partitionID, cursor := range cursors {
+		if partitionID < 0 || partitionID >= c.partitionCount {
+			return ErrPartitionDoesntExist
+		}
+		all[partitionID].Cursor = cursor
+	}
+	return fetchParallel(ctx, c, all, pageSizeHint, r, opts, headers...)
+}
+
+func fetchParallel(ctx context.Context, fetcher EventFetcher, cursors []Cursor, pageSizeHint int, r EventReceiver, opts ParallelOptions, headers ...string) error {
+	if len(cursors) == 0 {
+		return ErrCursorsMissing
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	parallelism := opts.Parallelism
+	if parallelism <= 0 || parallelism > len(cursors) {
+		parallelism = len(cursors)
+	}
+	receiver := &lockedReceiver{receiver: r}
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	work := make(chan Cursor)
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cursor := range work {
+				if err := fetcher.FetchEvents(ctx, []Cursor{cursor}, pageSizeHint, receiver, headers...); err != nil {
+					once.Do(func() {
+						firstErr = err
+						if !opts.ContinueOnError {
+							cancel()
+						}
+					})
+				}
+			}
+		}()
+	}
+	for _, cursor := range cursors {
+		work <- cursor
+	}
+	close(work)
+	wg.Wait()
+	return firstErr
+}
+
+// lockedReceiver serializes the calls to an EventReceiver.
+type lockedReceiver struct {
+	mu        sync.Mutex
+	receiver  EventReceiver
+	pollAfter time.Duration
+}
+
+func (l *lockedReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.receiver.Event(partitionID, headers, data)
+}
+
+func (l *lockedReceiver) Checkpoint(partitionID int, cursor string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.receiver.Checkpoint(partitionID, cursor)
+}
+
+// PollAfter passes the hint on if the receiver implements PollIntervalReceiver; with several requests, the
+// longest hint is the last one passed.
+func (l *lockedReceiver) PollAfter(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if receiver, ok := l.receiver.(PollIntervalReceiver); ok && d > l.pollAfter {
+		l.pollAfter = d
+		receiver.PollAfter(d)
+	}
+}
@@ -0,0 +1,179 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// blockingAPI fails the requests for partition 0 and blocks the others until their request is cancelled.
+type blockingAPI struct {
+	cancelled chan int
+}
+
+func (b blockingAPI) GetName() string {
+	return "blockingAPI"
+}
+
+func (b blockingAPI) GetPartitionCount() int {
+	return 3
+}
+
+func (b blockingAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	if cursors[0].PartitionID == 0 {
+		// let the other requests get going first
+		time.Sleep(50 * time.Millisecond)
+		return err500
+	}
+	if err := r.Event(cursors[0].PartitionID, nil, []byte(`"before blocking"`)); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		b.cancelled <- cursors[0].PartitionID
+		return ctx.Err()
+	case <-time.After(10 * time.Second):
+		return nil
+	}
+}
+
+func TestFetchEventsParallel(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	var page EventPageRaw
+	cursors := []Cursor{{PartitionID: 0, Cursor: FirstCursor}, {PartitionID: 1, Cursor: "9000"}}
+	require.NoError(t, FetchEventsParallel(context.Background(), client, cursors, 2000, &page))
+	require.Len(t, page.Events, 2000+999)
+	require.Equal(t, map[int]string{0: "1999", 1: "9999"}, page.Cursors)
+
+	require.Equal(t, ErrCursorsMissing, FetchEventsParallel(context.Background(), client, nil, DefaultPageSize, &page))
+}
+
+func TestFetchEventsParallelCancelsOnError(t *testing.T) {
+	api := blockingAPI{cancelled: make(chan int, 2)}
+	server := httptest.NewServer(Handler(nil, api))
+	defer server.Close()
+
+	start := time.Now()
+	var page EventPageRaw
+	cursors := []Cursor{{PartitionID: 0, Cursor: FirstCursor}, {PartitionID: 1, Cursor: FirstCursor}, {PartitionID: 2, Cursor: FirstCursor}}
+	err := FetchEventsParallel(context.Background(), NewClient(server.URL, 3), cursors, DefaultPageSize, &page)
+	require.EqualError(t, err, "unexpected response body: Internal server error\n")
+	require.True(t, time.Since(start) < 5*time.Second)
+
+	// the server sees the other requests go away instead of running to completion
+	cancelled := map[int]bool{}
+	for len(cancelled) < 2 {
+		select {
+		case partitionID := <-api.cancelled:
+			cancelled[partitionID] = true
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "requests not cancelled", "%v", cancelled)
+		}
+	}
+}
+
+// slowAPI returns a single event per partition after a delay, failing for partition failPartition, and records
+// the highest number of concurrent calls.
+type slowAPI struct {
+	partitionCount int
+	delay          time.Duration
+	failPartition  int
+	inFlight       *int32
+	maxInFlight    *int32
+}
+
+func newSlowAPI(partitionCount int, delay time.Duration, failPartition int) slowAPI {
+	return slowAPI{partitionCount: partitionCount, delay: delay, failPartition: failPartition, inFlight: new(int32), maxInFlight: new(int32)}
+}
+
+func (s slowAPI) GetName() string {
+	return "slowAPI"
+}
+
+func (s slowAPI) GetPartitionCount() int {
+	return s.partitionCount
+}
+
+func (s slowAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	n := atomic.AddInt32(s.inFlight, 1)
+	defer atomic.AddInt32(s.inFlight, -1)
+	for {
+		max := atomic.LoadInt32(s.maxInFlight)
+		if n <= max || atomic.CompareAndSwapInt32(s.maxInFlight, max, n) {
+			break
+		}
+	}
+	for _, cursor := range cursors {
+		if cursor.PartitionID == s.failPartition {
+			return err500
+		}
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := r.Event(cursor.PartitionID, nil, []byte(strconv.Itoa(cursor.PartitionID))); err != nil {
+			return err
+		}
+		if err := r.Checkpoint(cursor.PartitionID, "1"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestFetchEventsAllPartitions(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	var page EventPageSingleType[TestEvent]
+	require.NoError(t, client.FetchEventsAllPartitions(context.Background(), map[int]string{1: "9000"}, 2000, &page, ParallelOptions{}))
+	require.Equal(t, map[int]string{0: "1999", 1: "9999"}, page.Cursors)
+	// the events of each partition arrive in order, whatever the interleaving
+	next := map[int]int{0: 0, 1: 9001}
+	for _, event := range page.Events {
+		require.Equal(t, next[event.PartitionID], event.Data.Cursor)
+		next[event.PartitionID]++
+	}
+	require.Equal(t, map[int]int{0: 2000, 1: 10000}, next)
+
+	require.Equal(t, ErrPartitionDoesntExist, client.FetchEventsAllPartitions(context.Background(), map[int]string{2: FirstCursor}, DefaultPageSize, &page, ParallelOptions{}))
+}
+
+func TestFetchEventsAllPartitionsParallelism(t *testing.T) {
+	api := newSlowAPI(8, 20*time.Millisecond, -1)
+	server := httptest.NewServer(Handler(nil, api))
+	defer server.Close()
+
+	var page EventPageRaw
+	require.NoError(t, NewClient(server.URL, 8).FetchEventsAllPartitions(context.Background(), nil, DefaultPageSize, &page, ParallelOptions{Parallelism: 3}))
+	require.Len(t, page.Cursors, 8)
+	require.Equal(t, int32(3), atomic.LoadInt32(api.maxInFlight))
+}
+
+func TestFetchEventsAllPartitionsErrors(t *testing.T) {
+	api := newSlowAPI(4, 200*time.Millisecond, 0)
+	server := httptest.NewServer(Handler(nil, api))
+	defer server.Close()
+	client := NewClient(server.URL, 4)
+
+	// by default the failure cancels the other requests
+	var page EventPageRaw
+	err := client.FetchEventsAllPartitions(context.Background(), nil, DefaultPageSize, &page, ParallelOptions{})
+	require.EqualError(t, err, "unexpected response body: Internal server error\n")
+	require.Empty(t, page.Cursors)
+
+	page = EventPageRaw{}
+	err = client.FetchEventsAllPartitions(context.Background(), nil, DefaultPageSize, &page, ParallelOptions{ContinueOnError: true})
+	require.EqualError(t, err, "unexpected response body: Internal server error\n")
+	require.Equal(t, map[int]string{1: "1", 2: "1", 3: "1"}, page.Cursors)
+}
@@ -0,0 +1,25 @@
+module github.com/vippsas/zeroeventhub/go/parquet
+
+go 1.24.9
+
+require (
+	github.com/parquet-go/parquet-go v0.32.0
+	github.com/pkg/errors v0.9.1
+	github.com/stretchr/testify v1.3.0
+	github.com/vippsas/zeroeventhub/go v0.0.0-20261016210015-7c830b856199
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/gorilla/mux v1.8.0 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/parquet-go/bitpack v1.0.0 // indirect
+	github.com/parquet-go/jsonlite v1.0.0 // indirect
+	github.com/pierrec/lz4/v4 v4.1.21 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/twpayne/go-geom v1.6.1 // indirect
+	golang.org/x/sys v0.38.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
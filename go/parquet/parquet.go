@@ -0,0 +1,83 @@
+// Package zeroeventhubparquet exports events to Parquet files, e.g. to land a feed in a data lake while
+// reconstituting it. It is a module of its own, to keep the Parquet dependency out of the zeroeventhub package.
+package zeroeventhubparquet
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/pkg/errors"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// DefaultBatchSize is the number of rows Receiver buffers before writing them to the Parquet writer.
+const DefaultBatchSize = 1000
+
+// Receiver implements zeroeventhub.EventReceiver by decoding the data of every event into a T, and writing it as a
+// row of a Parquet file, with the schema of T (see parquet.SchemaOf for the struct tags). Rows are buffered, and
+// the file is only complete once Close has been called; Flush ends a row group, e.g. at the end of every page, so
+// that the rows written so far don't have to be kept in memory. Cursors records the last checkpoint of each
+// partition, to store once the file has been closed.
+type Receiver[T any] struct {
+	writer    *parquet.GenericWriter[T]
+	rows      []T
+	batchSize int
+	Cursors   map[int]string
+}
+
+// NewReceiver returns a Receiver writing a Parquet file to output. Closing the receiver doesn't close output.
+func NewReceiver[T any](output io.Writer, options ...parquet.WriterOption) *Receiver[T] {
+	return &Receiver[T]{
+		writer:    parquet.NewGenericWriter[T](output, options...),
+		batchSize: DefaultBatchSize,
+		Cursors:   map[int]string{},
+	}
+}
+
+func (r *Receiver[T]) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	var row T
+	if err := json.Unmarshal(data, &row); err != nil {
+		return errors.Wrapf(err, "event in partition %d", partitionID)
+	}
+	r.rows = append(r.rows, row)
+	if len(r.rows) >= r.batchSize {
+		return r.write()
+	}
+	return nil
+}
+
+func (r *Receiver[T]) Checkpoint(partitionID int, cursor string) error {
+	r.Cursors[partitionID] = cursor
+	return nil
+}
+
+// write passes the buffered rows on to the Parquet writer.
+func (r *Receiver[T]) write() error {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	if _, err := r.writer.Write(r.rows); err != nil {
+		return err
+	}
+	r.rows = r.rows[:0]
+	return nil
+}
+
+// Flush writes the buffered rows to output as a row group.
+func (r *Receiver[T]) Flush() error {
+	if err := r.write(); err != nil {
+		return err
+	}
+	return r.writer.Flush()
+}
+
+// Close writes the buffered rows and the footer of the file to output. The receiver can't be used afterwards.
+func (r *Receiver[T]) Close() error {
+	if err := r.write(); err != nil {
+		return err
+	}
+	return r.writer.Close()
+}
+
+var _ zeroeventhub.EventReceiver = &Receiver[struct{}]{}
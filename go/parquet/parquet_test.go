@@ -0,0 +1,83 @@
+package zeroeventhubparquet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+type testEvent struct {
+	ID      string `json:"ID" parquet:"id"`
+	Version int    `json:"Version" parquet:"version"`
+	Cursor  int    `json:"Cursor" parquet:"cursor"`
+}
+
+// testAPI serves 25 events in partition 0, with the event's index as cursor.
+type testAPI struct{}
+
+func (testAPI) GetName() string {
+	return "testAPI"
+}
+
+func (testAPI) GetPartitionCount() int {
+	return 1
+}
+
+func (testAPI) FetchEvents(ctx context.Context, cursors []zeroeventhub.Cursor, pageSizeHint int, r zeroeventhub.EventReceiver, headers ...string) error {
+	start := 0
+	if cursors[0].Cursor != zeroeventhub.FirstCursor {
+		if _, err := fmt.Sscan(cursors[0].Cursor, &start); err != nil {
+			return err
+		}
+		start++
+	}
+	for i := start; i < 25 && i < start+pageSizeHint; i++ {
+		data, err := json.Marshal(testEvent{ID: fmt.Sprintf("event-%d", i), Version: 1, Cursor: i})
+		if err != nil {
+			return err
+		}
+		if err := r.Event(0, nil, data); err != nil {
+			return err
+		}
+		if err := r.Checkpoint(0, fmt.Sprint(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestReceiver(t *testing.T) {
+	server := httptest.NewServer(zeroeventhub.Handler(nil, testAPI{}))
+	defer server.Close()
+	client := zeroeventhub.NewClient(server.URL, 1)
+
+	var file bytes.Buffer
+	receiver := NewReceiver[testEvent](&file)
+	receiver.batchSize = 4
+	cursor := zeroeventhub.FirstCursor
+	for cursor != "24" {
+		require.NoError(t, client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: cursor}}, 10, receiver))
+		require.NoError(t, receiver.Flush())
+		cursor = receiver.Cursors[0]
+	}
+	require.NoError(t, receiver.Close())
+
+	rows, err := parquet.Read[testEvent](bytes.NewReader(file.Bytes()), int64(file.Len()))
+	require.NoError(t, err)
+	require.Len(t, rows, 25)
+	for i, row := range rows {
+		require.Equal(t, testEvent{ID: fmt.Sprintf("event-%d", i), Version: 1, Cursor: i}, row)
+	}
+	f, err := parquet.OpenFile(bytes.NewReader(file.Bytes()), int64(file.Len()))
+	require.NoError(t, err)
+	require.Len(t, f.RowGroups(), 3)
+
+	require.Error(t, NewReceiver[testEvent](&file).Event(0, nil, json.RawMessage(`{"Cursor":"x"}`)))
+}
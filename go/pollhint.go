@@ -0,0 +1,56 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// PollAfterHeader is the response header with the number of milliseconds the server asks the client to wait
+// before fetching again, e.g. to make consumers of an idle feed back off.
+const PollAfterHeader = "X-Poll-After-Ms"
+
+// PollIntervalHinter can optionally be implemented by an API to tell clients how long to wait before polling
+// again. Handler calls it before FetchEvents, as the hint is sent as a response header (PollAfterHeader).
+type PollIntervalHinter interface {
+	// PollAfter returns how long the client fetching from the cursors should wait before fetching again, or
+	// 0 for no hint.
+	PollAfter(ctx context.Context, cursors []Cursor) time.Duration
+}
+
+// PollIntervalReceiver can optionally be implemented by an EventReceiver to get the poll interval hinted by
+// the server (see PollIntervalHinter). Client calls PollAfter before passing on any events, and only if the
+// server gave a hint; polling loops should then wait that long instead of their fixed interval.
+type PollIntervalReceiver interface {
+	PollAfter(d time.Duration)
+}
+
+// setPollAfterHeader sets PollAfterHeader if the API implements PollIntervalHinter and gives a hint.
+func setPollAfterHeader(ctx context.Context, writer http.ResponseWriter, api API, cursors []Cursor) {
+	hinter, ok := api.(PollIntervalHinter)
+	if !ok {
+		return
+	}
+	if d := hinter.PollAfter(ctx, cursors); d > 0 {
+		writer.Header().Set(PollAfterHeader, strconv.FormatInt(d.Milliseconds(), 10))
+	}
+}
+
+// passPollAfter passes the hint of the response on to the receiver if it implements PollIntervalReceiver.
+// A malformed or negative hint is ignored.
+func passPollAfter(res *http.Response, r EventReceiver) {
+	receiver, ok := r.(PollIntervalReceiver)
+	if !ok {
+		return
+	}
+	value := res.Header.Get(PollAfterHeader)
+	if value == "" {
+		return
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms < 0 {
+		return
+	}
+	receiver.PollAfter(time.Duration(ms) * time.Millisecond)
+}
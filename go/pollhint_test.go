@@ -0,0 +1,72 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// hintingAPI hints clients at the end of a partition to wait.
+type hintingAPI struct {
+	*TestZeroEventHubAPI
+	wait time.Duration
+}
+
+func (h hintingAPI) PollAfter(ctx context.Context, cursors []Cursor) time.Duration {
+	for _, cursor := range cursors {
+		if cursor.Cursor != "9999" {
+			return 0
+		}
+	}
+	return h.wait
+}
+
+// pollAfterPage is an EventPageRaw recording the poll interval hint.
+type pollAfterPage struct {
+	EventPageRaw
+	pollAfter time.Duration
+}
+
+func (p *pollAfterPage) PollAfter(d time.Duration) {
+	p.pollAfter = d
+}
+
+func TestPollAfter(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, hintingAPI{TestZeroEventHubAPI: NewTestZeroEventHubAPI(), wait: 1500 * time.Millisecond}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	var page pollAfterPage
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: 0, Cursor: "9999"}}, DefaultPageSize, &page))
+	require.Empty(t, page.Events)
+	require.Equal(t, 1500*time.Millisecond, page.pollAfter)
+
+	// no hint while there are events left
+	page = pollAfterPage{}
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: 0, Cursor: "9990"}}, DefaultPageSize, &page))
+	require.Len(t, page.Events, 9)
+	require.Zero(t, page.pollAfter)
+
+	// the longest hint of parallel requests is passed on
+	page = pollAfterPage{}
+	require.NoError(t, FetchEventsParallel(context.Background(), client, []Cursor{{PartitionID: 0, Cursor: "9999"}, {PartitionID: 1, Cursor: "9999"}}, DefaultPageSize, &page))
+	require.Equal(t, 1500*time.Millisecond, page.pollAfter)
+}
+
+func TestPollAfterMalformed(t *testing.T) {
+	for _, value := range []string{"soon", "-5", "1.5"} {
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			writer.Header().Set(PollAfterHeader, value)
+			_, _ = writer.Write([]byte(`{"partition":0,"cursor":"1"}` + "\n"))
+		}))
+		var page pollAfterPage
+		require.NoError(t, NewClient(server.URL, 1).FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page))
+		require.Zero(t, page.pollAfter, value)
+		require.Equal(t, "1", page.Cursors[0])
+		server.Close()
+	}
+}
@@ -0,0 +1,50 @@
+//go:build profbench
+
+package zeroeventhub
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+var profileDir = flag.String("profdir", "profiles", "directory to write the profiles of the benchmarks to")
+
+// profile starts a CPU profile of the benchmark, returning a function stopping it and writing a heap profile.
+// The profiles are written to <profdir>/<benchmark>.{cpu,heap}.pprof; as a benchmark is run with increasing
+// b.N, the files end up with the profiles of the last run. Use it as `defer profile(b)()` after any setup.
+func profile(b *testing.B) func() {
+	b.Helper()
+	if err := os.MkdirAll(*profileDir, 0o755); err != nil {
+		b.Fatal(err)
+	}
+	path := filepath.Join(*profileDir, strings.ReplaceAll(b.Name(), "/", "_"))
+	cpu, err := os.Create(path + ".cpu.pprof")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		_ = cpu.Close()
+		b.Fatal(err)
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		if err := cpu.Close(); err != nil {
+			b.Error(err)
+		}
+		heap, err := os.Create(path + ".heap.pprof")
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		defer heap.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(heap); err != nil {
+			b.Error(err)
+		}
+	}
+}
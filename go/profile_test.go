@@ -0,0 +1,12 @@
+//go:build !profbench
+
+package zeroeventhub
+
+import (
+	"testing"
+)
+
+// profile does nothing without the profbench build tag.
+func profile(*testing.B) func() {
+	return func() {}
+}
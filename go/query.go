@@ -0,0 +1,102 @@
+package zeroeventhub
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrReservedQueryParam is returned by the requests of Client, like FetchEvents and TailCursor, when a query parameter added with
+// WithQueryParam is one of the parameters of the protocol.
+var ErrReservedQueryParam = errors.New("query parameter is reserved by the protocol")
+
+var reservedQueryParam = regexp.MustCompile(`^(n|pagesizehint|headers|direction|partition|from|inclusive|caughtup|id|cursor[0-9]+)$`)
+
+type queryParam struct {
+	key, value string
+}
+
+// WithQueryParam is a Client method for adding a query parameter to every request, e.g. a tenant ID or a feature
+// flag understood by the server. Unlike WithRequestProcessor, it doesn't require touching the URL of the request.
+// A parameter that is part of the protocol (n, cursorN, pagesizehint, ...) makes the requests fail with
+// ErrReservedQueryParam.
+func (c Client) WithQueryParam(key, value string) (r Client) {
+	return c.WithQueryParams(url.Values{key: {value}})
+}
+
+// WithQueryParams is like WithQueryParam for several parameters at once.
+func (c Client) WithQueryParams(params ...url.Values) (r Client) {
+	r = c
+	r.queryParams = append([]queryParam(nil), c.queryParams...)
+	for _, values := range params {
+		for key, vs := range values {
+			for _, v := range vs {
+				r.queryParams = append(r.queryParams, queryParam{key: key, value: v})
+			}
+		}
+	}
+	return
+}
+
+// WithParamNames is a Client method for talking to servers using other names than the protocol for its query
+// parameters, e.g. a legacy system expecting the cursor in `since`. names maps the names of the protocol (n,
+// pagesizehint, headers, direction, partition, from, inclusive, caughtup, id, cursorN) to the names to use instead. A cursor parameter is
+// renamed by its full name if it is in names (e.g. "cursor0": "since"); otherwise "cursor" renames the prefix
+// (e.g. "cursor": "c" sends cursor1 as c1). Parameters not in names keep their names. This is an interop shim;
+// servers using Handler expect the protocol names.
+func (c Client) WithParamNames(names map[string]string) (r Client) {
+	r = c
+	r.paramNames = make(map[string]string, len(names))
+	for from, to := range names {
+		r.paramNames[from] = to
+	}
+	return
+}
+
+// paramName returns the name to use for the protocol query parameter, as configured by WithParamNames.
+func (c Client) paramName(name string) string {
+	if renamed, ok := c.paramNames[name]; ok {
+		return renamed
+	}
+	if prefix, ok := c.paramNames["cursor"]; ok && reservedQueryParam.MatchString(name) && strings.HasPrefix(name, "cursor") {
+		return prefix + strings.TrimPrefix(name, "cursor")
+	}
+	return name
+}
+
+// renameParams renames the protocol query parameters in q as configured by WithParamNames.
+func (c Client) renameParams(q url.Values) {
+	if len(c.paramNames) == 0 {
+		return
+	}
+	renamed := make(url.Values, len(q))
+	for name, values := range q {
+		renamed[c.paramName(name)] = values
+		delete(q, name)
+	}
+	for name, values := range renamed {
+		q[name] = values
+	}
+}
+
+func (c Client) checkQueryParams() error {
+	for _, param := range c.queryParams {
+		if reservedQueryParam.MatchString(param.key) {
+			return errors.Wrapf(ErrReservedQueryParam, "%q", param.key)
+		}
+		for _, renamed := range c.paramNames {
+			if param.key == renamed {
+				return errors.Wrapf(ErrReservedQueryParam, "%q", param.key)
+			}
+		}
+	}
+	return nil
+}
+
+func (c Client) addQueryParams(q url.Values) {
+	for _, param := range c.queryParams {
+		q.Add(param.key, param.value)
+	}
+}
@@ -0,0 +1,96 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQueryParam(t *testing.T) {
+	handler := Handler(nil, NewTestZeroEventHubAPI())
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		queries = append(queries, request.URL.Query())
+		handler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	base := NewClient(server.URL, 2).WithQueryParam("tenant", "t1")
+	client := base.WithQueryParams(url.Values{"flag": {"a", "b"}})
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "1"}}, 10, &page))
+	require.Len(t, page.Events, 10)
+	_, err := client.TailCursor(context.Background(), 0)
+	require.NoError(t, err)
+	require.NoError(t, base.FetchEvents(context.Background(), []Cursor{{Cursor: "1"}}, 10, &page))
+
+	require.Len(t, queries, 3)
+	for _, query := range queries[:2] {
+		require.Equal(t, "t1", query.Get("tenant"))
+		require.Equal(t, []string{"a", "b"}, query["flag"])
+	}
+	require.Equal(t, "2", queries[0].Get("n"))
+	require.Equal(t, "1", queries[0].Get("cursor0"))
+	require.Equal(t, "t1", queries[2].Get("tenant"))
+	require.False(t, queries[2].Has("flag"))
+
+	for _, key := range []string{"n", "cursor0", "cursor12", "pagesizehint", "headers", "direction", "partition", "from", "inclusive", CaughtUpParam, "id"} {
+		err := client.WithQueryParam(key, "x").FetchEvents(context.Background(), []Cursor{{Cursor: "1"}}, 10, &page)
+		require.True(t, errors.Is(err, ErrReservedQueryParam), key)
+	}
+	_, err = client.WithQueryParam("id", "x").FetchEventByID(context.Background(), 0, "00000000-0000-0000-0000-000000000000")
+	require.True(t, errors.Is(err, ErrReservedQueryParam))
+	require.NoError(t, client.WithQueryParam("cursor", "x").FetchEvents(context.Background(), []Cursor{{Cursor: "1"}}, 10, &page))
+	require.Len(t, queries, 4)
+}
+
+func TestWithParamNames(t *testing.T) {
+	// a server expecting the cursor of partition 0 in since, the partition in p and the cursors of other partitions
+	// in c1, c2, ...; it maps them back to the protocol names for Handler
+	handler := Handler(nil, NewTestZeroEventHubAPI())
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		queries = append(queries, request.URL.Query())
+		query := request.URL.Query()
+		for _, protocolName := range []string{"cursor0", "cursor1", "partition"} {
+			if query.Has(protocolName) {
+				http.Error(writer, "unexpected "+protocolName, http.StatusBadRequest)
+				return
+			}
+		}
+		for legacyName, protocolName := range map[string]string{"since": "cursor0", "c1": "cursor1", "p": "partition"} {
+			if query.Has(legacyName) {
+				query[protocolName] = query[legacyName]
+				delete(query, legacyName)
+			}
+		}
+		request.URL.RawQuery = query.Encode()
+		handler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, 2).
+		WithParamNames(map[string]string{"cursor0": "since", "cursor": "c", "partition": "p"}).
+		WithQueryParam("tenant", "t1")
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: 0, Cursor: "1"}, {PartitionID: 1, Cursor: "5"}}, 10, &page))
+	require.Len(t, page.Events, 20)
+	cursor, err := client.TailCursor(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotEmpty(t, cursor)
+
+	require.Len(t, queries, 2)
+	require.Equal(t, url.Values{"n": {"2"}, "pagesizehint": {"10"}, "since": {"1"}, "c1": {"5"}, "tenant": {"t1"}}, queries[0])
+	require.Equal(t, url.Values{"n": {"2"}, "p": {"1"}, "tenant": {"t1"}}, queries[1])
+
+	// the new names are reserved as well
+	err = client.WithQueryParam("since", "x").FetchEvents(context.Background(), []Cursor{{Cursor: "1"}}, 10, &page)
+	require.True(t, errors.Is(err, ErrReservedQueryParam))
+	// and the defaults are unchanged
+	require.Error(t, NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: "1"}}, 10, &page))
+}
@@ -0,0 +1,133 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by copies of a Client. In events mode a token is consumed per event
+// received, and a request waits until the bucket is no longer in debt; in requests mode each request takes a token.
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	last     time.Time
+	perEvent bool
+	now      func() time.Time
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+// newRateLimiter returns a token bucket refilled with rate tokens per second, which must be positive: the waits are
+// computed by dividing by it.
+func newRateLimiter(rate float64, burst int, perEvent bool) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:     rate,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		perEvent: perEvent,
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// refill adds the tokens accumulated since the last call; l.mu must be held.
+func (l *rateLimiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// wait blocks until a request may be sent, or ctx is done. In requests mode the token is taken before sleeping, so
+// that concurrent callers queue up behind each other rather than all waking up at once.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	l.refill()
+	if !l.perEvent {
+		l.tokens--
+	}
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay > 0 {
+		if err := l.sleep(ctx, delay); err != nil {
+			if !l.perEvent {
+				// no request is sent, so the token is given back
+				l.consume(-1)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// consume takes n tokens, possibly putting the bucket in debt.
+func (l *rateLimiter) consume(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens -= float64(n)
+}
+
+// eventCounter implements EventReceiver by counting the events passed on to another EventReceiver.
+type eventCounter struct {
+	receiver EventReceiver
+	events   int
+}
+
+func (c *eventCounter) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	c.events++
+	return c.receiver.Event(partitionID, headers, data)
+}
+
+func (c *eventCounter) Checkpoint(partitionID int, cursor string) error {
+	return c.receiver.Checkpoint(partitionID, cursor)
+}
+
+// WithRateLimit is a Client method for limiting the rate of events consumed, e.g. to avoid degrading the publisher
+// while catching up. Before each FetchEvents request the client waits long enough to keep the average rate under
+// eventsPerSecond, allowing bursts of up to burst events. Waiting is interrupted when the context is done.
+// The returned Client and copies made from it share the limit. Pass eventsPerSecond 0 (or less) to remove the limit.
+func (c Client) WithRateLimit(eventsPerSecond float64, burst int) (r Client) {
+	r = c
+	r.rateLimiter = nil
+	if eventsPerSecond > 0 {
+		r.rateLimiter = newRateLimiter(eventsPerSecond, burst, true)
+	}
+	return
+}
+
+// WithRequestRateLimit is like WithRateLimit, but limits the number of FetchEvents requests per second instead.
+// Pass requestsPerSecond 0 (or less) to remove the limit.
+func (c Client) WithRequestRateLimit(requestsPerSecond float64, burst int) (r Client) {
+	r = c
+	r.rateLimiter = nil
+	if requestsPerSecond > 0 {
+		r.rateLimiter = newRateLimiter(requestsPerSecond, burst, false)
+	}
+	return
+}
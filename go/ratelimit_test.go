@@ -0,0 +1,154 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func TestRateLimit(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		client Client
+
+		expectedEvents   int
+		expectedRequests int
+	}{
+		{
+			name:   "events per second",
+			client: NewClient(server.URL, 2).WithRateLimit(50, 100),
+			// the initial burst plus 50 events/sec; the last page may overshoot by up to a page
+			expectedEvents:   100 + 60*50 + 20,
+			expectedRequests: (100 + 60*50 + 20) / 20,
+		},
+		{
+			name:             "requests per second",
+			client:           NewClient(server.URL, 2).WithRequestRateLimit(2, 5),
+			expectedEvents:   (5 + 60*2) * 20,
+			expectedRequests: 5 + 60*2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := &fakeClock{now: start}
+			test.client.rateLimiter.now = clock.Now
+			test.client.rateLimiter.sleep = clock.Sleep
+
+			cursor := FirstCursor
+			events, requests := 0, 0
+			for {
+				var page EventPageRaw
+				err := test.client.FetchEvents(context.Background(), []Cursor{{Cursor: cursor}}, 20, &page)
+				require.NoError(t, err)
+				if clock.now.Sub(start) > time.Minute {
+					break
+				}
+				events += len(page.Events)
+				requests++
+				cursor = page.Cursors[0]
+			}
+			require.Equal(t, test.expectedEvents, events)
+			require.Equal(t, test.expectedRequests, requests)
+			lastCursor, _ := strconv.Atoi(cursor)
+			require.Equal(t, events-1, lastCursor)
+		})
+	}
+}
+
+func TestRateLimitCancellation(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	client := NewClient(server.URL, 2).WithRequestRateLimit(0.001, 1)
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, 1, &page))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := client.FetchEvents(ctx, []Cursor{{Cursor: FirstCursor}}, 1, &page)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+
+	// a rate of 0 or less removes the limit rather than dividing by it
+	for _, client := range []Client{
+		NewClient(server.URL, 2).WithRateLimit(0, 1),
+		NewClient(server.URL, 2).WithRequestRateLimit(-1, 1),
+		NewClient(server.URL, 2).WithRequestRateLimit(0.001, 1).WithRequestRateLimit(0, 1),
+	} {
+		require.Nil(t, client.rateLimiter)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		for i := 0; i < 5; i++ {
+			var page EventPageRaw
+			require.NoError(t, client.FetchEvents(ctx, []Cursor{{Cursor: FirstCursor}}, 20, &page))
+		}
+		cancel()
+	}
+}
+
+func TestRateLimitConcurrentWait(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(10, 1, false)
+	limiter.now = func() time.Time { return now }
+	var mu sync.Mutex
+	var delays []time.Duration
+	// the callers that have to wait all sleep at the same time
+	var asleep sync.WaitGroup
+	asleep.Add(4)
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		mu.Lock()
+		delays = append(delays, d)
+		mu.Unlock()
+		asleep.Done()
+		asleep.Wait()
+		return nil
+	}
+
+	// every caller reserves its token before sleeping, so they are spread out instead of waking up together
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, limiter.wait(context.Background()))
+		}()
+	}
+	wg.Wait()
+	sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+	require.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 400 * time.Millisecond}, delays)
+
+	// a cancelled wait gives its token back
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter.sleep = sleepContext
+	require.Equal(t, context.Canceled, limiter.wait(ctx))
+	require.Equal(t, -4.0, limiter.tokens)
+}
@@ -0,0 +1,90 @@
+package zeroeventhub
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ErrRedirected is matched by the RedirectError returned when the server redirects a Client refusing redirects.
+var ErrRedirected = errors.New("redirected")
+
+// RedirectError is returned by the requests of a Client with WithFollowRedirects(false) when the server responds
+// with a redirect, so that callers can update the URL they are configured with to Location.
+type RedirectError struct {
+	StatusCode int
+	Location   string
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("redirected with status %d to %s", e.StatusCode, e.Location)
+}
+
+func (e *RedirectError) Is(target error) bool {
+	return target == ErrRedirected
+}
+
+type redirectPolicy int
+
+const (
+	// redirectDefault leaves redirects to the CheckRedirect of the HTTP client
+	redirectDefault redirectPolicy = iota
+	redirectFollow
+	redirectRefuse
+)
+
+// maxRedirects is the number of redirects followed, as by the default policy of http.Client.
+const maxRedirects = 10
+
+// WithFollowRedirects is a Client method for controlling redirects explicitly instead of leaving them to the HTTP
+// client. If follow is true, redirects are followed with the GET method; the query of the original request is kept
+// when the Location has none, and requests signed with WithHMACSigning are signed again for the new URL (unless
+// the redirect is to another host, where the HTTP client drops the Authorization header). If follow is false, the
+// requests fail with a RedirectError.
+func (c Client) WithFollowRedirects(follow bool) (r Client) {
+	r = c
+	r.redirects = redirectRefuse
+	if follow {
+		r.redirects = redirectFollow
+	}
+	return
+}
+
+// redirectingClient returns the HTTP client to send requests with, with its CheckRedirect set by the redirect
+// policy.
+func (c Client) redirectingClient() *http.Client {
+	if c.redirects == redirectDefault {
+		return c.httpClient
+	}
+	httpClient := *c.httpClient
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if c.redirects == redirectRefuse {
+			return &RedirectError{StatusCode: req.Response.StatusCode, Location: req.URL.String()}
+		}
+		if len(via) >= maxRedirects {
+			return errors.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		original := via[0]
+		req.Method = http.MethodGet
+		if req.URL.RawQuery == "" {
+			req.URL.RawQuery = original.URL.RawQuery
+		}
+		if req.Header.Get("Authorization") != "" {
+			return c.hmacSigner.sign(req)
+		}
+		return nil
+	}
+	return &httpClient
+}
+
+// send sends a single request with the HTTP client.
+func (c Client) send(req *http.Request) (*http.Response, error) {
+	res, err := c.redirectingClient().Do(req)
+	var redirect *RedirectError
+	if errors.As(err, &redirect) {
+		// rather than the *url.Error wrapping it
+		return nil, redirect
+	}
+	return res, err
+}
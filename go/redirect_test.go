@@ -0,0 +1,58 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFollowRedirects(t *testing.T) {
+	handler := RequireHMAC(Handler(nil, NewTestZeroEventHubAPI()), lookupHMACSecret, time.Minute, NewMemoryNonceCache())
+	var served []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		served = append(served, request.URL.Path)
+		switch {
+		case strings.HasPrefix(request.URL.Path, "/old/"):
+			// the feed has moved; the Location has no query
+			http.Redirect(writer, request, strings.TrimPrefix(request.URL.Path, "/old"), http.StatusMovedPermanently)
+		case request.URL.Path == "/loop/feed/v1":
+			http.Redirect(writer, request, request.URL.Path, http.StatusFound)
+		default:
+			handler.ServeHTTP(writer, request)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL+"/old", 2).WithHMACSigning("partner", hmacSecrets["partner"])
+
+	var page EventPageRaw
+	following := client.WithFollowRedirects(true)
+	require.NoError(t, following.FetchEvents(context.Background(), []Cursor{{PartitionID: 1, Cursor: "10"}}, 5, &page))
+	require.Len(t, page.Events, 5)
+	require.Equal(t, "15", page.Cursors[1])
+	_, err := following.TailCursor(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/old/feed/v1", "/feed/v1", "/old/feed/v1/tail", "/feed/v1/tail"}, served)
+
+	// the HTTP client on its own drops the query
+	err = client.FetchEvents(context.Background(), []Cursor{{PartitionID: 1, Cursor: "10"}}, 5, &page)
+	var responseErr *ResponseError
+	require.True(t, errors.As(err, &responseErr), "%v", err)
+
+	served = nil
+	err = client.WithFollowRedirects(false).FetchEvents(context.Background(), []Cursor{{PartitionID: 1, Cursor: "10"}}, 5, &page)
+	require.True(t, errors.Is(err, ErrRedirected))
+	require.Equal(t, &RedirectError{StatusCode: http.StatusMovedPermanently, Location: server.URL + "/feed/v1"}, err)
+	require.Equal(t, []string{"/old/feed/v1"}, served)
+	_, err = client.WithFollowRedirects(false).TailCursor(context.Background(), 0)
+	require.True(t, errors.Is(err, ErrRedirected))
+
+	err = NewClient(server.URL+"/loop", 2).WithFollowRedirects(true).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "stopped after 10 redirects")
+}
@@ -0,0 +1,75 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// ErrPageTooLarge is returned by Handler, with 413 Request Entity Too Large, when not even the first event of a page
+// fits in HandlerOptions.MaxResponseBytes, and by Client.FetchEvents when the server responds with 413.
+var ErrPageTooLarge = NewAPIError("the first event doesn't fit in the maximum response size; "+
+	"reduce the page size hint or the headers requested", http.StatusRequestEntityTooLarge)
+
+// cappedReceiver keeps a page within maxBytes, for HandlerWithOptions, without ever cutting it off in the middle of
+// a line: receiver serializes into pending, which is only written to writer at a checkpoint, if it fits. When the
+// events since the last checkpoint don't fit, they are dropped and the page ends at the checkpoint with
+// ErrPageFull, or with ErrPageTooLarge if nothing has been written.
+type cappedReceiver struct {
+	receiver EventReceiver
+	pending  *bytes.Buffer
+	writer   io.Writer
+	written  int64
+	maxBytes int64
+	// flushed (optional) is called once the pending lines are written
+	flushed func()
+}
+
+// newCappedReceiver returns a cappedReceiver and the writer the serializer passed to it must write to.
+func newCappedReceiver(writer io.Writer, maxBytes int) (*cappedReceiver, io.Writer) {
+	c := &cappedReceiver{pending: &bytes.Buffer{}, writer: writer, maxBytes: int64(maxBytes)}
+	return c, c.pending
+}
+
+func (c *cappedReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if err := c.receiver.Event(partitionID, headers, data); err != nil {
+		return err
+	}
+	return c.check()
+}
+
+func (c *cappedReceiver) Checkpoint(partitionID int, cursor string) error {
+	if err := c.receiver.Checkpoint(partitionID, cursor); err != nil {
+		return err
+	}
+	if err := c.check(); err != nil {
+		return err
+	}
+	return c.flush()
+}
+
+// check drops the pending lines and ends the page if they don't fit.
+func (c *cappedReceiver) check() error {
+	if c.written+int64(c.pending.Len()) <= c.maxBytes {
+		return nil
+	}
+	c.pending.Reset()
+	if c.written == 0 {
+		return ErrPageTooLarge
+	}
+	return ErrPageFull
+}
+
+// flush writes the pending lines; at the end of the page, it writes the events after the last checkpoint, if any.
+func (c *cappedReceiver) flush() error {
+	n, err := c.writer.Write(c.pending.Bytes())
+	c.written += int64(n)
+	c.pending.Reset()
+	if err == nil && c.flushed != nil {
+		c.flushed()
+	}
+	return err
+}
+
+var _ EventReceiver = &cappedReceiver{}
@@ -0,0 +1,140 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// batchAPI serves batches of events of the given sizes in partition 0, with a checkpoint after each batch.
+type batchAPI struct {
+	*TestZeroEventHubAPI
+	batches [][]int
+}
+
+func (b batchAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	for i, batch := range b.batches {
+		for _, size := range batch {
+			if err := r.Event(0, nil, json.RawMessage(`"`+strings.Repeat("x", size)+`"`)); err != nil {
+				return err
+			}
+		}
+		if err := r.Checkpoint(0, strconv.Itoa(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestHandlerMaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(HandlerWithOptions(nil, NewTestZeroEventHubAPI(), HandlerOptions{MaxResponseBytes: 2000}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	// pages never exceed the limit, and a consumer reading page after page gets every event
+	cursor := FirstCursor
+	next := 0
+	for next < 100 {
+		var page EventPageSingleType[TestEvent]
+		require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: cursor}}, 1000, &page, All))
+		require.NotEmpty(t, page.Events)
+		for _, event := range page.Events {
+			require.Equal(t, next, event.Data.Cursor)
+			next++
+		}
+		cursor = page.Cursors[0]
+	}
+	res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=_first&pagesizehint=1000&headers=_all")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	var body bytes.Buffer
+	_, err = body.ReadFrom(res.Body)
+	require.NoError(t, err)
+	require.True(t, body.Len() > 1800 && body.Len() <= 2000, "%d bytes", body.Len())
+	// the page ends with a whole checkpoint line
+	lines := strings.Split(strings.TrimSuffix(body.String(), "\n"), "\n")
+	require.Contains(t, lines[len(lines)-1], `"cursor":`)
+}
+
+func TestHandlerMaxResponseBytesBatches(t *testing.T) {
+	// event lines are 26 bytes plus the size of the event, checkpoints 29
+	for _, test := range []struct {
+		name     string
+		batches  [][]int
+		expected []string
+	}{
+		{"fits", [][]int{{10, 10}, {10}}, []string{"event", "event", "0", "event", "1"}},
+		{"batch dropped", [][]int{{10, 10}, {40, 10}}, []string{"event", "event", "0"}},
+		{"empty batch", [][]int{{10}, {}, {10}}, []string{"event", "0", "1", "event", "2"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			api := batchAPI{NewTestZeroEventHubAPI(), test.batches}
+			server := httptest.NewServer(HandlerWithOptions(nil, api, HandlerOptions{MaxResponseBytes: 200}))
+			defer server.Close()
+			res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=_first")
+			require.NoError(t, err)
+			defer res.Body.Close()
+			var lines []string
+			require.NoError(t, DecodeStream(res.Body, func(line Line) error {
+				if line.Kind == LineCheckpoint {
+					lines = append(lines, line.Checkpoint.Cursor)
+				} else {
+					lines = append(lines, "event")
+				}
+				return nil
+			}))
+			require.Equal(t, test.expected, lines)
+		})
+	}
+}
+
+func TestHandlerMaxResponseBytesTooLarge(t *testing.T) {
+	api := batchAPI{NewTestZeroEventHubAPI(), [][]int{{500}}}
+	server := httptest.NewServer(HandlerWithOptions(nil, api, HandlerOptions{MaxResponseBytes: 200}))
+	defer server.Close()
+
+	res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=_first")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+
+	var page EventPageRaw
+	err = NewClient(server.URL, 2).FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page)
+	require.Equal(t, ErrPageTooLarge, err)
+	require.Empty(t, page.Events)
+}
+
+func TestHandlerMaxResponseBytesAudit(t *testing.T) {
+	for _, batches := range [][][]int{{{10, 10}, {10}}, {{10, 10}, {40, 10}}} {
+		sink := make(channelAuditSink, 1)
+		api := batchAPI{NewTestZeroEventHubAPI(), batches}
+		server := httptest.NewServer(HandlerWithOptions(nil, api, HandlerOptions{MaxResponseBytes: 200, AuditSink: sink}))
+		res, err := server.Client().Get(server.URL + "/feed/v1?n=2&cursor0=_first")
+		require.NoError(t, err)
+		var events int
+		var cursor string
+		require.NoError(t, DecodeStream(res.Body, func(line Line) error {
+			if line.Kind == LineCheckpoint {
+				cursor = line.Checkpoint.Cursor
+			} else {
+				events++
+			}
+			return nil
+		}))
+		require.NoError(t, res.Body.Close())
+		server.Close()
+
+		// only what was written to the body is recorded
+		rec := <-sink
+		require.Equal(t, events, rec.Events, "%v", batches)
+		require.Equal(t, events, rec.Partitions[0].Events, "%v", batches)
+		require.Equal(t, cursor, rec.Partitions[0].EndCursor, "%v", batches)
+	}
+}
@@ -0,0 +1,110 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// resumeSettings holds the settings of Client.WithResumeFromCheckpoint.
+type resumeSettings struct {
+	maxResumes int
+	onResume   func(cursors []Cursor, err error)
+}
+
+// WithResumeFromCheckpoint is a Client method for recovering from failures in the middle of a page, e.g. a dropped
+// connection or a server failing after writing part of the page: instead of failing, FetchEvents requests the page
+// again from the last checkpoint passed to the receiver for each partition (or the original cursor, for partitions
+// without one), up to maxResumes times per call. onResume (optional) is called with the new cursors and the
+// failure before each resume, e.g. to record a metric.
+//
+// The events after the last checkpoint before the failure are passed to the receiver again, so the receiver must
+// handle those idempotently, as it would after restarting from a stored cursor. The resumed request asks for a
+// whole page again, so a call may pass more than pageSizeHint events. Failures before anything was received,
+// errors returned by the receiver and non-2xx responses are not resumed. Pass 0 to disable resuming.
+func (c Client) WithResumeFromCheckpoint(maxResumes int, onResume func(cursors []Cursor, err error)) (r Client) {
+	r = c
+	r.resume = resumeSettings{maxResumes: maxResumes, onResume: onResume}
+	return
+}
+
+// fetchResuming is fetchFromEndpoints, resuming from the last checkpoints if enabled.
+func (c Client) fetchResuming(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	if c.resume.maxResumes <= 0 {
+		return c.fetchFromEndpoints(ctx, cursors, pageSizeHint, r, headers...)
+	}
+	tracker := &checkpointTracker{receiver: r}
+	for resumes := 0; ; resumes++ {
+		tracker.calls = 0
+		err := c.fetchFromEndpoints(ctx, cursors, pageSizeHint, tracker, headers...)
+		if err == nil || resumes == c.resume.maxResumes || !tracker.resumable(ctx, err) {
+			return err
+		}
+		cursors = tracker.resumeCursors(cursors)
+		if logEnabled(c.logger, LevelWarn) {
+			c.logger.WithField("event", "zeroeventhub.resume").WithField("Cursors", cursors).WithError(err).Warn()
+		}
+		if c.resume.onResume != nil {
+			c.resume.onResume(cursors, err)
+		}
+	}
+}
+
+// checkpointTracker passes calls on to receiver, keeping the last checkpoint of each partition and the last error
+// returned by the receiver.
+type checkpointTracker struct {
+	receiver EventReceiver
+	// calls is the number of calls of the current request
+	calls       int
+	checkpoints map[int]string
+	receiverErr error
+}
+
+func (t *checkpointTracker) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	t.calls++
+	t.receiverErr = t.receiver.Event(partitionID, headers, data)
+	return t.receiverErr
+}
+
+func (t *checkpointTracker) Checkpoint(partitionID int, cursor string) error {
+	t.calls++
+	if t.receiverErr = t.receiver.Checkpoint(partitionID, cursor); t.receiverErr != nil {
+		return t.receiverErr
+	}
+	t.checkpointed(partitionID, cursor)
+	return nil
+}
+
+// checkpointed records a checkpoint the receiver has accepted, to resume from.
+func (t *checkpointTracker) checkpointed(partitionID int, cursor string) {
+	if t.checkpoints == nil {
+		t.checkpoints = make(map[int]string)
+	}
+	t.checkpoints[partitionID] = cursor
+}
+
+func (t *checkpointTracker) PollAfter(d time.Duration) {
+	if receiver, ok := t.receiver.(PollIntervalReceiver); ok {
+		receiver.PollAfter(d)
+	}
+}
+
+// resumable tells whether err is a failure of the stream after some of it was received.
+func (t *checkpointTracker) resumable(ctx context.Context, err error) bool {
+	return t.calls > 0 && err != t.receiverErr && err != ErrResponseTooLarge &&
+		!errors.Is(err, ErrProtocolViolation) && ctx.Err() == nil
+}
+
+// resumeCursors returns the cursors with those of partitions with checkpoints replaced by the last checkpoint.
+func (t *checkpointTracker) resumeCursors(cursors []Cursor) []Cursor {
+	resumed := make([]Cursor, len(cursors))
+	for i, cursor := range cursors {
+		if checkpoint, ok := t.checkpoints[cursor.PartitionID]; ok {
+			cursor.Cursor = checkpoint
+		}
+		resumed[i] = cursor
+	}
+	return resumed
+}
@@ -0,0 +1,118 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyAPI serves events 1 to 4 of partition 0 with their number as cursor. The first `failures` requests fail
+// after the checkpoint of their first event and the event following it.
+type flakyAPI struct {
+	failures int32
+	requests *int32
+}
+
+func (f flakyAPI) GetName() string {
+	return "flakyAPI"
+}
+
+func (f flakyAPI) GetPartitionCount() int {
+	return 1
+}
+
+func (f flakyAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	request := atomic.AddInt32(f.requests, 1)
+	start := 1
+	if cursors[0].Cursor != FirstCursor {
+		after, err := strconv.Atoi(cursors[0].Cursor)
+		if err != nil {
+			return err
+		}
+		start = after + 1
+	}
+	for i := start; i <= 4; i++ {
+		if err := r.Event(0, nil, json.RawMessage(strconv.Itoa(i))); err != nil {
+			return err
+		}
+		if i > start && request <= f.failures {
+			return errors.New("connection to database lost")
+		}
+		if err := r.Checkpoint(0, strconv.Itoa(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestClientResumeFromCheckpoint(t *testing.T) {
+	requests := new(int32)
+	server := httptest.NewServer(Handler(nil, flakyAPI{failures: 1, requests: requests}))
+	defer server.Close()
+
+	var resumed [][]Cursor
+	client := NewClient(server.URL, 1).WithResumeFromCheckpoint(3, func(cursors []Cursor, err error) {
+		require.Error(t, err)
+		resumed = append(resumed, cursors)
+	})
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page))
+	var data []string
+	for _, event := range page.Events {
+		data = append(data, string(event.Data))
+	}
+	// event 2 came before the failure, and again after resuming from checkpoint 1
+	require.Equal(t, []string{"1", "2", "2", "3", "4"}, data)
+	require.Equal(t, map[int]string{0: "4"}, page.Cursors)
+	require.Equal(t, [][]Cursor{{{Cursor: "1"}}}, resumed)
+	require.Equal(t, int32(2), atomic.LoadInt32(requests))
+
+	// without resuming the failure is returned
+	atomic.StoreInt32(requests, 0)
+	page = EventPageRaw{}
+	require.Error(t, NewClient(server.URL, 1).FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page))
+	require.Equal(t, map[int]string{0: "1"}, page.Cursors)
+}
+
+func TestClientResumeFromCheckpointLimits(t *testing.T) {
+	requests := new(int32)
+	server := httptest.NewServer(Handler(nil, flakyAPI{failures: 100, requests: requests}))
+	defer server.Close()
+	client := NewClient(server.URL, 1).WithResumeFromCheckpoint(2, nil)
+
+	// the page keeps failing: given up after 2 resumes
+	var page EventPageRaw
+	require.Error(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page))
+	require.Equal(t, int32(3), atomic.LoadInt32(requests))
+	require.Equal(t, map[int]string{0: "3"}, page.Cursors)
+
+	// errors of the receiver aren't resumed
+	atomic.StoreInt32(requests, 0)
+	err := client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &failingPage{failAt: "2"})
+	require.EqualError(t, err, "receiver failed")
+	require.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	// nor are failures before anything was received
+	atomic.StoreInt32(requests, 0)
+	require.Error(t, NewClient(server.URL, 2).WithResumeFromCheckpoint(2, nil).FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &page))
+	require.Equal(t, int32(0), atomic.LoadInt32(requests))
+}
+
+// failingPage is an EventPageRaw failing on the event with the given data.
+type failingPage struct {
+	EventPageRaw
+	failAt string
+}
+
+func (p *failingPage) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if string(data) == p.failAt {
+		return errors.New("receiver failed")
+	}
+	return p.EventPageRaw.Event(partitionID, headers, data)
+}
@@ -0,0 +1,78 @@
+package zeroeventhub
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// ServeFromRowsDefaultPageSize is the page size used by ServeFromRows when the caller passes DefaultPageSize.
+const ServeFromRowsDefaultPageSize = 100
+
+// ErrIllegalCursor is returned by ServeFromRows for a cursor that is neither numeric nor one of the special cursors.
+var ErrIllegalCursor = NewAPIError("illegal cursor", http.StatusBadRequest)
+
+// ServeFromRows is a helper for implementing FetchEvents of a single partition on top of a store with
+// monotonically increasing int64 IDs, e.g. a SQL table queried as `WHERE id > @afterID ORDER BY id LIMIT @limit`.
+// It translates the cursor into afterID (FirstCursor starts before any row, LastCursor serves the last row only),
+// calls query once, emits the returned rows as events and finishes the page with a checkpoint at the last row's ID.
+// toEnvelope returns the ID of a row along with its data and headers. A malformed cursor fails with
+// ErrIllegalCursor, which Handler responds to with 400.
+//
+// Resolving LastCursor requires paging through the whole partition; publishers that can look up the last ID
+// cheaply should resolve LastCursor themselves and pass the numeric cursor.
+func ServeFromRows[T any](
+	partitionID int,
+	cursor string,
+	pageSize int,
+	query func(afterID int64, limit int) ([]T, error),
+	toEnvelope func(T) (int64, json.RawMessage, map[string]string),
+	receiver EventReceiver,
+) error {
+	if pageSize == DefaultPageSize {
+		pageSize = ServeFromRowsDefaultPageSize
+	}
+	var rows []T
+	switch cursor {
+	case FirstCursor:
+		var err error
+		if rows, err = query(math.MinInt64, pageSize); err != nil {
+			return err
+		}
+	case LastCursor:
+		afterID := int64(math.MinInt64)
+		for {
+			page, err := query(afterID, pageSize)
+			if err != nil {
+				return err
+			}
+			if len(page) == 0 {
+				break
+			}
+			rows = page[len(page)-1:]
+			afterID, _, _ = toEnvelope(rows[0])
+		}
+	default:
+		afterID, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return ErrIllegalCursor
+		}
+		if rows, err = query(afterID, pageSize); err != nil {
+			return err
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+	var lastID int64
+	for _, row := range rows {
+		id, data, headers := toEnvelope(row)
+		if err := receiver.Event(partitionID, headers, data); err != nil {
+			return err
+		}
+		lastID = id
+	}
+	return receiver.Checkpoint(partitionID, strconv.FormatInt(lastID, 10))
+}
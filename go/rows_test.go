@@ -0,0 +1,153 @@
+package zeroeventhub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRow struct {
+	ID   int64
+	Name string
+}
+
+func TestServeFromRows(t *testing.T) {
+	var table []testRow
+	for i := int64(1); i <= 25; i++ {
+		table = append(table, testRow{ID: i * 10, Name: fmt.Sprintf("row%d", i)})
+	}
+	queries := 0
+	query := func(afterID int64, limit int) (result []testRow, err error) {
+		queries++
+		for _, row := range table {
+			if row.ID > afterID && len(result) < limit {
+				result = append(result, row)
+			}
+		}
+		return
+	}
+	toEnvelope := func(row testRow) (int64, json.RawMessage, map[string]string) {
+		return row.ID, mustMarshalJson(row.Name), map[string]string{"id": fmt.Sprintf("%d", row.ID)}
+	}
+
+	tests := []struct {
+		name     string
+		cursor   string
+		pageSize int
+
+		expectedNames   []string
+		expectedCursors map[int]string
+		expectedQueries int
+		expectedError   string
+	}{
+		{
+			name:            "first cursor",
+			cursor:          FirstCursor,
+			pageSize:        2,
+			expectedNames:   []string{"row1", "row2"},
+			expectedCursors: map[int]string{1: "20"},
+			expectedQueries: 1,
+		},
+		{
+			name:            "numeric cursor",
+			cursor:          "235",
+			pageSize:        10,
+			expectedNames:   []string{"row24", "row25"},
+			expectedCursors: map[int]string{1: "250"},
+			expectedQueries: 1,
+		},
+		{
+			name:            "default page size",
+			cursor:          "0",
+			expectedNames:   namesOf(table),
+			expectedCursors: map[int]string{1: "250"},
+			expectedQueries: 1,
+		},
+		{
+			name:            "last cursor",
+			cursor:          LastCursor,
+			pageSize:        10,
+			expectedNames:   []string{"row25"},
+			expectedCursors: map[int]string{1: "250"},
+			expectedQueries: 4,
+		},
+		{
+			name:            "cursor at the end",
+			cursor:          "250",
+			pageSize:        10,
+			expectedQueries: 1,
+		},
+		{
+			name:          "malformed cursor",
+			cursor:        "qwerty",
+			expectedError: ErrIllegalCursor.Error(),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queries = 0
+			var page EventPageSingleType[string]
+			err := ServeFromRows(1, test.cursor, test.pageSize, query, toEnvelope, &page)
+			if test.expectedError != "" {
+				require.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			var names []string
+			for _, e := range page.Events {
+				require.Equal(t, 1, e.PartitionID)
+				names = append(names, e.Data)
+			}
+			require.Equal(t, test.expectedNames, names)
+			require.Equal(t, test.expectedCursors, page.Cursors)
+			require.Equal(t, test.expectedQueries, queries)
+		})
+	}
+}
+
+func namesOf(rows []testRow) (names []string) {
+	for _, row := range rows {
+		names = append(names, row.Name)
+	}
+	return
+}
+
+// rowsAPI is a TestZeroEventHubAPI serving partition 0 from rows with ServeFromRows.
+type rowsAPI struct {
+	*TestZeroEventHubAPI
+	rows []testRow
+}
+
+func (api rowsAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	query := func(afterID int64, limit int) (result []testRow, err error) {
+		for _, row := range api.rows {
+			if row.ID > afterID && len(result) < limit {
+				result = append(result, row)
+			}
+		}
+		return
+	}
+	toEnvelope := func(row testRow) (int64, json.RawMessage, map[string]string) {
+		return row.ID, mustMarshalJson(row.Name), nil
+	}
+	return ServeFromRows(0, cursors[0].Cursor, pageSizeHint, query, toEnvelope, r)
+}
+
+func TestServeFromRowsMalformedCursor(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, rowsAPI{NewTestZeroEventHubAPI(), []testRow{{ID: 1, Name: "row1"}}}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	var page EventPageSingleType[string]
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, 10, &page))
+	require.Len(t, page.Events, 1)
+
+	// a client error rather than an internal server error
+	err := client.FetchEvents(context.Background(), []Cursor{{Cursor: "qwerty"}}, 10, &page)
+	require.Equal(t, &ResponseError{StatusCode: http.StatusBadRequest, Body: "illegal cursor\n"}, err)
+}
@@ -0,0 +1,44 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TimeSeeker can optionally be implemented by an API whose events have timestamps, to let consumers start
+// reading from a point in time, e.g. "from 2 hours ago", instead of from a stored cursor. Handler serves it on
+// /feed/v1/seek, and Client.CursorForTime fetches it.
+type TimeSeeker interface {
+	// CursorForTime returns the cursor to fetch from to get the events of the partition from t on: the cursor of
+	// the last event before t, or FirstCursor if there is none.
+	CursorForTime(ctx context.Context, partitionID int, t time.Time) (string, error)
+}
+
+var (
+	// ErrTimeSeekNotSupported is returned when the API doesn't implement TimeSeeker.
+	ErrTimeSeekNotSupported = NewAPIError("seeking by time not supported", http.StatusNotImplemented)
+	// ErrIllegalSeekTime is returned for a from parameter that isn't an RFC 3339 time.
+	ErrIllegalSeekTime = NewAPIError("illegal time to seek to; expected RFC 3339", http.StatusBadRequest)
+)
+
+func seekHandler(logger Logger, api API) http.HandlerFunc {
+	seeker, ok := api.(TimeSeeker)
+	if !ok {
+		return unsupportedHandler(ErrTimeSeekNotSupported)
+	}
+	return cursorHandler(logger, api, "seek", func(ctx context.Context, query url.Values, partitionID int) (string, error) {
+		t, err := time.Parse(time.RFC3339Nano, query.Get("from"))
+		if err != nil {
+			return "", ErrIllegalSeekTime
+		}
+		return seeker.CursorForTime(ctx, partitionID, t)
+	})
+}
+
+// CursorForTime fetches the cursor to fetch from to get the events of the partition from t on, from a server
+// whose API implements TimeSeeker. A server without support responds with 501, returned as a *ResponseError.
+func (c Client) CursorForTime(ctx context.Context, partitionID int, t time.Time) (string, error) {
+	return c.fetchCursor(ctx, "seek", partitionID, url.Values{"from": {t.Format(time.RFC3339Nano)}})
+}
@@ -0,0 +1,82 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var seekEpoch = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// timestampedAPI is a TestZeroEventHubAPI whose event with cursor i happened i minutes after seekEpoch.
+type timestampedAPI struct {
+	*TestZeroEventHubAPI
+}
+
+func (t timestampedAPI) CursorForTime(ctx context.Context, partitionID int, at time.Time) (string, error) {
+	// the first event at or after the time, minus one
+	i := int((at.Sub(seekEpoch)+time.Minute-1)/time.Minute) - 1
+	switch {
+	case i < 0:
+		return FirstCursor, nil
+	case i >= len(t.partitions[partitionID]):
+		i = len(t.partitions[partitionID]) - 1
+	}
+	return strconv.Itoa(i), nil
+}
+
+func TestCursorForTime(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, timestampedAPI{NewTestZeroEventHubAPI()}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	tests := []struct {
+		at         time.Time
+		cursor     string
+		firstEvent int
+	}{
+		{at: seekEpoch.Add(-time.Hour), cursor: FirstCursor, firstEvent: 0},
+		{at: seekEpoch, cursor: FirstCursor, firstEvent: 0},
+		{at: seekEpoch.Add(2 * time.Hour), cursor: "119", firstEvent: 120},
+		{at: seekEpoch.Add(2*time.Hour + time.Second), cursor: "120", firstEvent: 121},
+		{at: seekEpoch.Add(2 * time.Hour).In(time.FixedZone("CET", 3600)), cursor: "119", firstEvent: 120},
+	}
+	for _, test := range tests {
+		cursor, err := client.CursorForTime(context.Background(), 1, test.at)
+		require.NoError(t, err, test.at)
+		require.Equal(t, test.cursor, cursor, test.at)
+
+		var page EventPageSingleType[TestEvent]
+		require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: 1, Cursor: cursor}}, 1, &page))
+		require.Equal(t, test.firstEvent, page.Events[0].Data.Cursor, test.at)
+	}
+
+	// after the last event, there is nothing left to read
+	cursor, err := client.CursorForTime(context.Background(), 0, seekEpoch.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: 0, Cursor: cursor}}, DefaultPageSize, &page))
+	require.Empty(t, page.Events)
+
+	_, err = client.CursorForTime(context.Background(), 2, seekEpoch)
+	require.Equal(t, &ResponseError{StatusCode: http.StatusBadRequest, Body: "partition doesn't exist\n"}, err)
+
+	res, err := http.Get(server.URL + "/feed/v1/seek?" + url.Values{"n": {"2"}, "partition": {"0"}, "from": {"2 hours ago"}}.Encode())
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestCursorForTimeNotSupported(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, 2).CursorForTime(context.Background(), 0, seekEpoch)
+	require.Equal(t, &ResponseError{StatusCode: http.StatusNotImplemented, Body: "seeking by time not supported\n"}, err)
+}
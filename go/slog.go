@@ -0,0 +1,76 @@
+//go:build go1.21
+
+package zeroeventhub
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogLogger returns a Logger logging to an slog.Logger. The "event" field is used as the message,
+// and all fields, including the event, are passed as attributes.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger, ctx: context.Background()}
+}
+
+// WithSlog is a Client method for logging to an slog.Logger; see NewSlogLogger.
+func (c Client) WithSlog(logger *slog.Logger) (r Client) {
+	return c.WithLogger(NewSlogLogger(logger))
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+	ctx    context.Context
+	event  string
+	attrs  []slog.Attr
+}
+
+func (l slogLogger) WithField(key string, value interface{}) Logger {
+	if key == "event" {
+		if event, ok := value.(string); ok {
+			l.event = event
+		}
+	}
+	l.attrs = append(append([]slog.Attr(nil), l.attrs...), slog.Any(key, value))
+	return l
+}
+
+func (l slogLogger) WithError(err error) Logger {
+	return l.WithField("error", err)
+}
+
+func (l slogLogger) WithContext(ctx context.Context) Logger {
+	l.ctx = ctx
+	return l
+}
+
+func (l slogLogger) Enabled(level LogLevel) bool {
+	return l.logger.Enabled(l.ctx, slogLevels[level])
+}
+
+var slogLevels = map[LogLevel]slog.Level{
+	LevelDebug: slog.LevelDebug,
+	LevelInfo:  slog.LevelInfo,
+	LevelWarn:  slog.LevelWarn,
+	LevelError: slog.LevelError,
+}
+
+func (l slogLogger) log(level slog.Level) {
+	l.logger.LogAttrs(l.ctx, level, l.event, l.attrs...)
+}
+
+func (l slogLogger) Debug() {
+	l.log(slog.LevelDebug)
+}
+
+func (l slogLogger) Info() {
+	l.log(slog.LevelInfo)
+}
+
+func (l slogLogger) Warn() {
+	l.log(slog.LevelWarn)
+}
+
+func (l slogLogger) Error() {
+	l.log(slog.LevelError)
+}
@@ -0,0 +1,45 @@
+//go:build go1.21
+
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	server := httptest.NewServer(HandlerWithOptions(NewSlogLogger(logger.With("component", "server")), NewTestZeroEventHubAPI(), HandlerOptions{RequestLogLevel: LevelInfo}))
+	defer server.Close()
+
+	var page EventPageRaw
+	require.NoError(t, NewClient(server.URL, 2).WithSlog(logger).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page, "foo"))
+	require.Error(t, NewClient(server.URL, 3).WithSlog(logger).FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var request, clientError map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &request))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &clientError))
+
+	require.Equal(t, "INFO", request["level"])
+	require.Equal(t, "TestZeroEventHubAPI", request["msg"])
+	require.Equal(t, "TestZeroEventHubAPI", request["event"])
+	require.Equal(t, "server", request["component"])
+	require.Equal(t, float64(5), request["PageSizeHint"])
+	require.Equal(t, []interface{}{"foo"}, request["Headers"])
+
+	require.Equal(t, "ERROR", clientError["level"])
+	require.Equal(t, "zeroeventhub.unexpected_response_body", clientError["msg"])
+	require.Equal(t, "400", clientError["responseCode"])
+	require.Equal(t, "unexpected response body: handshake error: partition count mismatch\n", clientError["error"])
+	require.Contains(t, clientError["requestUrl"], server.URL)
+}
@@ -0,0 +1,74 @@
+package zeroeventhub
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ErrProtocolViolation matches, with errors.Is, every *ProtocolViolationError.
+var ErrProtocolViolation = errors.New("protocol violation")
+
+// ProtocolViolationError is returned in strict decoding mode for a line that parses, but doesn't follow the protocol.
+type ProtocolViolationError struct {
+	// Line is the line number in the stream, starting at 1 and counting blank lines.
+	Line int
+	// Field is the name of the offending field, as written in the line.
+	Field  string
+	Reason string
+}
+
+func (e *ProtocolViolationError) Error() string {
+	return fmt.Sprintf("protocol violation on line %d: field %q %s", e.Line, e.Field, e.Reason)
+}
+
+func (e *ProtocolViolationError) Is(target error) bool {
+	return target == ErrProtocolViolation
+}
+
+// DecodeOptions configures DecodeStreamWithOptions.
+type DecodeOptions struct {
+	// Strict fails the stream with a *ProtocolViolationError at the first line with a field that doesn't belong to
+	// its kind of line, e.g. an unknown field or data in a checkpoint, and at the first event without data.
+	// By default such lines are tolerated, taking what is needed from them.
+	Strict bool
+}
+
+// WithStrictDecoding returns a client decoding responses with DecodeOptions.Strict, i.e. failing FetchEvents with
+// a *ProtocolViolationError rather than tolerating lines that don't follow the protocol. It is meant for checking
+// the conformance of servers; a protocol violation is not resumed by WithResumeFromCheckpoint.
+func (c Client) WithStrictDecoding() (r Client) {
+	r = c
+	r.strictDecoding = true
+	return
+}
+
+// lineFields are the fields allowed in each kind of line.
+var lineFields = map[LineKind]map[string]bool{
+	LineEvent:      {"partition": true, "headers": true, "data": true},
+	LineCheckpoint: {"partition": true, "cursor": true, "caughtUp": true},
+	LineError:      {"partition": true, "error": true},
+}
+
+// checkLine returns the violation of the protocol, if any, of line number n, b, which has already been parsed as a
+// line of the given kind. Field names are compared exactly, while json.Unmarshal would also accept e.g. "Data".
+func checkLine(b []byte, kind LineKind, n int) *ProtocolViolationError {
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(b, &fields)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !lineFields[kind][name] {
+			return &ProtocolViolationError{Line: n, Field: name, Reason: fmt.Sprintf("not allowed in %s line", kind)}
+		}
+	}
+	if _, ok := fields["data"]; kind == LineEvent && !ok {
+		return &ProtocolViolationError{Line: n, Field: "data", Reason: "missing in event line"}
+	}
+	return nil
+}
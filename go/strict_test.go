@@ -0,0 +1,110 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	valid := []string{
+		`{"partition":0,"data":{"a":1}}`,
+		`{"partition":0,"headers":{"a":"b"},"data":null}`,
+		`{"partition":0,"cursor":"1"}`,
+		`{"cursor":"1","partition":0}`,
+		`{"partition":0,"cursor":"1","caughtUp":true}`,
+		`{"partition":0,"error":"failed"}`,
+	}
+	for _, line := range valid {
+		require.NoError(t, DecodeStreamWithOptions(strings.NewReader(line), DecodeOptions{Strict: true}, func(Line) error {
+			return nil
+		}), line)
+	}
+
+	for _, tc := range []struct {
+		line, field string
+	}{
+		{`{"partition":0,"data":1,"extra":2}`, "extra"},
+		{`{"partition":0,"Data":1}`, "Data"},
+		{`{"partition":0,"headers":{"a":"b"}}`, "data"},
+		{`{"partition":0}`, "data"},
+		{`{"partition":0,"cursor":"1","data":{}}`, "data"},
+		{`{"partition":0,"cursor":"1","headers":{}}`, "headers"},
+		{`{"partition":0,"cursor":"1","extra":true}`, "extra"},
+		{`{"partition":0,"error":"failed","data":1}`, "data"},
+	} {
+		stream := valid[0] + "\n\n" + tc.line + "\n" + valid[2] + "\n"
+		var lines int
+		count := func(Line) error {
+			lines++
+			return nil
+		}
+		require.NoError(t, DecodeStream(strings.NewReader(stream), count), tc.line)
+		require.Equal(t, 3, lines)
+
+		lines = 0
+		err := DecodeStreamWithOptions(strings.NewReader(stream), DecodeOptions{Strict: true}, count)
+		require.True(t, errors.Is(err, ErrProtocolViolation), "%s: %v", tc.line, err)
+		var violation *ProtocolViolationError
+		require.True(t, errors.As(err, &violation))
+		require.Equal(t, 3, violation.Line, tc.line)
+		require.Equal(t, tc.field, violation.Field, tc.line)
+		require.Equal(t, 1, lines)
+	}
+}
+
+func TestClientStrictDecoding(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = writer.Write([]byte(`{"partition":0,"data":1}` + "\n" + `{"partition":0,"cursor":"1"}` + "\n" +
+			`{"partition":0,"cursor":"2","data":2}` + "\n"))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, 1)
+
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, 10, &page))
+	require.Equal(t, map[int]string{0: "2"}, page.Cursors)
+
+	// a violation is not resumed, as it would only happen again
+	page = EventPageRaw{}
+	atomic.StoreInt32(&requests, 0)
+	err := client.WithStrictDecoding().WithResumeFromCheckpoint(3, nil).
+		FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, 10, &page)
+	require.Equal(t, &ProtocolViolationError{Line: 3, Field: "data", Reason: "not allowed in checkpoint line"}, err)
+	require.Equal(t, `protocol violation on line 3: field "data" not allowed in checkpoint line`, err.Error())
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	require.Equal(t, map[int]string{0: "1"}, page.Cursors)
+}
+
+func TestDebugProxyStrictDecoding(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"partition":0,"data":1}` + "\n" + `{"partition":0,"extra":1}` + "\n"))
+	}))
+	defer upstream.Close()
+	proxy, err := NewDebugProxy(upstream.URL, DebugProxySettings{RecordLines: 10, StrictDecoding: true}, nil)
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/feed/v1")
+	require.NoError(t, err)
+	var body bytes.Buffer
+	_, err = body.ReadFrom(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, `{"partition":0,"data":1}`+"\n"+`{"partition":0,"extra":1}`+"\n", body.String())
+
+	require.Equal(t, int64(1), proxy.Counters().Violations)
+	lines := proxy.Lines()
+	require.Nil(t, lines[0].Violation)
+	require.Equal(t, &ProtocolViolationError{Line: 2, Field: "extra", Reason: "not allowed in event line"}, lines[1].Violation)
+}
@@ -0,0 +1,158 @@
+package zeroeventhub
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PageEnd is why a page read by Client.FetchEvents ended.
+type PageEnd int
+
+const (
+	// PageEndComplete is a page the server ended before the page size hint was reached, usually because the consumer
+	// caught up with the feed, or because the server limited its size (see HandlerOptions.MaxPageBytes).
+	PageEndComplete PageEnd = iota
+	// PageEndFull is a page with at least as many events as the page size hint.
+	PageEndFull
+	// PageEndTooLarge is a page cut off by the limit of WithMaxResponseBytes.
+	PageEndTooLarge
+	// PageEndIdle is a page cut off by the timeout of WithIdleReadTimeout.
+	PageEndIdle
+	// PageEndFailed is a page cut off by any other error, e.g. one returned by the receiver.
+	PageEndFailed
+)
+
+func (e PageEnd) String() string {
+	switch e {
+	case PageEndComplete:
+		return "complete"
+	case PageEndFull:
+		return "full"
+	case PageEndTooLarge:
+		return "too_large"
+	case PageEndIdle:
+		return "idle"
+	case PageEndFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// PageSummary sums up a page read by Client.FetchEvents, for debugging.
+type PageSummary struct {
+	// StartCursors are the cursors the page was requested from.
+	StartCursors []Cursor
+	// EndCursors are the last checkpoint of each partition in the page; partitions without a checkpoint are missing.
+	EndCursors  Cursors
+	Events      int
+	Checkpoints int
+	// Bytes is the size of the response body as read, i.e. after decompression.
+	Bytes int64
+	// Duration is the time from sending the request to the end of the page.
+	Duration time.Duration
+	End      PageEnd
+	// Err is the error the page ended with, if any.
+	Err error
+}
+
+// WithPageSummary is a Client method for getting a PageSummary of every page read, including resumed and
+// failed-over requests, after the last event of the page has been passed to the receiver. Without it, the
+// summaries are logged at LevelDebug, if enabled. Pass nil to go back to logging them.
+func (c Client) WithPageSummary(observe func(PageSummary)) (r Client) {
+	r = c
+	r.onPage = observe
+	return
+}
+
+// pageSummarizer builds the PageSummary of a page. Its methods do nothing on a nil pageSummarizer, which is used
+// when nobody is interested in the summary.
+type pageSummarizer struct {
+	summary PageSummary
+	start   time.Time
+	body    io.Reader
+}
+
+// newPageSummarizer returns a pageSummarizer for a page requested at start, or nil if the summary would be thrown
+// away.
+func (c Client) newPageSummarizer(cursors []Cursor, start time.Time) *pageSummarizer {
+	if c.onPage == nil && c.onTrace == nil && !logEnabled(c.logger, LevelDebug) {
+		return nil
+	}
+	return &pageSummarizer{
+		summary: PageSummary{StartCursors: cursors, EndCursors: Cursors{}},
+		start:   start,
+	}
+}
+
+// reader returns body, counting the bytes read from it.
+func (s *pageSummarizer) reader(body io.Reader) io.Reader {
+	if s == nil {
+		return body
+	}
+	s.body = body
+	return s
+}
+
+func (s *pageSummarizer) Read(p []byte) (int, error) {
+	n, err := s.body.Read(p)
+	s.summary.Bytes += int64(n)
+	return n, err
+}
+
+func (s *pageSummarizer) event() {
+	if s != nil {
+		s.summary.Events++
+	}
+}
+
+func (s *pageSummarizer) checkpoint(partitionID int, cursor string) {
+	if s != nil {
+		s.summary.Checkpoints++
+		s.summary.EndCursors[partitionID] = cursor
+	}
+}
+
+// finishPageSummary passes the summary of the page, which ended with err, to the observer, or logs it.
+func (c Client) finishPageSummary(ctx context.Context, s *pageSummarizer, pageSizeHint int, err error) {
+	if s == nil {
+		return
+	}
+	summary := s.summary
+	summary.Duration = time.Since(s.start)
+	summary.Err = err
+	switch {
+	case errors.Is(err, ErrResponseTooLarge):
+		summary.End = PageEndTooLarge
+	case errors.Is(err, ErrStreamIdle):
+		summary.End = PageEndIdle
+	case err != nil:
+		summary.End = PageEndFailed
+	case pageSizeHint > 0 && summary.Events >= pageSizeHint:
+		summary.End = PageEndFull
+	}
+	if c.onPage != nil {
+		c.onPage(summary)
+		return
+	}
+	if !logEnabled(c.logger, LevelDebug) {
+		// only summarized for WithTrace
+		return
+	}
+	logger := c.logger.
+		WithContext(ctx).
+		WithField("event", "zeroeventhub.page").
+		WithField("StartCursors", summary.StartCursors).
+		WithField("EndCursors", summary.EndCursors).
+		WithField("Events", summary.Events).
+		WithField("Checkpoints", summary.Checkpoints).
+		WithField("Bytes", summary.Bytes).
+		WithField("Duration", summary.Duration).
+		WithField("End", summary.End.String())
+	if err != nil {
+		logger = logger.WithError(err)
+	}
+	logger.Debug()
+}
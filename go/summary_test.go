@@ -0,0 +1,51 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageSummary(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, NewTestZeroEventHubAPI()))
+	defer server.Close()
+	var summaries []PageSummary
+	client := NewClient(server.URL, 2).WithPageSummary(func(summary PageSummary) {
+		summaries = append(summaries, summary)
+	})
+	cursors := []Cursor{{PartitionID: 0, Cursor: "10"}, {PartitionID: 1, Cursor: "100"}}
+
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), cursors, 5, &page))
+	require.Len(t, summaries, 1)
+	summary := summaries[0]
+	require.True(t, summary.Duration > 0)
+	require.True(t, summary.Bytes > 0)
+	summary.Duration, summary.Bytes = 0, 0
+	require.Equal(t, PageSummary{
+		StartCursors: cursors,
+		EndCursors:   Cursors{0: "15", 1: "105"},
+		Events:       10,
+		Checkpoints:  10,
+		End:          PageEndFull,
+	}, summary)
+
+	// the summary of a page cut off by the byte limit
+	require.Equal(t, ErrResponseTooLarge, client.WithMaxResponseBytes(100).FetchEvents(context.Background(), cursors, 5, &page))
+	require.Len(t, summaries, 2)
+	require.Equal(t, PageEndTooLarge, summaries[1].End)
+	require.Equal(t, int64(100), summaries[1].Bytes)
+	require.Equal(t, ErrResponseTooLarge, summaries[1].Err)
+
+	// without an observer, the summary is logged at debug
+	log := &recordingLogger{}
+	require.NoError(t, NewClient(server.URL, 2).WithLogger(log).FetchEvents(context.Background(), cursors, 100, &page))
+	entries := log.Entries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "debug", entries[0].Level)
+	require.Equal(t, "zeroeventhub.page", entries[0].Fields["event"])
+	require.Equal(t, 200, entries[0].Fields["Events"])
+	require.Equal(t, "full", entries[0].Fields["End"])
+}
@@ -0,0 +1,170 @@
+package zeroeventhub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// TailCursorProvider can optionally be implemented by an API to report the cursor of the latest event of
+// a partition without reading the feed. Handler serves it on /feed/v1/tail, and Client.TailCursor fetches it,
+// so consumers can tell how far behind they are without draining the feed.
+type TailCursorProvider interface {
+	// TailCursor returns the cursor of the latest event in the partition, or FirstCursor if it is empty.
+	TailCursor(ctx context.Context, partitionID int) (string, error)
+}
+
+// ErrTailCursorNotSupported is returned when the API doesn't implement TailCursorProvider.
+var ErrTailCursorNotSupported = NewAPIError("tail cursor not supported", http.StatusNotImplemented)
+
+func tailCursorHandler(logger Logger, api API) http.HandlerFunc {
+	provider, ok := api.(TailCursorProvider)
+	if !ok {
+		return unsupportedHandler(ErrTailCursorNotSupported)
+	}
+	return cursorHandler(logger, api, "tail_cursor", func(ctx context.Context, query url.Values, partitionID int) (string, error) {
+		return provider.TailCursor(ctx, partitionID)
+	})
+}
+
+// unsupportedHandler responds with err to every request.
+func unsupportedHandler(err StatusError) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		http.Error(writer, err.Error(), err.Status())
+	}
+}
+
+// cursorHandler serves an endpoint returning a single checkpoint for the partition given in the query, as
+// found by find. A StatusError from find is responded to with its status, other errors with 500.
+func cursorHandler(logger Logger, api API, name string, find func(ctx context.Context, query url.Values, partitionID int) (string, error)) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		partitionID, badRequest := parsePartition(api, query)
+		if badRequest != nil {
+			http.Error(writer, badRequest.Error(), badRequest.Status())
+			return
+		}
+		cursor, err := find(contextWithRequest(request.Context(), request), query, partitionID)
+		if err != nil {
+			respondWithError(logger, api, name, writer, err)
+			return
+		}
+		setNDJSONHeaders(writer.Header())
+		if err := NewNDJSONEventSerializer(writer).Checkpoint(partitionID, cursor); err != nil {
+			logger.WithField("event", api.GetName()+"."+name+"_write_error").WithError(err).Info()
+		}
+	}
+}
+
+// respondWithError responds to a request for which the API failed: with the status of a StatusError, and with 500,
+// logging it, for other errors.
+func respondWithError(logger Logger, api API, name string, writer http.ResponseWriter, err error) {
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		http.Error(writer, statusErr.Error(), statusErr.Status())
+		return
+	}
+	logger.WithField("event", api.GetName()+"."+name+"_error").WithError(err).Info()
+	http.Error(writer, "Internal server error", http.StatusInternalServerError)
+}
+
+// parsePartition checks the handshake and returns the partition given in the query of a request for a single
+// partition.
+func parsePartition(api API, query url.Values) (int, StatusError) {
+	if err := checkPartitionCount(api, query); err != nil {
+		return 0, err
+	}
+	partitionID, err := strconv.Atoi(query.Get("partition"))
+	if err != nil || partitionID < 0 || partitionID >= api.GetPartitionCount() {
+		return 0, ErrPartitionDoesntExist
+	}
+	return partitionID, nil
+}
+
+// TailCursor fetches the cursor of the latest event in the partition from a server whose API implements
+// TailCursorProvider. A server without support responds with 501, returned as a *ResponseError.
+func (c Client) TailCursor(ctx context.Context, partitionID int) (string, error) {
+	return c.fetchCursor(ctx, "tail", partitionID, nil)
+}
+
+// fetchCursor fetches a single checkpoint for the partition from the endpoint /feed/v1/<path>, served by
+// cursorHandler.
+func (c Client) fetchCursor(ctx context.Context, path string, partitionID int, params url.Values) (string, error) {
+	var cursor *Cursor
+	err := c.fetchFromPartition(ctx, path, partitionID, params, func(body io.Reader) error {
+		err := DecodeStream(body, func(line Line) error {
+			if line.Kind != LineCheckpoint || line.Checkpoint.PartitionID != partitionID {
+				return errors.Errorf("unexpected %s line in %s cursor response", line.Kind, path)
+			}
+			cursor = line.Checkpoint
+			return nil
+		})
+		if err == nil && cursor == nil {
+			return errors.Errorf("no cursor in %s cursor response", path)
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return cursor.Cursor, nil
+}
+
+// fetchFromPartition requests the endpoint /feed/v1/<path> for the partition and passes the body of a 2xx
+// response to decode. Other responses are returned as a *ResponseError. Like FetchEvents, the request is subject to
+// the circuit breaker, request timeout, rate limit, fallback URLs and maximum response size of the Client.
+func (c Client) fetchFromPartition(ctx context.Context, path string, partitionID int, params url.Values, decode func(body io.Reader) error) error {
+	if err := c.checkQueryParams(); err != nil {
+		return err
+	}
+	return c.guard(ctx, func(ctx context.Context) error {
+		return c.withEndpoints(ctx, func(url string) (bool, error) {
+			return c.fetchFromPartitionAt(ctx, url, path, partitionID, params, decode)
+		})
+	})
+}
+
+// fetchFromPartitionAt does a single fetchFromPartition request against the given base URL. retry is true if the
+// request failed before anything was decoded, due to a connection error or a server error.
+func (c Client) fetchFromPartitionAt(ctx context.Context, url, path string, partitionID int, params url.Values, decode func(body io.Reader) error) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/feed/v1/%s", url, path), nil)
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	q := req.URL.Query()
+	q.Add("n", strconv.Itoa(c.partitionCount))
+	q.Add("partition", strconv.Itoa(partitionID))
+	for key, values := range params {
+		for _, value := range values {
+			q.Add(key, value)
+		}
+	}
+	c.renameParams(q)
+	c.addQueryParams(q)
+	req.URL.RawQuery = q.Encode()
+	if err := c.requestProcessor(req); err != nil {
+		return false, err
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return !errors.Is(err, ErrRedirected), err
+	}
+	defer closeBody(res.Body)
+	body, stop := c.responseBody(res)
+	defer stop()
+	if res.StatusCode/100 != 2 {
+		all, err := io.ReadAll(body)
+		if err != nil {
+			return false, err
+		}
+		return retryStatus(res.StatusCode), &ResponseError{StatusCode: res.StatusCode, Body: string(all)}
+	}
+	return false, decode(body)
+}
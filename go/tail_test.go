@@ -0,0 +1,81 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailCursor(t *testing.T) {
+	api := NewTestZeroEventHubAPI()
+	server := httptest.NewServer(Handler(nil, api))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	tail, err := client.TailCursor(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, "9999", tail)
+
+	// the tail is the last checkpoint when reading to the end
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{PartitionID: 1, Cursor: "9990"}}, 100, &page))
+	require.Equal(t, tail, page.Cursors[1])
+
+	_, err = client.TailCursor(context.Background(), 2)
+	require.Equal(t, &ResponseError{StatusCode: http.StatusBadRequest, Body: "partition doesn't exist\n"}, err)
+
+	_, err = NewClient(server.URL, 3).TailCursor(context.Background(), 0)
+	require.Equal(t, &ResponseError{StatusCode: http.StatusBadRequest, Body: "handshake error: partition count mismatch\n"}, err)
+}
+
+func TestTailCursorNotSupported(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, struct{ API }{NewTestZeroEventHubAPI()}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, 2).TailCursor(context.Background(), 0)
+	require.Equal(t, &ResponseError{StatusCode: http.StatusNotImplemented, Body: "tail cursor not supported\n"}, err)
+}
+
+func TestTailCursorClientConfiguration(t *testing.T) {
+	api := NewTestZeroEventHubAPI()
+	primary := &countingHandler{handler: Handler(nil, api), failing: 1}
+	fallback := &countingHandler{handler: Handler(nil, api)}
+	primaryServer := httptest.NewServer(primary)
+	defer primaryServer.Close()
+	fallbackServer := httptest.NewServer(fallback)
+	defer fallbackServer.Close()
+
+	// fails over to the fallback URL, as FetchEvents does
+	tail, err := NewClient(primaryServer.URL, 2).WithFallbackURLs(fallbackServer.URL).TailCursor(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, "9999", tail)
+	require.Equal(t, int32(1), atomic.LoadInt32(&primary.requests))
+	require.Equal(t, int32(1), atomic.LoadInt32(&fallback.requests))
+
+	// trips the circuit breaker, and fails fast once it is open
+	client := NewClient(primaryServer.URL, 2).WithCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1, OpenDuration: time.Hour})
+	_, err = client.TailCursor(context.Background(), 0)
+	require.Equal(t, &ResponseError{StatusCode: http.StatusInternalServerError, Body: "Internal server error\n"}, err)
+	_, err = client.TailCursor(context.Background(), 0)
+	require.Equal(t, ErrCircuitOpen, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&primary.requests))
+
+	// a server without support doesn't trip it
+	unsupported := httptest.NewServer(Handler(nil, struct{ API }{api}))
+	defer unsupported.Close()
+	client = NewClient(unsupported.URL, 2).WithCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 1, OpenDuration: time.Hour})
+	for i := 0; i < 2; i++ {
+		_, err = client.TailCursor(context.Background(), 0)
+		require.Equal(t, &ResponseError{StatusCode: http.StatusNotImplemented, Body: "tail cursor not supported\n"}, err)
+	}
+
+	// is limited by the maximum response size
+	_, err = NewClient(fallbackServer.URL, 2).WithMaxResponseBytes(10).TailCursor(context.Background(), 0)
+	require.True(t, errors.Is(err, ErrResponseTooLarge), err)
+}
@@ -0,0 +1,58 @@
+package zeroeventhub
+
+import "encoding/json"
+
+// TombstoneHeader is the header marking an event as a tombstone, i.e. the deletion of the entity the event is
+// about, for feeds of entity state. Its value is "true"; the data of a tombstone is the key of the entity, or null.
+// Like any header, it is only sent to consumers requesting it in FetchEvents.
+const TombstoneHeader = "deleted"
+
+// IsTombstone returns whether the event is a tombstone, i.e. it has TombstoneHeader set to "true".
+func (e Envelope) IsTombstone() bool {
+	return IsTombstone(e.Headers)
+}
+
+// IsTombstone returns whether the headers of an event mark it as a tombstone, for EventReceiver implementations.
+func IsTombstone(headers map[string]string) bool {
+	return headers[TombstoneHeader] == "true"
+}
+
+// SendTombstone passes a tombstone for the entity with the given key (which may be nil) to r, with TombstoneHeader
+// added to headers. Publishers call it on the receiver passed to FetchEvents, as they call Event for updates.
+func SendTombstone(r EventReceiver, partitionID int, headers map[string]string, key json.RawMessage) error {
+	withTombstone := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		withTombstone[k] = v
+	}
+	withTombstone[TombstoneHeader] = "true"
+	if key == nil {
+		key = json.RawMessage("null")
+	}
+	return r.Event(partitionID, withTombstone, key)
+}
+
+// Tombstone writes a tombstone; see SendTombstone.
+func (s NDJSONEventSerializer) Tombstone(partitionID int, headers map[string]string, key json.RawMessage) error {
+	return SendTombstone(s, partitionID, headers, key)
+}
+
+// TombstoneRouter implements EventReceiver by passing tombstones to OnTombstone and other events and checkpoints
+// to Receiver, e.g. an EventPageSingleType, which can't decode the data of a tombstone into its event type. The
+// consumer must request TombstoneHeader in FetchEvents for tombstones to be recognized.
+type TombstoneRouter struct {
+	Receiver    EventReceiver
+	OnTombstone func(partitionID int, headers map[string]string, key json.RawMessage) error
+}
+
+func (t TombstoneRouter) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if IsTombstone(headers) {
+		return t.OnTombstone(partitionID, headers, data)
+	}
+	return t.Receiver.Event(partitionID, headers, data)
+}
+
+func (t TombstoneRouter) Checkpoint(partitionID int, cursor string) error {
+	return t.Receiver.Checkpoint(partitionID, cursor)
+}
+
+var _ EventReceiver = TombstoneRouter{}
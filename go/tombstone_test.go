@@ -0,0 +1,94 @@
+package zeroeventhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type entity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// entityAPI is an API serving a feed of entity state in partition 0: two updates and a deletion.
+type entityAPI struct {
+	*TestZeroEventHubAPI
+}
+
+func (entityAPI) FetchEvents(ctx context.Context, cursors []Cursor, pageSizeHint int, r EventReceiver, headers ...string) error {
+	for i, update := range []string{`{"id":"a","name":"first"}`, `{"id":"b","name":"second"}`} {
+		if err := r.Event(0, map[string]string{"type": "entity"}, json.RawMessage(update)); err != nil {
+			return err
+		}
+		if err := r.Checkpoint(0, string(rune('1'+i))); err != nil {
+			return err
+		}
+	}
+	if err := SendTombstone(r, 0, map[string]string{"type": "entity"}, json.RawMessage(`"a"`)); err != nil {
+		return err
+	}
+	return r.Checkpoint(0, "3")
+}
+
+func TestTombstones(t *testing.T) {
+	server := httptest.NewServer(Handler(nil, entityAPI{NewTestZeroEventHubAPI()}))
+	defer server.Close()
+	client := NewClient(server.URL, 2)
+
+	var raw EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &raw, TombstoneHeader))
+	require.Len(t, raw.Events, 3)
+	require.False(t, raw.Events[0].IsTombstone())
+	require.False(t, raw.Events[1].IsTombstone())
+	require.True(t, raw.Events[2].IsTombstone())
+	require.JSONEq(t, `"a"`, string(raw.Events[2].Data))
+
+	// rebuilding the state, with the tombstones routed around the typed page
+	state := map[string]entity{}
+	var page EventPageSingleType[entity]
+	router := TombstoneRouter{
+		Receiver: &page,
+		OnTombstone: func(partitionID int, headers map[string]string, key json.RawMessage) error {
+			var id string
+			if err := json.Unmarshal(key, &id); err != nil {
+				return err
+			}
+			for _, event := range page.Events {
+				state[event.Data.ID] = event.Data
+			}
+			page.Events = nil
+			delete(state, id)
+			return nil
+		},
+	}
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, router, TombstoneHeader))
+	for _, event := range page.Events {
+		state[event.Data.ID] = event.Data
+	}
+	require.Equal(t, map[string]entity{"b": {ID: "b", Name: "second"}}, state)
+	require.Equal(t, "3", page.Cursors[0])
+
+	// the header is only sent to consumers requesting it
+	raw = EventPageRaw{}
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: FirstCursor}}, DefaultPageSize, &raw))
+	require.False(t, raw.Events[2].IsTombstone())
+}
+
+func TestSerializerTombstone(t *testing.T) {
+	var buf bytes.Buffer
+	serializer := NewNDJSONEventSerializer(&buf)
+	headers := map[string]string{"type": "entity"}
+	require.NoError(t, serializer.Tombstone(1, headers, nil))
+	require.NoError(t, serializer.Tombstone(1, nil, json.RawMessage(`{"id":"a"}`)))
+	require.Equal(t, map[string]string{"type": "entity"}, headers)
+	require.Equal(t, `{"partition":1,"headers":{"deleted":"true","type":"entity"},"data":null}`+"\n"+
+		`{"partition":1,"headers":{"deleted":"true"},"data":{"id":"a"}}`+"\n", buf.String())
+	require.True(t, IsTombstone(map[string]string{TombstoneHeader: "true"}))
+	require.False(t, IsTombstone(map[string]string{TombstoneHeader: "false"}))
+	require.False(t, Envelope{}.IsTombstone())
+}
@@ -0,0 +1,132 @@
+package zeroeventhub
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// FetchTrace is the timing of a single request made by Client.FetchEvents, phase by phase, for ad-hoc debugging of
+// latency. Phases that didn't happen, e.g. DNS and Connect on a reused connection, are 0.
+type FetchTrace struct {
+	// URL is the base URL of the feed the request was made to.
+	URL string
+	// DNS is the time spent looking up the host.
+	DNS time.Duration
+	// Connect is the time spent establishing the TCP connection.
+	Connect time.Duration
+	// TLSHandshake is the time spent on the TLS handshake.
+	TLSHandshake time.Duration
+	// ConnReused is true if the request was sent on an idle connection.
+	ConnReused bool
+	// TimeToFirstByte is the time from writing the request to the first byte of the response.
+	TimeToFirstByte time.Duration
+	// BodyRead is the time from the first byte of the response to the end of the page.
+	BodyRead time.Duration
+	// Total is the time from starting the request to the end of the page.
+	Total time.Duration
+	// StatusCode is the status of the response, or 0 if there was none.
+	StatusCode int
+	// Events and Bytes are the number of events and the size of the response body of a 2xx response.
+	Events int
+	Bytes  int64
+	// Err is the error the request failed with, if any.
+	Err error
+}
+
+// WithTrace is a Client method for getting a FetchTrace of every request made by FetchEvents, including resumed and
+// failed-over requests, once the request is done. It is more detailed than a span, e.g. for finding out whether
+// time goes to connecting or to the server. With WithHedging, the phases may come from different attempts.
+// Pass nil to stop tracing.
+func (c Client) WithTrace(trace func(FetchTrace)) (r Client) {
+	r = c
+	r.onTrace = trace
+	return
+}
+
+// fetchTracer records the times of the phases of a request, through an httptrace.ClientTrace. Its methods do
+// nothing on a nil fetchTracer, which is used when tracing is off.
+type fetchTracer struct {
+	lock                       sync.Mutex
+	start                      time.Time
+	dnsStart, connectStart     time.Time
+	tlsStart, wroteRequest     time.Time
+	firstByte                  time.Time
+	dns, connect, tlsHandshake time.Duration
+	reused                     bool
+}
+
+// newFetchTracer returns a fetchTracer for a request starting now, or nil if tracing is off.
+func (c Client) newFetchTracer() *fetchTracer {
+	if c.onTrace == nil {
+		return nil
+	}
+	return &fetchTracer{start: time.Now()}
+}
+
+// trace returns req with the tracer added to its context.
+func (t *fetchTracer) trace(req *http.Request) *http.Request {
+	if t == nil {
+		return req
+	}
+	record := func(f func()) {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		f()
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			record(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			record(func() { t.dns = time.Since(t.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			record(func() { t.connectStart = time.Now() })
+		},
+		ConnectDone: func(string, string, error) {
+			record(func() { t.connect = time.Since(t.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			record(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			record(func() { t.tlsHandshake = time.Since(t.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			record(func() { t.reused = info.Reused })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			record(func() { t.wroteRequest = time.Now() })
+		},
+		GotFirstResponseByte: func() {
+			record(func() { t.firstByte = time.Now() })
+		},
+	}))
+}
+
+// finishFetchTrace passes the trace of the request to url, which ended with err, to the callback of the client.
+func (c Client) finishFetchTrace(t *fetchTracer, url string, res *http.Response, summary *pageSummarizer, err error) {
+	if t == nil {
+		return
+	}
+	end := time.Now()
+	trace := FetchTrace{URL: url, Total: end.Sub(t.start), Err: err}
+	t.lock.Lock()
+	trace.DNS, trace.Connect, trace.TLSHandshake, trace.ConnReused = t.dns, t.connect, t.tlsHandshake, t.reused
+	if !t.firstByte.IsZero() {
+		trace.TimeToFirstByte = t.firstByte.Sub(t.wroteRequest)
+		trace.BodyRead = end.Sub(t.firstByte)
+	}
+	t.lock.Unlock()
+	if res != nil {
+		trace.StatusCode = res.StatusCode
+	}
+	if summary != nil {
+		trace.Events = summary.summary.Events
+		trace.Bytes = summary.summary.Bytes
+	}
+	c.onTrace(trace)
+}
@@ -0,0 +1,67 @@
+package zeroeventhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	handler := Handler(nil, NewTestZeroEventHubAPI())
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		// time for the server to think, and to send the body
+		time.Sleep(10 * time.Millisecond)
+		handler.ServeHTTP(&slowWriter{ResponseWriter: writer}, request)
+	}))
+	defer server.Close()
+	// by name, so that there is a lookup
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	var traces []FetchTrace
+	client := NewClient(url, 2).WithTrace(func(trace FetchTrace) {
+		traces = append(traces, trace)
+	})
+
+	var page EventPageRaw
+	require.NoError(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: "10"}}, 5, &page))
+	require.Len(t, traces, 1)
+	trace := traces[0]
+	require.Equal(t, url, trace.URL)
+	require.True(t, trace.DNS > 0, "%v", trace.DNS)
+	require.True(t, trace.Connect > 0, "%v", trace.Connect)
+	require.False(t, trace.ConnReused)
+	require.True(t, trace.TimeToFirstByte >= 10*time.Millisecond, "%v", trace.TimeToFirstByte)
+	require.True(t, trace.BodyRead >= 10*time.Millisecond, "%v", trace.BodyRead)
+	require.True(t, trace.Total >= trace.DNS+trace.Connect+trace.TimeToFirstByte+trace.BodyRead, "%+v", trace)
+	require.Equal(t, http.StatusOK, trace.StatusCode)
+	require.Equal(t, 5, trace.Events)
+	require.True(t, trace.Bytes > 0)
+	require.NoError(t, trace.Err)
+
+	// the connection is reused, and errors are traced too
+	require.Error(t, client.FetchEvents(context.Background(), []Cursor{{Cursor: cursorReturn500}}, 5, &page))
+	require.Len(t, traces, 2)
+	require.True(t, traces[1].ConnReused)
+	require.Equal(t, time.Duration(0), traces[1].Connect)
+	require.Equal(t, http.StatusInternalServerError, traces[1].StatusCode)
+	require.Error(t, traces[1].Err)
+}
+
+// slowWriter delays the second write of a response, after flushing the first.
+type slowWriter struct {
+	http.ResponseWriter
+	writes int
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes == 2 {
+		w.ResponseWriter.(http.Flusher).Flush()
+		time.Sleep(10 * time.Millisecond)
+	}
+	return w.ResponseWriter.Write(p)
+}
@@ -0,0 +1,158 @@
+package zeroeventhubtest
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// ErrChaos is the error injected by ChaosAPI.
+var ErrChaos = errors.New("chaos: injected error")
+
+// ChaosSettings configures the misbehavior of ChaosAPI. The probabilities are per page and partition,
+// between 0 (never) and 1 (always).
+type ChaosSettings struct {
+	// Seed seeds the random choices; the same seed and sequence of requests give the same behavior.
+	Seed int64
+	// MaxLatency is the upper bound of a random delay before every page.
+	MaxLatency time.Duration
+	// ErrorBeforeProbability is the probability of failing with ErrChaos before any event.
+	ErrorBeforeProbability float64
+	// ErrorAfterProbability is the probability of failing with ErrChaos after ErrorAfterEvents events.
+	ErrorAfterProbability float64
+	ErrorAfterEvents      int
+	// DuplicateProbability is the probability of repeating some of the last events of the previous page of the
+	// partition (without their checkpoints) before the page, as at-least-once delivery allows.
+	DuplicateProbability float64
+	// TruncateProbability is the probability of ending the page after an event, without its checkpoint.
+	// The checkpoint of the first event is kept, so the consumer still makes progress.
+	TruncateProbability float64
+	// MalformedHeadersProbability is the probability of replacing the headers of an event with an odd map
+	// (empty key, control characters and invalid UTF-8).
+	MalformedHeadersProbability float64
+}
+
+// ChaosAPI wraps an API, misbehaving on purpose as configured by ChaosSettings, to test that consumers
+// handle latency, transient errors, duplicated events and truncated pages.
+type ChaosAPI struct {
+	zeroeventhub.API
+	settings ChaosSettings
+
+	lock     sync.Mutex
+	random   *rand.Rand
+	lastPage map[int][]zeroeventhub.Envelope
+}
+
+// NewChaosAPI returns a ChaosAPI wrapping api.
+func NewChaosAPI(api zeroeventhub.API, settings ChaosSettings) *ChaosAPI {
+	return &ChaosAPI{
+		API:      api,
+		settings: settings,
+		random:   rand.New(rand.NewSource(settings.Seed)),
+		lastPage: make(map[int][]zeroeventhub.Envelope),
+	}
+}
+
+// chaosPlan is what goes wrong with the page of a partition.
+type chaosPlan struct {
+	latency        time.Duration
+	errorBefore    bool
+	errorAfter     int
+	duplicates     []zeroeventhub.Envelope
+	truncateAfter  int
+	malformedEvent int
+}
+
+func (c *ChaosAPI) chance(probability float64) bool {
+	return probability > 0 && c.random.Float64() < probability
+}
+
+func (c *ChaosAPI) plan(partitionID int) chaosPlan {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	plan := chaosPlan{errorAfter: -1, truncateAfter: -1, malformedEvent: -1}
+	if c.settings.MaxLatency > 0 {
+		plan.latency = time.Duration(c.random.Int63n(int64(c.settings.MaxLatency)))
+	}
+	plan.errorBefore = c.chance(c.settings.ErrorBeforeProbability)
+	if c.chance(c.settings.ErrorAfterProbability) {
+		plan.errorAfter = c.settings.ErrorAfterEvents
+	}
+	if last := c.lastPage[partitionID]; len(last) > 0 && c.chance(c.settings.DuplicateProbability) {
+		plan.duplicates = last[len(last)-1-c.random.Intn(len(last)):]
+	}
+	if c.chance(c.settings.TruncateProbability) {
+		// after the second event at the earliest, so that the checkpoint of the first one is kept
+		plan.truncateAfter = 2 + c.random.Intn(10)
+	}
+	if c.chance(c.settings.MalformedHeadersProbability) {
+		plan.malformedEvent = c.random.Intn(10)
+	}
+	return plan
+}
+
+func (c *ChaosAPI) FetchEvents(ctx context.Context, cursors []zeroeventhub.Cursor, pageSizeHint int, r zeroeventhub.EventReceiver, headers ...string) error {
+	for _, cursor := range cursors {
+		plan := c.plan(cursor.PartitionID)
+		if plan.latency > 0 {
+			select {
+			case <-time.After(plan.latency):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if plan.errorBefore {
+			return ErrChaos
+		}
+		for _, duplicate := range plan.duplicates {
+			if err := r.Event(duplicate.PartitionID, duplicate.Headers, duplicate.Data); err != nil {
+				return err
+			}
+		}
+		receiver := &chaosReceiver{receiver: r, plan: plan}
+		err := c.API.FetchEvents(ctx, []zeroeventhub.Cursor{cursor}, pageSizeHint, receiver, headers...)
+		c.lock.Lock()
+		c.lastPage[cursor.PartitionID] = receiver.delivered
+		c.lock.Unlock()
+		if errors.Is(err, errTruncated) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var errTruncated = errors.New("page truncated")
+
+// chaosReceiver carries out the plan for the events of a page.
+type chaosReceiver struct {
+	receiver  zeroeventhub.EventReceiver
+	plan      chaosPlan
+	delivered []zeroeventhub.Envelope
+}
+
+func (r *chaosReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	events := len(r.delivered)
+	if events == r.plan.errorAfter {
+		return ErrChaos
+	}
+	if events == r.plan.malformedEvent {
+		headers = map[string]string{"": "", "\x00\n": "\xff\xfe"}
+	}
+	r.delivered = append(r.delivered, zeroeventhub.Envelope{PartitionID: partitionID, Headers: headers, Data: data})
+	return r.receiver.Event(partitionID, headers, data)
+}
+
+func (r *chaosReceiver) Checkpoint(partitionID int, cursor string) error {
+	if len(r.delivered) == r.plan.truncateAfter {
+		return errTruncated
+	}
+	return r.receiver.Checkpoint(partitionID, cursor)
+}
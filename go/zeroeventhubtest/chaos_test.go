@@ -0,0 +1,86 @@
+package zeroeventhubtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// deduplicatingConsumer records the data of every event seen for the first time, and the latest cursor.
+type deduplicatingConsumer struct {
+	seen       map[string]bool
+	events     []string
+	duplicates int
+	cursor     string
+}
+
+func (d *deduplicatingConsumer) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if d.seen[string(data)] {
+		d.duplicates++
+		return nil
+	}
+	d.seen[string(data)] = true
+	d.events = append(d.events, string(data))
+	return nil
+}
+
+func (d *deduplicatingConsumer) Checkpoint(partitionID int, cursor string) error {
+	d.cursor = cursor
+	return nil
+}
+
+// consume reads the partition to the end through chaos, retrying failed pages.
+func consume(t *testing.T, settings ChaosSettings) (*deduplicatingConsumer, int) {
+	events := make([]string, 200)
+	for i := range events {
+		events[i] = fmt.Sprintf(`{"n":%d}`, i)
+	}
+	server := httptest.NewServer(zeroeventhub.Handler(nil, NewChaosAPI(memoryAPI{partitions: [][]string{events}}, settings)))
+	defer server.Close()
+	client := zeroeventhub.NewClient(server.URL, 1)
+
+	consumer := &deduplicatingConsumer{seen: make(map[string]bool), cursor: zeroeventhub.FirstCursor}
+	failures := 0
+	for i := 0; i < 1000; i++ {
+		cursor := consumer.cursor
+		err := client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{Cursor: cursor}}, 10, consumer, zeroeventhub.All)
+		if err != nil {
+			failures++
+			continue
+		}
+		if consumer.cursor == cursor {
+			require.Equal(t, events, consumer.events)
+			return consumer, failures
+		}
+	}
+	require.FailNow(t, "partition not consumed")
+	return nil, 0
+}
+
+func TestChaosAPI(t *testing.T) {
+	settings := ChaosSettings{
+		Seed:                        42,
+		MaxLatency:                  time.Millisecond,
+		ErrorBeforeProbability:      0.1,
+		ErrorAfterProbability:       0.1,
+		ErrorAfterEvents:            3,
+		DuplicateProbability:        0.3,
+		TruncateProbability:         0.2,
+		MalformedHeadersProbability: 0.2,
+	}
+	consumer, failures := consume(t, settings)
+	require.Equal(t, "199", consumer.cursor)
+	require.True(t, consumer.duplicates > 0)
+	require.True(t, failures > 0)
+
+	// reproducible
+	again, againFailures := consume(t, settings)
+	require.Equal(t, consumer.duplicates, again.duplicates)
+	require.Equal(t, failures, againFailures)
+}
@@ -0,0 +1,429 @@
+// Package zeroeventhubtest contains helpers for testing implementations of the ZeroEventHub protocol.
+package zeroeventhubtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// ConformanceOptions configures RunAPIConformance and RunServerConformance.
+type ConformanceOptions struct {
+	// PageSizes are the page size hints to check paging continuity with; defaults to 1, 2, 3, 7 and 100.
+	PageSizes []int
+	// OutOfRangeCursor, if set, is a cursor that is valid syntax for the publisher but points past the end of
+	// every partition. Fetching from it must either fail or return no events, and do the same every time.
+	OutOfRangeCursor string
+	// MalformedCursor, if set, is a cursor the server must reject with a 4xx response; only used by
+	// RunServerConformance.
+	MalformedCursor string
+	// MaxPages limits the number of pages read when draining a partition; defaults to 10000.
+	MaxPages int
+	// Cursors, if set, compares the cursors of the publisher; the checkpoints of a partition must then be
+	// strictly increasing. Leave it nil for opaque cursors.
+	Cursors zeroeventhub.CursorComparator
+	// StrictPageSize requires pages to have at most as many events as the page size hint. The protocol only
+	// makes it a hint, but a publisher may promise more.
+	StrictPageSize bool
+	// StrictDecoding fetches with zeroeventhub.Client.WithStrictDecoding, failing on lines that don't follow the
+	// protocol, like events without data or checkpoints with it.
+	StrictDecoding bool
+
+	// SkipLastCursor skips the checks of zeroeventhub.LastCursor.
+	SkipLastCursor bool
+	// SkipResume skips resuming from every emitted checkpoint, which takes one request per event.
+	SkipResume bool
+	// SkipCancellation skips checking that FetchEvents stops when its context is cancelled.
+	SkipCancellation bool
+}
+
+// RunAPIConformance runs a suite of subtests checking that an API implementation behaves according to the protocol
+// when served by zeroeventhub.Handler. The factory is called for every subtest and must return an API whose
+// partitions contain the same events every time, at least one of them non-empty. As the events are read one
+// at a time and from every checkpoint, the API should hold tens rather than thousands of events. It is checked for:
+//
+//   - a checkpoint after the events of every page,
+//   - FirstCursor starting at the first event and LastCursor near the end,
+//   - paging continuity for all the page sizes: no gaps and no duplicates when following the checkpoints,
+//   - resuming from every emitted checkpoint at the event following it,
+//   - checkpoints in cursor order and pages within the page size hint, if asked for in the options,
+//   - stable behavior at the end of a partition and on out-of-range cursors,
+//   - FetchEvents honoring cancellation of its context.
+func RunAPIConformance(t *testing.T, factory func() zeroeventhub.API, opts ConformanceOptions) {
+	runFeedConformance(t, func(t *testing.T) *feed {
+		return newFeed(t, factory())
+	}, opts)
+
+	t.Run("cancellation", func(t *testing.T) {
+		if opts.SkipCancellation {
+			t.Skip("skipped")
+		}
+		api := factory()
+		for partitionID := 0; partitionID < api.GetPartitionCount(); partitionID++ {
+			cursors := []zeroeventhub.Cursor{{PartitionID: partitionID, Cursor: zeroeventhub.FirstCursor}}
+			// only a page with events left after the first one can show whether the cancellation is honored
+			counter := &cancellingReceiver{cancel: func() {}}
+			require.NoError(t, api.FetchEvents(context.Background(), cursors, 1000, counter), "partition %d", partitionID)
+			if counter.events < 2 {
+				continue
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			receiver := &cancellingReceiver{cancel: cancel}
+			err := api.FetchEvents(ctx, cursors, 1000, receiver)
+			cancel()
+			require.Error(t, err, "partition %d: FetchEvents must fail when its context is cancelled", partitionID)
+		}
+	})
+}
+
+// RunServerConformance runs the checks of RunAPIConformance, except for cancellation, against a running server,
+// e.g. one implementing the protocol in another language. newClient must return a client of the server for the
+// given partition count, set up with whatever the server needs, like authentication; it is also called with a
+// wrong partition count to check the handshake. The server must hold the same events for the whole run. In
+// addition, requests the server must reject with a 4xx response are checked: a partition count mismatch,
+// a cursor for a partition that doesn't exist and ConformanceOptions.MalformedCursor, if set.
+func RunServerConformance(t *testing.T, newClient func(partitionCount int) zeroeventhub.Client, partitionCount int, opts ConformanceOptions) {
+	runFeedConformance(t, func(t *testing.T) *feed {
+		return &feed{client: newClient(partitionCount), partitionCount: partitionCount}
+	}, opts)
+
+	rejected := func(t *testing.T, client zeroeventhub.Client, cursor zeroeventhub.Cursor, msgAndArgs ...interface{}) {
+		var page zeroeventhub.EventPageRaw
+		err := client.FetchEvents(context.Background(), []zeroeventhub.Cursor{cursor}, zeroeventhub.DefaultPageSize, &page)
+		var responseErr *zeroeventhub.ResponseError
+		require.True(t, errors.As(err, &responseErr), "expected an error response, got %v", err)
+		require.True(t, responseErr.StatusCode/100 == 4, "expected a 4xx response, got %d %q", responseErr.StatusCode, responseErr.Body)
+		require.Empty(t, page.Events, msgAndArgs...)
+	}
+
+	t.Run("handshake", func(t *testing.T) {
+		rejected(t, newClient(partitionCount+1), zeroeventhub.Cursor{PartitionID: 0, Cursor: zeroeventhub.FirstCursor})
+	})
+
+	t.Run("unknown partition", func(t *testing.T) {
+		rejected(t, newClient(partitionCount), zeroeventhub.Cursor{PartitionID: partitionCount, Cursor: zeroeventhub.FirstCursor})
+	})
+
+	t.Run("malformed cursor", func(t *testing.T) {
+		if opts.MalformedCursor == "" {
+			t.Skip("no MalformedCursor given")
+		}
+		client := newClient(partitionCount)
+		for partitionID := 0; partitionID < partitionCount; partitionID++ {
+			rejected(t, client, zeroeventhub.Cursor{PartitionID: partitionID, Cursor: opts.MalformedCursor}, "partition %d", partitionID)
+		}
+	})
+}
+
+// runFeedConformance runs the checks that only need a feed; open is called for every subtest.
+func runFeedConformance(t *testing.T, open func(t *testing.T) *feed, opts ConformanceOptions) {
+	if len(opts.PageSizes) == 0 {
+		opts.PageSizes = []int{1, 2, 3, 7, 100}
+	}
+	if opts.MaxPages == 0 {
+		opts.MaxPages = 10000
+	}
+	if opts.StrictDecoding {
+		openLenient := open
+		open = func(t *testing.T) *feed {
+			feed := openLenient(t)
+			feed.client = feed.client.WithStrictDecoding()
+			return feed
+		}
+	}
+
+	// reference is everything in each partition, read one event at a time
+	reference := func(t *testing.T, feed *feed) [][]recordedEvent {
+		result := make([][]recordedEvent, feed.partitionCount)
+		for partitionID := range result {
+			result[partitionID] = feed.drain(t, zeroeventhub.FirstCursor, partitionID, 1, opts.MaxPages).events
+		}
+		return result
+	}
+
+	t.Run("partitions", func(t *testing.T) {
+		feed := open(t)
+		require.True(t, feed.partitionCount > 0, "GetPartitionCount must be positive")
+		total := 0
+		for _, events := range reference(t, feed) {
+			total += len(events)
+		}
+		require.True(t, total > 0, "the factory must return an API with events")
+	})
+
+	t.Run("checkpoint after every page", func(t *testing.T) {
+		feed := open(t)
+		for partitionID := 0; partitionID < feed.partitionCount; partitionID++ {
+			for _, pageSize := range opts.PageSizes {
+				cursor := zeroeventhub.FirstCursor
+				for page := 0; page < opts.MaxPages; page++ {
+					result := feed.fetch(t, cursor, partitionID, pageSize)
+					if len(result.events) == 0 {
+						break
+					}
+					require.NotEmpty(t, result.calls, "partition %d, page size %d", partitionID, pageSize)
+					last := result.calls[len(result.calls)-1]
+					require.True(t, last.checkpoint, "partition %d, page size %d: page from %q doesn't end with a checkpoint", partitionID, pageSize, cursor)
+					cursor = last.cursor
+				}
+			}
+		}
+	})
+
+	t.Run("first cursor", func(t *testing.T) {
+		feed := open(t)
+		for partitionID, events := range reference(t, feed) {
+			if len(events) == 0 {
+				continue
+			}
+			result := feed.fetch(t, zeroeventhub.FirstCursor, partitionID, zeroeventhub.DefaultPageSize)
+			require.NotEmpty(t, result.events, "partition %d", partitionID)
+			require.Equal(t, events[0], result.events[0], "partition %d: FirstCursor must start at the first event", partitionID)
+		}
+	})
+
+	t.Run("last cursor", func(t *testing.T) {
+		if opts.SkipLastCursor {
+			t.Skip("LastCursor not supported")
+		}
+		feed := open(t)
+		for partitionID, events := range reference(t, feed) {
+			tail := feed.drain(t, zeroeventhub.LastCursor, partitionID, zeroeventhub.DefaultPageSize, opts.MaxPages).events
+			require.True(t, len(tail) <= len(events), "partition %d: LastCursor returned more events than FirstCursor", partitionID)
+			require.Equal(t, events[len(events)-len(tail):], tail, "partition %d: LastCursor must continue to the end of the partition", partitionID)
+		}
+	})
+
+	t.Run("paging continuity", func(t *testing.T) {
+		feed := open(t)
+		for partitionID, events := range reference(t, feed) {
+			for _, pageSize := range opts.PageSizes {
+				drained := feed.drain(t, zeroeventhub.FirstCursor, partitionID, pageSize, opts.MaxPages)
+				require.Equal(t, events, drained.events, "partition %d, page size %d", partitionID, pageSize)
+			}
+		}
+	})
+
+	t.Run("ordered checkpoints", func(t *testing.T) {
+		if opts.Cursors == nil {
+			t.Skip("no Cursors given")
+		}
+		feed := open(t)
+		for partitionID := 0; partitionID < feed.partitionCount; partitionID++ {
+			for _, pageSize := range opts.PageSizes {
+				drained := feed.drain(t, zeroeventhub.FirstCursor, partitionID, pageSize, opts.MaxPages)
+				AssertOrdered(t, drained.checkpoints(), opts.Cursors, "partition %d, page size %d", partitionID, pageSize)
+			}
+		}
+	})
+
+	t.Run("page size", func(t *testing.T) {
+		if !opts.StrictPageSize {
+			t.Skip("StrictPageSize not set")
+		}
+		feed := open(t)
+		for partitionID := 0; partitionID < feed.partitionCount; partitionID++ {
+			for _, pageSize := range opts.PageSizes {
+				cursor := zeroeventhub.FirstCursor
+				for page := 0; page < opts.MaxPages; page++ {
+					result := feed.fetch(t, cursor, partitionID, pageSize)
+					if len(result.events) == 0 {
+						break
+					}
+					require.True(t, len(result.events) <= pageSize, "partition %d: page from %q has %d events, more than the hint %d", partitionID, cursor, len(result.events), pageSize)
+					cursor = result.cursor
+				}
+			}
+		}
+	})
+
+	t.Run("resume from every checkpoint", func(t *testing.T) {
+		if opts.SkipResume {
+			t.Skip("skipped")
+		}
+		feed := open(t)
+		for partitionID, events := range reference(t, feed) {
+			// the checkpoints of a full read, and how many events precede each of them
+			drained := feed.drain(t, zeroeventhub.FirstCursor, partitionID, zeroeventhub.DefaultPageSize, opts.MaxPages)
+			eventsBefore := 0
+			for _, call := range drained.calls {
+				if !call.checkpoint {
+					eventsBefore++
+					continue
+				}
+				resumed := feed.fetch(t, call.cursor, partitionID, 1)
+				if eventsBefore == len(events) {
+					require.Empty(t, resumed.events, "partition %d: resuming from the last checkpoint %q", partitionID, call.cursor)
+					continue
+				}
+				require.NotEmpty(t, resumed.events, "partition %d: resuming from checkpoint %q", partitionID, call.cursor)
+				require.Equal(t, events[eventsBefore], resumed.events[0], "partition %d: resuming from checkpoint %q", partitionID, call.cursor)
+			}
+		}
+	})
+
+	t.Run("end of partition", func(t *testing.T) {
+		feed := open(t)
+		for partitionID := 0; partitionID < feed.partitionCount; partitionID++ {
+			cursor := feed.drain(t, zeroeventhub.FirstCursor, partitionID, zeroeventhub.DefaultPageSize, opts.MaxPages).cursor
+			if cursor == zeroeventhub.FirstCursor {
+				continue
+			}
+			for i := 0; i < 2; i++ {
+				result := feed.fetch(t, cursor, partitionID, zeroeventhub.DefaultPageSize)
+				require.Empty(t, result.events, "partition %d: fetching from the last checkpoint %q", partitionID, cursor)
+			}
+		}
+	})
+
+	t.Run("out of range cursor", func(t *testing.T) {
+		if opts.OutOfRangeCursor == "" {
+			t.Skip("no OutOfRangeCursor given")
+		}
+		feed := open(t)
+		for partitionID := 0; partitionID < feed.partitionCount; partitionID++ {
+			var firstErr error
+			for i := 0; i < 2; i++ {
+				var page zeroeventhub.EventPageRaw
+				err := feed.client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{PartitionID: partitionID, Cursor: opts.OutOfRangeCursor}}, zeroeventhub.DefaultPageSize, &page)
+				if i == 0 {
+					firstErr = err
+				} else {
+					require.Equal(t, firstErr == nil, err == nil, "partition %d: out of range cursor must behave the same every time", partitionID)
+				}
+				require.Empty(t, page.Events, "partition %d: out of range cursor", partitionID)
+			}
+		}
+	})
+}
+
+// feed serves an API with zeroeventhub.Handler and fetches from it with zeroeventhub.Client.
+type feed struct {
+	client         zeroeventhub.Client
+	partitionCount int
+}
+
+func newFeed(t *testing.T, api zeroeventhub.API) *feed {
+	server := httptest.NewServer(zeroeventhub.Handler(nil, api))
+	t.Cleanup(server.Close)
+	return &feed{
+		client:         zeroeventhub.NewClient(server.URL, api.GetPartitionCount()),
+		partitionCount: api.GetPartitionCount(),
+	}
+}
+
+type recordedEvent struct {
+	Headers map[string]string
+	Data    string
+}
+
+type recordedCall struct {
+	checkpoint bool
+	cursor     string
+}
+
+type fetchResult struct {
+	calls  []recordedCall
+	events []recordedEvent
+	// cursor is the last checkpoint, or the cursor fetched from if there was none
+	cursor string
+}
+
+// recorder records the calls of a single partition, failing on calls for any other partition.
+type recorder struct {
+	partitionID int
+	result      *fetchResult
+}
+
+func (r recorder) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	if partitionID != r.partitionID {
+		return errors.Errorf("event for partition %d when fetching partition %d", partitionID, r.partitionID)
+	}
+	r.result.calls = append(r.result.calls, recordedCall{})
+	r.result.events = append(r.result.events, recordedEvent{Headers: headers, Data: string(data)})
+	return nil
+}
+
+func (r recorder) Checkpoint(partitionID int, cursor string) error {
+	if partitionID != r.partitionID {
+		return errors.Errorf("checkpoint for partition %d when fetching partition %d", partitionID, r.partitionID)
+	}
+	r.result.calls = append(r.result.calls, recordedCall{checkpoint: true, cursor: cursor})
+	r.result.cursor = cursor
+	return nil
+}
+
+func (r fetchResult) checkpoints() []string {
+	var cursors []string
+	for _, call := range r.calls {
+		if call.checkpoint {
+			cursors = append(cursors, call.cursor)
+		}
+	}
+	return cursors
+}
+
+func (f *feed) fetch(t *testing.T, cursor string, partitionID int, pageSize int) fetchResult {
+	result := fetchResult{cursor: cursor}
+	err := f.client.FetchEvents(context.Background(), []zeroeventhub.Cursor{{PartitionID: partitionID, Cursor: cursor}}, pageSize, recorder{partitionID: partitionID, result: &result}, zeroeventhub.All)
+	require.NoError(t, err, "partition %d, cursor %q, page size %d", partitionID, cursor, pageSize)
+	return result
+}
+
+// drain follows the checkpoints from cursor until a page without events.
+func (f *feed) drain(t *testing.T, cursor string, partitionID int, pageSize int, maxPages int) fetchResult {
+	var result fetchResult
+	for page := 0; page < maxPages; page++ {
+		next := f.fetch(t, cursor, partitionID, pageSize)
+		if len(next.events) == 0 {
+			result.cursor = cursor
+			return result
+		}
+		require.NotEqual(t, cursor, next.cursor, "partition %d: page from %q has events but doesn't advance the cursor", partitionID, cursor)
+		result.calls = append(result.calls, next.calls...)
+		result.events = append(result.events, next.events...)
+		cursor = next.cursor
+	}
+	require.FailNow(t, "partition not drained", "partition %d: still events after %d pages", partitionID, maxPages)
+	return result
+}
+
+// AssertOrdered asserts that cursors, the checkpoints of one partition in the order they were received, are
+// strictly increasing according to cmp. Cursors that cmp can't compare fail the assertion. It returns whether
+// the assertion succeeded, and can be used in tests of consumers as well as of publishers.
+func AssertOrdered(t *testing.T, cursors []string, cmp zeroeventhub.CursorComparator, msgAndArgs ...interface{}) bool {
+	t.Helper()
+	for i := 1; i < len(cursors); i++ {
+		order, ok := cmp(cursors[i-1], cursors[i])
+		if !ok {
+			return assert.Fail(t, fmt.Sprintf("cursors %q and %q can't be compared", cursors[i-1], cursors[i]), msgAndArgs...)
+		}
+		if order >= 0 {
+			return assert.Fail(t, fmt.Sprintf("cursor %q at index %d isn't after %q", cursors[i], i, cursors[i-1]), msgAndArgs...)
+		}
+	}
+	return true
+}
+
+// cancellingReceiver counts the events, calling cancel on each.
+type cancellingReceiver struct {
+	cancel context.CancelFunc
+	events int
+}
+
+func (r *cancellingReceiver) Event(int, map[string]string, json.RawMessage) error {
+	r.events++
+	r.cancel()
+	return nil
+}
+
+func (r *cancellingReceiver) Checkpoint(int, string) error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package zeroeventhubtest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// memoryAPI serves partitions of events with the index of an event as its cursor.
+type memoryAPI struct {
+	partitions [][]string
+}
+
+func (m memoryAPI) GetName() string {
+	return "memoryAPI"
+}
+
+func (m memoryAPI) GetPartitionCount() int {
+	return len(m.partitions)
+}
+
+func (m memoryAPI) FetchEvents(ctx context.Context, cursors []zeroeventhub.Cursor, pageSizeHint int, r zeroeventhub.EventReceiver, headers ...string) error {
+	if pageSizeHint == zeroeventhub.DefaultPageSize {
+		pageSizeHint = 5
+	}
+	for _, cursor := range cursors {
+		if cursor.PartitionID < 0 || cursor.PartitionID >= len(m.partitions) {
+			return zeroeventhub.ErrPartitionDoesntExist
+		}
+		events := m.partitions[cursor.PartitionID]
+		start := 0
+		switch cursor.Cursor {
+		case zeroeventhub.FirstCursor:
+		case zeroeventhub.LastCursor:
+			if len(events) > 0 {
+				start = len(events) - 1
+			}
+		default:
+			after, err := strconv.Atoi(cursor.Cursor)
+			if err != nil {
+				return zeroeventhub.NewAPIError("malformed cursor", http.StatusBadRequest)
+			}
+			start = after + 1
+		}
+		for i := start; i < len(events) && i < start+pageSizeHint; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := r.Event(cursor.PartitionID, map[string]string{"index": strconv.Itoa(i)}, []byte(events[i])); err != nil {
+				return err
+			}
+			if err := r.Checkpoint(cursor.PartitionID, strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestRunAPIConformance(t *testing.T) {
+	RunAPIConformance(t, func() zeroeventhub.API {
+		api := memoryAPI{partitions: make([][]string, 3)}
+		for i := 0; i < 23; i++ {
+			api.partitions[0] = append(api.partitions[0], fmt.Sprintf(`{"n":%d}`, i))
+		}
+		api.partitions[1] = []string{`"only"`}
+		return api
+	}, ConformanceOptions{
+		OutOfRangeCursor: "1000000",
+		Cursors:          zeroeventhub.NumericCursors,
+		StrictPageSize:   true,
+	})
+}
+
+func TestRunServerConformance(t *testing.T) {
+	api := memoryAPI{partitions: [][]string{{`"a"`, `"b"`, `"c"`}, {}, {`1`, `2`, `3`, `4`, `5`, `6`, `7`}}}
+	server := httptest.NewServer(zeroeventhub.Handler(nil, api))
+	defer server.Close()
+	RunServerConformance(t, func(partitionCount int) zeroeventhub.Client {
+		return zeroeventhub.NewClient(server.URL, partitionCount)
+	}, 3, ConformanceOptions{
+		OutOfRangeCursor: "1000000",
+		MalformedCursor:  "not a number",
+		Cursors:          zeroeventhub.NumericCursors,
+		StrictDecoding:   true,
+	})
+}
+
+func TestAssertOrdered(t *testing.T) {
+	require.True(t, AssertOrdered(t, nil, zeroeventhub.NumericCursors))
+	require.True(t, AssertOrdered(t, []string{"1", "2", "10"}, zeroeventhub.NumericCursors))
+
+	for _, cursors := range [][]string{{"1", "3", "2"}, {"1", "1"}, {"1", "opaque"}} {
+		mockT := &testing.T{}
+		require.False(t, AssertOrdered(mockT, cursors, zeroeventhub.NumericCursors), "%q", cursors)
+		require.True(t, mockT.Failed(), "%q", cursors)
+	}
+}
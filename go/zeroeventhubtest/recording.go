@@ -0,0 +1,80 @@
+package zeroeventhubtest
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+// ReceivedCall is a call to a RecordingReceiver.
+type ReceivedCall struct {
+	// Kind is zeroeventhub.LineEvent or zeroeventhub.LineCheckpoint.
+	Kind        zeroeventhub.LineKind
+	PartitionID int
+	// Headers and Data are set for events, Cursor for checkpoints.
+	Headers map[string]string
+	Data    json.RawMessage
+	Cursor  string
+	// Offset is the time of the call since the receiver was created.
+	Offset time.Duration
+}
+
+// RecordingReceiver is an EventReceiver recording every call in the order they arrive, with their time, to assert
+// how events and checkpoints of several partitions interleave, or when they arrive while streaming. It may be
+// called concurrently, e.g. by zeroeventhub.FetchEventsParallel. Create it with NewRecordingReceiver.
+type RecordingReceiver struct {
+	lock  sync.Mutex
+	start time.Time
+	calls []ReceivedCall
+}
+
+var _ zeroeventhub.EventReceiver = &RecordingReceiver{}
+
+// NewRecordingReceiver returns a RecordingReceiver measuring the offsets of calls from now.
+func NewRecordingReceiver() *RecordingReceiver {
+	return &RecordingReceiver{start: time.Now()}
+}
+
+func (r *RecordingReceiver) record(call ReceivedCall) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	call.Offset = time.Since(r.start)
+	r.calls = append(r.calls, call)
+}
+
+// Event records an event. The data is copied, as the client may reuse its buffer.
+func (r *RecordingReceiver) Event(partitionID int, headers map[string]string, data json.RawMessage) error {
+	r.record(ReceivedCall{
+		Kind:        zeroeventhub.LineEvent,
+		PartitionID: partitionID,
+		Headers:     headers,
+		Data:        append(json.RawMessage(nil), data...),
+	})
+	return nil
+}
+
+// Checkpoint records a checkpoint.
+func (r *RecordingReceiver) Checkpoint(partitionID int, cursor string) error {
+	r.record(ReceivedCall{Kind: zeroeventhub.LineCheckpoint, PartitionID: partitionID, Cursor: cursor})
+	return nil
+}
+
+// Calls returns the calls recorded so far, in order.
+func (r *RecordingReceiver) Calls() []ReceivedCall {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]ReceivedCall(nil), r.calls...)
+}
+
+// Checkpoints returns the checkpoints recorded so far, in order.
+func (r *RecordingReceiver) Checkpoints() []zeroeventhub.Cursor {
+	var cursors []zeroeventhub.Cursor
+	for _, call := range r.Calls() {
+		if call.Kind == zeroeventhub.LineCheckpoint {
+			cursors = append(cursors, zeroeventhub.Cursor{PartitionID: call.PartitionID, Cursor: call.Cursor})
+		}
+	}
+	return cursors
+}
@@ -0,0 +1,50 @@
+package zeroeventhubtest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zeroeventhub "github.com/vippsas/zeroeventhub/go"
+)
+
+func TestRecordingReceiver(t *testing.T) {
+	api := memoryAPI{partitions: [][]string{{`"a"`, `"b"`}, {`1`}}}
+	server := httptest.NewServer(zeroeventhub.Handler(nil, api))
+	defer server.Close()
+
+	r := NewRecordingReceiver()
+	client := zeroeventhub.NewClient(server.URL, 2).WithReusedEventData(true)
+	cursors := []zeroeventhub.Cursor{{PartitionID: 0, Cursor: zeroeventhub.FirstCursor}, {PartitionID: 1, Cursor: zeroeventhub.FirstCursor}}
+	require.NoError(t, client.FetchEvents(context.Background(), cursors, zeroeventhub.DefaultPageSize, r, "index"))
+
+	calls := r.Calls()
+	for i := range calls {
+		if i > 0 {
+			require.True(t, calls[i].Offset >= calls[i-1].Offset)
+		}
+		calls[i].Offset = 0
+	}
+	require.Equal(t, []ReceivedCall{
+		{Kind: zeroeventhub.LineEvent, PartitionID: 0, Headers: map[string]string{"index": "0"}, Data: json.RawMessage(`"a"`)},
+		{Kind: zeroeventhub.LineCheckpoint, PartitionID: 0, Cursor: "0"},
+		{Kind: zeroeventhub.LineEvent, PartitionID: 0, Headers: map[string]string{"index": "1"}, Data: json.RawMessage(`"b"`)},
+		{Kind: zeroeventhub.LineCheckpoint, PartitionID: 0, Cursor: "1"},
+		{Kind: zeroeventhub.LineEvent, PartitionID: 1, Headers: map[string]string{"index": "0"}, Data: json.RawMessage(`1`)},
+		{Kind: zeroeventhub.LineCheckpoint, PartitionID: 1, Cursor: "0"},
+	}, calls)
+	require.Equal(t, []zeroeventhub.Cursor{{PartitionID: 0, Cursor: "0"}, {PartitionID: 0, Cursor: "1"}, {PartitionID: 1, Cursor: "0"}}, r.Checkpoints())
+}
+
+func TestRecordingReceiverOffsets(t *testing.T) {
+	r := NewRecordingReceiver()
+	require.NoError(t, r.Event(0, nil, json.RawMessage(`{}`)))
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, r.Checkpoint(0, "1"))
+	calls := r.Calls()
+	require.Len(t, calls, 2)
+	require.True(t, calls[1].Offset-calls[0].Offset >= 20*time.Millisecond, calls)
+}